@@ -0,0 +1,42 @@
+package api
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Accounts(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "save_account":
+			res = service.SaveAccount(params)
+		case "delaccount":
+			res = service.DelAccount(params)
+		case "accountstatus":
+			res = service.ChangeAccountStatus(params)
+		case "resetpaircode":
+			res = service.ResetPairCode(params)
+		case "binddevice":
+			res = service.BindAccountDevice(params, username)
+		case "unbinddevices":
+			res = service.UnbindAccountDevices(params)
+		case "movedevices":
+			res = service.MoveAccountDevices(params, username)
+		case "replacedevice":
+			res = service.ReplaceAccountDevice(params, username)
+		}
+	}
+	c.JSON(200, res)
+}
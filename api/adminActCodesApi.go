@@ -0,0 +1,55 @@
+package api
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ActCodes(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "gen_codes":
+			res = service.GenActCodes(params, username)
+		case "delcodes":
+			res = service.DelActCodes(params)
+		case "disablecodes":
+			res = service.SetActCodesStatus(params, 0)
+		case "enablecodes":
+			res = service.SetActCodesStatus(params, 1)
+		}
+	}
+	c.JSON(200, res)
+}
+
+func ExportActCodes(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	batch := c.Query("batch")
+	keywords := c.Query("keywords")
+	if !until.IsSafe(batch) || !until.IsSafe(keywords) {
+		c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"})
+		return
+	}
+	data, err := service.ExportActCodes(batch, c.Query("state"), keywords)
+	if err != nil {
+		c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "导出失败:" + err.Error(), Type: "danger"})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=act_codes.csv")
+	c.Data(200, "text/csv; charset=utf-8", data)
+}
@@ -4,20 +4,75 @@ import (
 	"go-iptv/dto"
 	"go-iptv/service"
 	"go-iptv/until"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func Admins(c *gin.Context) {
-	_, ok := until.GetAuthName(c)
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "submit":
+			res = service.Admins(params, username)
+			// 修改用户名后按原有效期重新签发 token
+			if newName, ok := res.Data.(string); ok && newName != "" {
+				auth, _ := c.Get("auth")
+				exp := time.Until(time.Unix(until.GetAuthExp(auth.(jwt.MapClaims)), 0))
+				token, _ := until.GenerateJWT(newName, until.GetAuthRole(c), until.GetAuthSid(c), exp)
+				until.SetAuthCookie(c, token, int(exp.Seconds()))
+				res.Data = nil
+			}
+		case "totpsetup":
+			res = service.TotpSetup(username)
+		case "totpenable":
+			res = service.TotpEnable(params, username)
+		case "totpdisable":
+			res = service.TotpDisable(params, username)
+		case "totprecovery":
+			res = service.TotpRecovery(params, username)
+		case "revokesessions":
+			all := until.HasPerm(until.GetAuthRole(c), until.PermAdmins)
+			res = service.RevokeSessions(params, username, until.GetAuthSid(c), all, false)
+		case "revokeothers":
+			res = service.RevokeSessions(params, username, until.GetAuthSid(c), false, true)
+		}
+	}
+	c.JSON(200, res)
+}
+
+func ManageAdmins(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
 	if !ok {
 		c.JSON(200, dto.NewAdminRedirectDto())
 		return
 	}
 	c.Request.ParseForm()
 	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
 
-	c.JSON(200, service.Admins(params))
+	for k := range params {
+		switch k {
+		case "addadmin":
+			res = service.AddAdmin(params, username)
+		case "disableadmins":
+			res = service.SetAdminsStatus(params, 0, username)
+		case "enableadmins":
+			res = service.SetAdminsStatus(params, 1, username)
+		case "resetadmin":
+			res = service.ResetAdmin(params, username)
+		}
+	}
+	c.JSON(200, res)
 }
 
 func UpdataCheckWeb(c *gin.Context) {
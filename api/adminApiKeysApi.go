@@ -0,0 +1,32 @@
+package api
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApiKeys(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "createapikey":
+			res = service.CreateApiKey(params, username)
+		case "revokeapikeys":
+			res = service.RevokeApiKeys(params, username)
+		case "delapikeys":
+			res = service.DelApiKeys(params)
+		}
+	}
+	c.JSON(200, res)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AuditLogs(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "submitauditdays":
+			res = service.SetAuditLogDays(params)
+		}
+	}
+	c.JSON(200, res)
+}
+
+func ExportAuditLogs(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	admin := c.Query("admin")
+	keywords := c.Query("keywords")
+	if !until.IsSafe(admin) || !until.IsSafe(keywords) {
+		c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"})
+		return
+	}
+	data, err := service.ExportAuditLogs(admin, keywords, c.Query("result"))
+	if err != nil {
+		c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "导出失败:" + err.Error(), Type: "danger"})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=audit_logs.csv")
+	c.Data(200, "text/csv; charset=utf-8", data)
+}
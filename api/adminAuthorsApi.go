@@ -25,13 +25,15 @@ func Authors(c *gin.Context) {
 		case "submitauthor":
 			res = service.SubmitAuthorWithDays(params, username)
 		case "submitforbidden":
-			res = service.ForbiddenUser(params)
+			res = service.ForbiddenUser(params, username)
 		case "submitdelonedaybefor":
 			res = service.DelUnAuthorOneDayBefore()
 		case "submitdel":
 			res = service.DelUsers(params)
 		case "submitdelall":
 			res = service.DelAllUsers()
+		case "submitclearflags":
+			res = service.ClearUserFlags(params)
 		}
 
 	}
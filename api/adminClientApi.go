@@ -34,6 +34,12 @@ func Client(c *gin.Context) {
 			res = service.SetAppInfo(params)
 		case "submittipset":
 			res = service.SetTipSet(params)
+		case "submittrialset":
+			res = service.SetTrialSet(params)
+		case "submitexpiryset":
+			res = service.SetExpirySet(params)
+		case "runexpiry":
+			res = service.RunExpiry()
 		}
 	}
 	c.JSON(200, res)
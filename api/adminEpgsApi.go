@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/xml"
 	"go-iptv/dto"
 	"go-iptv/service"
 	"go-iptv/until"
@@ -83,3 +84,111 @@ func UploadLogo(c *gin.Context) {
 	}
 	c.JSON(200, service.UploadLogo(c))
 }
+
+func EpgAlias(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "save_alias":
+			res = service.SaveAlias(params)
+		case "delalias":
+			res = service.DelAlias(params)
+		case "delaliases":
+			res = service.DelAliases(params)
+		case "mergealias":
+			res = service.MergeAlias(params)
+		}
+	}
+	c.JSON(200, res)
+}
+
+func ImportAlias(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.JSON(200, service.ImportAliasFile(c))
+}
+
+func ExportAlias(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	data, err := until.ExportAliasJson()
+	if err != nil {
+		c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "导出失败:" + err.Error(), Type: "danger"})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=alias.json")
+	c.Data(200, "application/json; charset=utf-8", data)
+}
+
+func EpgCustom(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "save_custom":
+			res = service.SaveEpgCustom(params)
+		case "delcustom":
+			res = service.DelEpgCustom(params)
+		case "delcustoms":
+			res = service.DelEpgCustoms(params)
+		case "customstatus":
+			res = service.ChangeEpgCustomStatus(params)
+		}
+	}
+	c.JSON(200, res)
+}
+
+func ImportEpgCustom(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.JSON(200, service.ImportEpgCustomFile(c))
+}
+
+func ExportEpgCustom(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	if c.Query("format") == "xml" {
+		data, err := xml.MarshalIndent(until.ExportEpgCustomXml(), "", "  ")
+		if err != nil {
+			c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "导出失败:" + err.Error(), Type: "danger"})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename=epg_custom.xml")
+		c.Data(200, "application/xml; charset=utf-8", append([]byte(xml.Header), data...))
+		return
+	}
+	data, err := until.ExportEpgCustomCsv()
+	if err != nil {
+		c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "导出失败:" + err.Error(), Type: "danger"})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=epg_custom.csv")
+	c.Data(200, "text/csv; charset=utf-8", data)
+}
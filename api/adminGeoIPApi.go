@@ -0,0 +1,39 @@
+package api
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GeoIP(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "ip_remote":
+			res = service.SetIPRemote(params)
+		case "submittestip":
+			res = service.TestGeoIP(params)
+		}
+	}
+	c.JSON(200, res)
+}
+
+func GeoIPUpload(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.JSON(200, service.UploadGeoIP(c))
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func IPPolicies(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "savepolicy":
+			res = service.SaveIPPolicy(params)
+		case "dryrunpolicy":
+			res = service.DryRunIPPolicy(params)
+		case "delpolicies":
+			res = service.DelIPPolicies(params)
+		case "disablepolicies":
+			res = service.SetIPPoliciesStatus(params, 0)
+		case "enablepolicies":
+			res = service.SetIPPoliciesStatus(params, 1)
+		}
+	}
+	c.JSON(200, res)
+}
@@ -4,20 +4,28 @@ import (
 	"go-iptv/dto"
 	"go-iptv/service"
 	"go-iptv/until"
+	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 func Login(c *gin.Context) {
 	username := c.PostForm("username")
-	password := until.HashPassword(c.PostForm("password"))
+	password := c.PostForm("password")
 	remember := c.PostForm("rememberpass")
 
 	reMe := false
 	if remember == "on" || remember == "true" || remember == "1" {
 		reMe = true
 	}
-	res := service.AdminLogin(username, password, reMe)
+	// 连续登录失败后锁定账号
+	if wait := service.LoginLocked(username, c.ClientIP()); wait > 0 {
+		c.Header("Retry-After", strconv.FormatInt(wait, 10))
+		c.JSON(http.StatusTooManyRequests, dto.ReturnJsonDto{Code: 0, Msg: "登录失败次数过多，请" + service.FormatWait(wait) + "后重试", Type: "danger"})
+		return
+	}
+	res := service.AdminLogin(username, password, c.PostForm("totp"), reMe, c.Request.UserAgent(), c.ClientIP())
 
 	token, ok := res.Data.(string)
 	if !ok || token == "" {
@@ -26,15 +34,22 @@ func Login(c *gin.Context) {
 	}
 
 	if reMe {
-		c.SetCookie("token", token, 7*24*3600, "/", "", false, true)
+		until.SetAuthCookie(c, token, 7*24*3600)
 	} else {
-		c.SetCookie("token", token, 2*3600, "/", "", false, true)
+		until.SetAuthCookie(c, token, 2*3600)
 	}
 	res.Data = nil
 	c.JSON(200, res)
 }
 
 func Logout(c *gin.Context) {
-	c.SetCookie("token", "", -1, "/", "", false, true)
+	// 吊销服务端会话，旧 token 立即失效
+	if tokenString, err := c.Cookie("token"); err == nil {
+		if claims, err, _ := until.VerifyJWT(tokenString); err == nil {
+			sid, _ := claims["sid"].(string)
+			service.RevokeSession(sid)
+		}
+	}
+	until.SetAuthCookie(c, "", -1)
 	c.JSON(200, dto.ReturnJsonDto{Code: 1, Msg: "退出登录成功", Type: "success"})
 }
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RateLimits(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "submitratelimit":
+			res = service.SetRateLimit(params)
+		case "unblock":
+			res = service.Unblock(params, false)
+		case "unblockall":
+			res = service.Unblock(params, true)
+		}
+	}
+	c.JSON(200, res)
+}
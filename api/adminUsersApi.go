@@ -4,6 +4,7 @@ import (
 	"go-iptv/dao"
 	"go-iptv/dto"
 	"go-iptv/models"
+	"go-iptv/service"
 	"go-iptv/until"
 	"strconv"
 	"time"
@@ -12,7 +13,7 @@ import (
 )
 
 func EditUsers(c *gin.Context) {
-	_, ok := until.GetAuthName(c)
+	username, ok := until.GetAuthName(c)
 	if !ok {
 		c.JSON(200, dto.NewAdminRedirectDto())
 		return
@@ -22,7 +23,7 @@ func EditUsers(c *gin.Context) {
 	for k := range params {
 		switch k {
 		case "submitdel":
-			ids := c.PostFormArray("ids[]")
+			ids := service.BulkUserNames(params)
 			if len(ids) == 0 {
 				c.JSON(200, gin.H{"code": 0, "msg": "请选择要删除的用户账号", "type": "danger"})
 				return
@@ -31,7 +32,7 @@ func EditUsers(c *gin.Context) {
 			c.JSON(200, gin.H{"code": 1, "msg": "已删除选中的用户账号", "type": "success"})
 			return
 		case "submitmodifymarks":
-			ids := c.PostFormArray("ids[]")
+			ids := service.BulkUserNames(params)
 			if len(ids) == 0 {
 				c.JSON(200, gin.H{"code": 0, "msg": "请选择要修改备注的用户账号", "type": "danger"})
 				return
@@ -41,18 +42,20 @@ func EditUsers(c *gin.Context) {
 			c.JSON(200, gin.H{"code": 1, "msg": "已修改选中的用户账号的备注", "type": "success"})
 			return
 		case "submitforbidden":
-			ids := c.PostFormArray("ids[]")
+			ids := service.BulkUserNames(params)
 			if len(ids) == 0 {
 				c.JSON(200, gin.H{"code": 0, "msg": "请选择要取消授权的用户账号", "type": "danger"})
 				return
 			}
 			dao.DB.Model(&models.IptvUser{}).Where("name in (?)", ids).Updates(map[string]interface{}{
-				"status": -1,
+				"status":     -1,
+				"author":     username,
+				"authortime": time.Now().Unix(),
 			})
 			c.JSON(200, gin.H{"code": 1, "msg": "已取消选中的用户账号的授权", "type": "success"})
 			return
 		case "e_meals":
-			ids := c.PostFormArray("ids[]")
+			ids := service.BulkUserNames(params)
 			if len(ids) == 0 {
 				c.JSON(200, gin.H{"code": 0, "msg": "请选择要修改套餐的用户账号", "type": "danger"})
 				return
@@ -71,13 +74,15 @@ func EditUsers(c *gin.Context) {
 			}
 
 			dao.DB.Model(&models.IptvUser{}).Where("name in (?)", ids).Updates(map[string]interface{}{
-				"meal":   meal.ID,
-				"status": 999,
+				"meal":       meal.ID,
+				"status":     999,
+				"author":     username,
+				"authortime": time.Now().Unix(),
 			})
 			c.JSON(200, gin.H{"code": 1, "msg": "已修改选中的用户账号的套餐", "type": "success"})
 			return
 		case "submitextend":
-			ids := c.PostFormArray("ids[]")
+			ids := service.BulkUserNames(params)
 			if len(ids) == 0 {
 				c.JSON(200, gin.H{"code": 0, "msg": "请选择要延期的用户账号", "type": "danger"})
 				return
@@ -109,8 +114,10 @@ func EditUsers(c *gin.Context) {
 				}
 
 				dao.DB.Model(&models.IptvUser{}).Where("name = ?", user.Name).Updates(map[string]interface{}{
-					"exp":    newExp,
-					"status": 1,
+					"exp":        newExp,
+					"status":     1,
+					"author":     username,
+					"authortime": time.Now().Unix(),
 				})
 			}
 
@@ -119,3 +126,57 @@ func EditUsers(c *gin.Context) {
 		}
 	}
 }
+
+// 设备分页查询接口，筛选参数同设备列表页面
+func QueryUsers(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	recCounts, _ := strconv.ParseInt(c.DefaultQuery("recCounts", "20"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	if recCounts <= 0 || recCounts > 1000 {
+		recCounts = 20
+	}
+	filter := service.ParseUserFilter(c.Request.URL.Query())
+	users, total, err := service.QueryUsers(filter, page, recCounts)
+	if err != nil {
+		c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "查询失败:" + err.Error(), Type: "danger"})
+		return
+	}
+	c.JSON(200, dto.ReturnJsonDto{Code: 1, Msg: "ok", Type: "success", Data: gin.H{
+		"total":     total,
+		"page":      page,
+		"pagecount": (total + recCounts - 1) / recCounts,
+		"filter":    filter,
+		"users":     users,
+	}})
+}
+
+func ExportUsers(c *gin.Context) {
+	_, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	data, err := service.ExportUsers(service.ParseUserFilter(c.Request.URL.Query()))
+	if err != nil {
+		c.JSON(200, dto.ReturnJsonDto{Code: 0, Msg: "导出失败:" + err.Error(), Type: "danger"})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=users.csv")
+	c.Data(200, "text/csv; charset=utf-8", data)
+}
+
+func ImportUsers(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.JSON(200, service.ImportUsers(c, username))
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Webhooks(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	c.Request.ParseForm()
+	params := c.Request.PostForm
+	var res dto.ReturnJsonDto
+
+	for k := range params {
+		switch k {
+		case "savewebhook":
+			res = service.SaveWebhook(params, username)
+		case "delwebhooks":
+			res = service.DelWebhooks(params)
+		case "enablewebhooks":
+			res = service.WebhookChangeStatus(params, 1)
+		case "disablewebhooks":
+			res = service.WebhookChangeStatus(params, 0)
+		case "testwebhooks":
+			res = service.TestWebhooks(params, username)
+		case "resenddeliveries":
+			res = service.ResendWebhookDeliveries(params)
+		}
+	}
+	c.JSON(200, res)
+}
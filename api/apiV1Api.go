@@ -0,0 +1,99 @@
+package api
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/until"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 开放接口请求参数，支持 JSON 和表单
+func apiParams(c *gin.Context) (url.Values, bool) {
+	if strings.HasPrefix(c.ContentType(), "application/json") {
+		raw, _ := io.ReadAll(c.Request.Body)
+		params, err := until.JsonToValues(raw)
+		if err != nil {
+			apiFail(c, http.StatusBadRequest, "请求内容不是合法的 JSON 对象")
+			return nil, false
+		}
+		return params, true
+	}
+	c.Request.ParseForm()
+	return c.Request.PostForm, true
+}
+
+func apiFail(c *gin.Context, status int, msg string) {
+	c.JSON(status, dto.ReturnJsonDto{Code: 0, Msg: msg, Type: "danger"})
+}
+
+func apiOK(c *gin.Context, status int, data interface{}) {
+	if status == http.StatusNoContent {
+		c.Status(status)
+		return
+	}
+	c.JSON(status, dto.ReturnJsonDto{Code: 1, Msg: "ok", Type: "success", Data: data})
+}
+
+// 服务返回结果转为 HTTP 状态码，失败默认为 422
+func apiResult(c *gin.Context, res dto.ReturnJsonDto, okStatus int) {
+	if res.Code < 1 {
+		c.JSON(http.StatusUnprocessableEntity, res)
+		return
+	}
+	if okStatus == http.StatusNoContent {
+		c.Status(okStatus)
+		return
+	}
+	c.JSON(okStatus, res)
+}
+
+// 路由中的数字ID，格式错误返回 400
+func apiParamID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		apiFail(c, http.StatusBadRequest, "ID 格式错误")
+		return 0, false
+	}
+	return id, true
+}
+
+// 按ID查询记录，不存在返回 404
+func apiFind(c *gin.Context, model interface{}, id int64) bool {
+	if err := dao.DB.Where("id = ?", id).First(model).Error; err != nil {
+		apiFail(c, http.StatusNotFound, "记录不存在")
+		return false
+	}
+	return true
+}
+
+// enable 参数：1/true/on 启用，0/false/off 停用，未传返回 -1
+func apiEnable(c *gin.Context, params url.Values) (int64, bool) {
+	if _, ok := params["enable"]; !ok {
+		return -1, true
+	}
+	switch strings.ToLower(params.Get("enable")) {
+	case "1", "true", "on":
+		return 1, true
+	case "0", "false", "off":
+		return 0, true
+	}
+	apiFail(c, http.StatusBadRequest, "enable 只能为 true 或 false")
+	return -1, false
+}
+
+// 复制接口字段到服务使用的参数名，未传的字段不复制
+func apiMapParams(params url.Values, fields map[string]string) url.Values {
+	values := url.Values{}
+	for from, to := range fields {
+		if v, ok := params[from]; ok {
+			values[to] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"go-iptv/dao"
+	"go-iptv/models"
+	"go-iptv/service"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var apiListFields = map[string]string{
+	"name":         "listname",
+	"url":          "listurl",
+	"ua":           "listua",
+	"autocategory": "autocategory",
+	"autogroup":    "autogroup",
+	"ku9":          "ku9",
+	"repeat":       "repeat",
+	"rename":       "rename",
+}
+
+var apiCategoryFields = map[string]string{
+	"name":      "caname",
+	"ua":        "caua",
+	"auto_type": "autoType",
+	"ku9":       "ku9",
+	"proxy":     "caproxy",
+	"rename":    "rename",
+}
+
+func ApiV1Lists(c *gin.Context) {
+	var list []models.IptvCategoryList
+	if err := dao.DB.Model(&models.IptvCategoryList{}).Order("id").Find(&list).Error; err != nil {
+		apiFail(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+	apiOK(c, http.StatusOK, list)
+}
+
+func ApiV1List(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var list models.IptvCategoryList
+	if apiFind(c, &list, id) {
+		apiOK(c, http.StatusOK, list)
+	}
+}
+
+// 新增直播源，PUT 时按新参数重新导入
+func ApiV1SaveList(c *gin.Context) {
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	values := apiMapParams(params, apiListFields)
+	status := http.StatusCreated
+	if c.Param("id") != "" {
+		id, ok := apiParamID(c)
+		if !ok || !apiFind(c, &models.IptvCategoryList{}, id) {
+			return
+		}
+		values.Set("clId", c.Param("id"))
+		status = http.StatusOK
+	}
+	res := service.AddList(values)
+	if res.Code < 1 {
+		apiResult(c, res, status)
+		return
+	}
+	var list models.IptvCategoryList
+	dao.DB.Model(&models.IptvCategoryList{}).Where("name = ?", values.Get("listname")).First(&list)
+	apiOK(c, status, list)
+}
+
+func ApiV1PatchList(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var list models.IptvCategoryList
+	if !apiFind(c, &list, id) {
+		return
+	}
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	enable, ok := apiEnable(c, params)
+	if !ok {
+		return
+	}
+	if enable >= 0 && enable != list.Enable {
+		if res := service.CategoryListChangeStatus(url.Values{"categoryListStatus": {c.Param("id")}}); res.Code < 1 {
+			apiResult(c, res, http.StatusOK)
+			return
+		}
+	}
+	dao.DB.Where("id = ?", id).First(&list)
+	apiOK(c, http.StatusOK, list)
+}
+
+func ApiV1DelList(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvCategoryList{}, id) {
+		return
+	}
+	apiResult(c, service.DelList(url.Values{"dellist": {c.Param("id")}}), http.StatusNoContent)
+}
+
+func ApiV1RefreshList(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvCategoryList{}, id) {
+		return
+	}
+	apiResult(c, service.UpdateList(url.Values{"updatelist": {c.Param("id")}}), http.StatusOK)
+}
+
+func ApiV1RefreshLists(c *gin.Context) {
+	res := service.UpdateListAll()
+	if res.Code < 1 {
+		c.JSON(http.StatusConflict, res)
+		return
+	}
+	c.JSON(http.StatusAccepted, res)
+}
+
+func ApiV1Categories(c *gin.Context) {
+	db := dao.DB.Model(&models.IptvCategory{})
+	if listId := c.Query("list_id"); listId != "" {
+		id, err := strconv.ParseInt(listId, 10, 64)
+		if err != nil {
+			apiFail(c, http.StatusBadRequest, "list_id 格式错误")
+			return
+		}
+		db = db.Where("list_id = ?", id)
+	}
+	var list []models.IptvCategory
+	if err := db.Order("sort").Find(&list).Error; err != nil {
+		apiFail(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+	apiOK(c, http.StatusOK, list)
+}
+
+func ApiV1Category(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var category models.IptvCategory
+	if apiFind(c, &category, id) {
+		apiOK(c, http.StatusOK, category)
+	}
+}
+
+func ApiV1CategoryChannels(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvCategory{}, id) {
+		return
+	}
+	apiResult(c, service.CaGetChannels(url.Values{"caId": {c.Param("id")}}), http.StatusOK)
+}
+
+// 新增或修改分类，rules 按 auto_type 对应到正则或EPG规则
+func ApiV1SaveCategory(c *gin.Context) {
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	values := apiMapParams(params, apiCategoryFields)
+	if values.Get("autoType") == "autoEpgs" {
+		values.Set("ruleEpgs", params.Get("rules"))
+	} else {
+		values.Set("rulesRe", params.Get("rules"))
+	}
+	status := http.StatusCreated
+	if c.Param("id") != "" {
+		id, ok := apiParamID(c)
+		if !ok || !apiFind(c, &models.IptvCategory{}, id) {
+			return
+		}
+		values.Set("caId", c.Param("id"))
+		status = http.StatusOK
+	}
+	res := service.SaveCategory(values)
+	if res.Code < 1 {
+		apiResult(c, res, status)
+		return
+	}
+	var category models.IptvCategory
+	dao.DB.Model(&models.IptvCategory{}).Where("name = ?", values.Get("caname")).First(&category)
+	apiOK(c, status, category)
+}
+
+func ApiV1PatchCategory(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var category models.IptvCategory
+	if !apiFind(c, &category, id) {
+		return
+	}
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	enable, ok := apiEnable(c, params)
+	if !ok {
+		return
+	}
+	if enable >= 0 && enable != category.Enable {
+		if res := service.CategoryChangeStatus(url.Values{"categoryStatus": {c.Param("id")}}); res.Code < 1 {
+			apiResult(c, res, http.StatusOK)
+			return
+		}
+	}
+	dao.DB.Where("id = ?", id).First(&category)
+	apiOK(c, http.StatusOK, category)
+}
+
+func ApiV1DelCategory(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvCategory{}, id) {
+		return
+	}
+	apiResult(c, service.DelCa(url.Values{"delca": {c.Param("id")}}), http.StatusNoContent)
+}
+
+func ApiV1Channel(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var channel models.IptvChannel
+	if apiFind(c, &channel, id) {
+		apiOK(c, http.StatusOK, channel)
+	}
+}
+
+// 修改频道名称、地址、EPG 或启用状态，未传的字段保持不变
+func ApiV1PatchChannel(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var channel models.IptvChannel
+	if !apiFind(c, &channel, id) {
+		return
+	}
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	enable, ok := apiEnable(c, params)
+	if !ok {
+		return
+	}
+
+	_, hasName := params["name"]
+	_, hasUrl := params["url"]
+	_, hasEpg := params["epg_id"]
+	if hasName || hasUrl || hasEpg {
+		values := url.Values{
+			"chId":   {c.Param("id")},
+			"chname": {channel.Name},
+			"chURL":  {channel.Url},
+		}
+		if hasName {
+			values.Set("chname", params.Get("name"))
+		}
+		if hasUrl {
+			values.Set("chURL", params.Get("url"))
+		}
+		if hasEpg {
+			if e := params.Get("epg_id"); e != "0" {
+				values.Set("e_id", e)
+			}
+		} else if channel.EId > 0 {
+			values.Set("e_id", strconv.FormatInt(channel.EId, 10))
+		}
+		if res := service.SaveChannelsOne(values); res.Code < 1 {
+			apiResult(c, res, http.StatusOK)
+			return
+		}
+	}
+	if enable >= 0 && enable != channel.Status {
+		if res := service.ChannelsChangeStatus(url.Values{"channelsStatus": {c.Param("id")}}); res.Code < 1 {
+			apiResult(c, res, http.StatusOK)
+			return
+		}
+	}
+	dao.DB.Where("id = ?", id).First(&channel)
+	apiOK(c, http.StatusOK, channel)
+}
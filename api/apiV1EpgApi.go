@@ -0,0 +1,112 @@
+package api
+
+import (
+	"go-iptv/dao"
+	"go-iptv/models"
+	"go-iptv/service"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var apiEpgSourceFields = map[string]string{
+	"name": "epgfromname",
+	"url":  "epgfromurl",
+	"ua":   "epgfromua",
+	"type": "epgfromtype",
+}
+
+func ApiV1EpgSources(c *gin.Context) {
+	var list []models.IptvEpgList
+	if err := dao.DB.Model(&models.IptvEpgList{}).Order("id").Find(&list).Error; err != nil {
+		apiFail(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+	apiOK(c, http.StatusOK, list)
+}
+
+func ApiV1EpgSource(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var epgList models.IptvEpgList
+	if apiFind(c, &epgList, id) {
+		apiOK(c, http.StatusOK, epgList)
+	}
+}
+
+// 新增或修改EPG源，保存后立即拉取一次
+func ApiV1SaveEpgSource(c *gin.Context) {
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	values := apiMapParams(params, apiEpgSourceFields)
+	status := http.StatusCreated
+	if c.Param("id") != "" {
+		id, ok := apiParamID(c)
+		if !ok || !apiFind(c, &models.IptvEpgList{}, id) {
+			return
+		}
+		values.Set("eid", c.Param("id"))
+		status = http.StatusOK
+	}
+	res := service.EpgImport(values)
+	if res.Code < 1 {
+		apiResult(c, res, status)
+		return
+	}
+	var epgList models.IptvEpgList
+	dao.DB.Model(&models.IptvEpgList{}).Where("url = ?", strings.TrimSpace(values.Get("epgfromurl"))).Order("id desc").First(&epgList)
+	apiOK(c, status, epgList)
+}
+
+func ApiV1PatchEpgSource(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var epgList models.IptvEpgList
+	if !apiFind(c, &epgList, id) {
+		return
+	}
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	enable, ok := apiEnable(c, params)
+	if !ok {
+		return
+	}
+	if enable >= 0 && enable != epgList.Status {
+		if res := service.ChangeListStatus(url.Values{"change_status": {c.Param("id")}}); res.Code < 1 {
+			apiResult(c, res, http.StatusOK)
+			return
+		}
+	}
+	dao.DB.Where("id = ?", id).First(&epgList)
+	apiOK(c, http.StatusOK, epgList)
+}
+
+func ApiV1DelEpgSource(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvEpgList{}, id) {
+		return
+	}
+	apiResult(c, service.DelEpgList(url.Values{"dellist": {c.Param("id")}}), http.StatusNoContent)
+}
+
+func ApiV1RefreshEpgSource(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvEpgList{}, id) {
+		return
+	}
+	apiResult(c, service.UpdateEpgList(url.Values{"updatelist": {c.Param("id")}}), http.StatusOK)
+}
+
+func ApiV1RefreshEpgSources(c *gin.Context) {
+	apiResult(c, service.UpdateEpgListAll(), http.StatusOK)
+}
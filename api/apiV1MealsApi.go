@@ -0,0 +1,190 @@
+package api
+
+import (
+	"go-iptv/dao"
+	"go-iptv/models"
+	"go-iptv/service"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApiV1Meals(c *gin.Context) {
+	var list []models.IptvMeals
+	if err := dao.DB.Model(&models.IptvMeals{}).Order("id").Find(&list).Error; err != nil {
+		apiFail(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+	apiOK(c, http.StatusOK, list)
+}
+
+func ApiV1Meal(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var meal models.IptvMeals
+	if apiFind(c, &meal, id) {
+		apiOK(c, http.StatusOK, meal)
+	}
+}
+
+// 新增或修改套餐，category_ids 为包含的分类
+func ApiV1SaveMeal(c *gin.Context) {
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	values := url.Values{"mealName": {params.Get("name")}, "ids[]": params["category_ids"]}
+	status := http.StatusCreated
+	if c.Param("id") != "" {
+		id, ok := apiParamID(c)
+		if !ok || !apiFind(c, &models.IptvMeals{}, id) {
+			return
+		}
+		values.Set("mealId", c.Param("id"))
+		status = http.StatusOK
+	}
+	res := service.MealsSubmit(values)
+	if res.Code < 1 {
+		apiResult(c, res, status)
+		return
+	}
+	var meal models.IptvMeals
+	if status == http.StatusCreated {
+		dao.DB.Model(&models.IptvMeals{}).Where("name = ?", values.Get("mealName")).Order("id desc").First(&meal)
+	} else {
+		dao.DB.Model(&models.IptvMeals{}).Where("id = ?", values.Get("mealId")).First(&meal)
+	}
+	apiOK(c, status, meal)
+}
+
+func ApiV1PatchMeal(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok {
+		return
+	}
+	var meal models.IptvMeals
+	if !apiFind(c, &meal, id) {
+		return
+	}
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	enable, ok := apiEnable(c, params)
+	if !ok {
+		return
+	}
+	if enable >= 0 && enable != meal.Status {
+		if res := service.MealsChangeStatus(url.Values{"change_status": {c.Param("id")}}); res.Code < 1 {
+			apiResult(c, res, http.StatusOK)
+			return
+		}
+	}
+	dao.DB.Where("id = ?", id).First(&meal)
+	apiOK(c, http.StatusOK, meal)
+}
+
+func ApiV1DelMeal(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvMeals{}, id) {
+		return
+	}
+	apiResult(c, service.MealsDel(url.Values{"delmeal": {c.Param("id")}}), http.StatusNoContent)
+}
+
+func ApiV1MealTokens(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvMeals{}, id) {
+		return
+	}
+	tokens, err := service.GetMealTokens(id)
+	if err != nil {
+		apiFail(c, http.StatusInternalServerError, "获取token列表失败: "+err.Error())
+		return
+	}
+	apiOK(c, http.StatusOK, tokens)
+}
+
+func ApiV1CreateMealToken(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvMeals{}, id) {
+		return
+	}
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	var expireDays int64
+	if v := params.Get("expire_days"); v != "" {
+		days, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || days < 0 {
+			apiFail(c, http.StatusBadRequest, "有效期天数格式错误")
+			return
+		}
+		expireDays = days
+	}
+	token, err := service.CreateMealToken(id, params.Get("remark"), expireDays)
+	if err != nil {
+		apiFail(c, http.StatusInternalServerError, "创建token失败: "+err.Error())
+		return
+	}
+	apiOK(c, http.StatusCreated, token)
+}
+
+// 修改 token 状态或备注
+func ApiV1PatchMealToken(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvMealToken{}, id) {
+		return
+	}
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	enable, ok := apiEnable(c, params)
+	if !ok {
+		return
+	}
+	values := url.Values{"token_id": {c.Param("id")}, "remark": {params.Get("remark")}}
+	if enable >= 0 {
+		values.Set("status", strconv.FormatInt(enable, 10))
+	}
+	if res := service.UpdateMealToken(values); res.Code < 1 {
+		apiResult(c, res, http.StatusOK)
+		return
+	}
+	var token models.IptvMealToken
+	dao.DB.Where("id = ?", id).First(&token)
+	apiOK(c, http.StatusOK, token)
+}
+
+func ApiV1ExtendMealToken(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvMealToken{}, id) {
+		return
+	}
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	res := service.ExtendTokenAPI(url.Values{"token_id": {c.Param("id")}, "extend_days": {params.Get("days")}})
+	if res.Code < 1 {
+		apiResult(c, res, http.StatusOK)
+		return
+	}
+	var token models.IptvMealToken
+	dao.DB.Where("id = ?", id).First(&token)
+	apiOK(c, http.StatusOK, token)
+}
+
+func ApiV1DelMealToken(c *gin.Context) {
+	id, ok := apiParamID(c)
+	if !ok || !apiFind(c, &models.IptvMealToken{}, id) {
+		return
+	}
+	apiResult(c, service.DeleteMealTokenAPI(url.Values{"token_id": {c.Param("id")}}), http.StatusNoContent)
+}
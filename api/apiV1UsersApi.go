@@ -0,0 +1,97 @@
+package api
+
+import (
+	"go-iptv/dao"
+	"go-iptv/models"
+	"go-iptv/service"
+	"go-iptv/until"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 设备分页查询，筛选参数同设备列表页面
+func ApiV1Users(c *gin.Context) {
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	size, _ := strconv.ParseInt(c.DefaultQuery("size", "20"), 10, 64)
+	if page < 1 {
+		page = 1
+	}
+	if size <= 0 || size > 1000 {
+		size = 20
+	}
+	filter := service.ParseUserFilter(c.Request.URL.Query())
+	users, total, err := service.QueryUsers(filter, page, size)
+	if err != nil {
+		apiFail(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+	apiOK(c, http.StatusOK, gin.H{
+		"total":     total,
+		"page":      page,
+		"pagecount": (total + size - 1) / size,
+		"users":     users,
+	})
+}
+
+func ApiV1User(c *gin.Context) {
+	var user models.IptvUser
+	if err := dao.DB.Where("name = ?", c.Param("name")).First(&user).Error; err != nil {
+		apiFail(c, http.StatusNotFound, "设备不存在")
+		return
+	}
+	apiOK(c, http.StatusOK, user)
+}
+
+// 授权设备，days 为空或0时永久授权
+func ApiV1AuthorizeUsers(c *gin.Context) {
+	username, _ := until.GetAuthName(c)
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	if len(params["names"]) == 0 {
+		apiFail(c, http.StatusBadRequest, "请传入设备 names")
+		return
+	}
+	mealId, err := strconv.ParseInt(params.Get("meal_id"), 10, 64)
+	if err != nil {
+		apiFail(c, http.StatusBadRequest, "meal_id 格式错误")
+		return
+	}
+	if err := dao.DB.Where("id = ?", mealId).First(&models.IptvMeals{}).Error; err != nil {
+		apiFail(c, http.StatusUnprocessableEntity, "套餐不存在")
+		return
+	}
+	values := url.Values{"ids[]": params["names"], "meal": {params.Get("meal_id")}}
+	if days := params.Get("days"); days != "" && days != "0" {
+		values.Set("author_days", days)
+		apiResult(c, service.SubmitAuthorWithDays(values, username), http.StatusOK)
+		return
+	}
+	apiResult(c, service.SubmitAuthorForever(values, username), http.StatusOK)
+}
+
+func ApiV1ForbidUsers(c *gin.Context) {
+	username, _ := until.GetAuthName(c)
+	params, ok := apiParams(c)
+	if !ok {
+		return
+	}
+	if len(params["names"]) == 0 {
+		apiFail(c, http.StatusBadRequest, "请传入设备 names")
+		return
+	}
+	apiResult(c, service.ForbiddenUser(url.Values{"ids[]": params["names"]}, username), http.StatusOK)
+}
+
+func ApiV1DelUser(c *gin.Context) {
+	var user models.IptvUser
+	if err := dao.DB.Where("name = ?", c.Param("name")).First(&user).Error; err != nil {
+		apiFail(c, http.StatusNotFound, "设备不存在")
+		return
+	}
+	apiResult(c, service.DelUsers(url.Values{"ids[]": {c.Param("name")}}), http.StatusNoContent)
+}
@@ -27,8 +27,11 @@ func ApkLogin(c *gin.Context) {
 	}
 
 	dbUser := service.CheckUserDb(user, ip)
+	if user.PairCode != "" {
+		dbUser = service.PairDevice(dbUser, user.PairCode, "apk")
+	}
 
-	result := service.ApkLogin(dbUser)
+	result := service.ApkLogin(dbUser, accessClient(c, user.AppName, user.AppVer))
 
 	resObj, _ := json.Marshal(result)
 
@@ -64,7 +67,7 @@ func GetChannels(c *gin.Context) {
 		channel.Mac = channel.DeviceID
 	}
 
-	result := service.GetChannels(channel)
+	result := service.GetChannels(channel, accessClient(c, "", ""))
 
 	c.String(http.StatusOK, result)
 }
@@ -86,3 +89,30 @@ func GetEpg(c *gin.Context) {
 		c.JSON(http.StatusOK, result)
 	}
 }
+
+func ApkRedeem(c *gin.Context) {
+	var req dto.RedeemReqDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	if strings.Contains(req.Mac, "获取地址失败") || req.Mac == "" {
+		req.Mac = req.DeviceID
+	}
+
+	c.JSON(http.StatusOK, service.ApkRedeem(req, c.ClientIP()))
+}
+
+// 访问记录的客户端信息，未上报应用名时按当前编译的APK记录
+func accessClient(c *gin.Context, appName, appVer string) dto.AccessClient {
+	if appName == "" {
+		appName = dao.GetConfig().Build.Name
+	}
+	return dto.AccessClient{
+		IP:       c.ClientIP(),
+		AppName:  appName,
+		AppVer:   appVer,
+		Endpoint: c.FullPath(),
+	}
+}
@@ -22,8 +22,6 @@ func MytvGetUserM3U8(c *gin.Context) {
 		return
 	}
 
-	clientIP := c.ClientIP()
-
 	scheme := GetClientScheme(c)
 
 	host := c.Request.Host
@@ -33,7 +31,7 @@ func MytvGetUserM3U8(c *gin.Context) {
 	}
 	host = fmt.Sprintf("%s://%s", scheme, host)
 
-	c.String(200, service.MytvGetUserM3U8(ts, deviceId, clientIP, host))
+	c.String(200, service.MytvGetUserM3U8(ts, deviceId, c.Query("pair"), host, mytvAccessClient(c)))
 }
 
 func MytvGetRssEpg(c *gin.Context) {
@@ -43,7 +41,7 @@ func MytvGetRssEpg(c *gin.Context) {
 		return
 	}
 
-	tv := service.MytvGetRssEpg(deviceId)
+	tv := service.MytvGetRssEpg(deviceId, mytvAccessClient(c))
 
 	output, err := xml.MarshalIndent(tv, "", "  ")
 	if err != nil {
@@ -56,6 +54,14 @@ func MytvGetRssEpg(c *gin.Context) {
 	c.Data(200, "text/xml", xmlData)
 }
 
+func mytvAccessClient(c *gin.Context) dto.AccessClient {
+	return dto.AccessClient{
+		IP:       c.ClientIP(),
+		AppName:  "MyTV",
+		Endpoint: c.FullPath(),
+	}
+}
+
 func MytvReleases(c *gin.Context) {
 	c.JSON(200, service.MytvReleases())
 }
@@ -76,3 +82,12 @@ func BaseApk(c *gin.Context) {
 func BaseVersion(c *gin.Context) {
 	c.String(200, string(assets.MyTVApkVersion))
 }
+
+func MytvRedeem(c *gin.Context) {
+	var req dto.RedeemReqDto
+	if err := c.ShouldBind(&req); err != nil || req.DeviceID == "" {
+		c.JSON(http.StatusOK, dto.RedeemResDto{Code: 0, Msg: "参数错误"})
+		return
+	}
+	c.JSON(http.StatusOK, service.MytvRedeem(req, c.ClientIP()))
+}
@@ -7,6 +7,7 @@ import (
 	"go-iptv/service"
 	"go-iptv/until"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -288,3 +289,64 @@ func GetClientScheme(c *gin.Context) string {
 	}
 	return "http"
 }
+
+// 按token返回的节目单最长缓存时间(秒)
+const rssTokenMaxAge = 60
+
+// GetRssDiyp DIYP格式单频道节目单 /diyp/:token?ch=CCTV1&date=2025-01-01
+func GetRssDiyp(c *gin.Context) {
+	token := c.Param("token")
+	ch := strings.TrimSpace(c.Query("ch"))
+	if token == "" || ch == "" {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(400, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+
+	today := time.Now().In(until.EpgLocation).Format("2006-01-02")
+	date := c.DefaultQuery("date", today)
+	day, err := time.ParseInLocation("2006-01-02", date, until.EpgLocation)
+	if err != nil {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(400, gin.H{"code": 400, "msg": "日期格式错误"})
+		return
+	}
+
+	res, msg := service.GetRssDiyp(token, ch, day)
+	if msg != "" {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(403, gin.H{"code": 403, "msg": msg})
+		return
+	}
+
+	// 按token返回的数据只允许客户端私有缓存，token吊销或过期后很快失效
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", rssTokenMaxAge))
+	c.JSON(200, res)
+}
+
+// GetRssNowNext 套餐内所有频道的当前/下一个节目 /epg/:token/now.json
+func GetRssNowNext(c *gin.Context) {
+	token := c.Param("token")
+	if token == "" {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(400, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+
+	res, maxAge, msg := service.GetRssNowNext(token)
+	if msg != "" {
+		c.Header("Cache-Control", "no-store")
+		c.JSON(403, gin.H{"code": 403, "msg": msg})
+		return
+	}
+
+	// 缓存到最近一个节目结束，最长不超过 rssTokenMaxAge
+	if maxAge < 10 {
+		maxAge = 10
+	}
+	if maxAge > rssTokenMaxAge {
+		maxAge = rssTokenMaxAge
+	}
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	c.JSON(200, gin.H{"code": 200, "time": time.Now().Unix(), "data": res})
+}
@@ -29,7 +29,7 @@ func GetBuildStatus() int64 {
 	return v.(int64)
 }
 
-func BuildAPK() bool {
+func BuildAPK() (ok bool) {
 	SetBuildStatus(1) // 编译中
 	defer SetBuildStatus(0)
 
@@ -39,6 +39,21 @@ func BuildAPK() bool {
 	apkName := cfg.Build.Name
 	apkPackage := cfg.Build.Package
 	apkVersion := cfg.Build.Version
+
+	defer func() {
+		event := until.EventApkBuilt
+		if ok {
+			until.Notify(until.NotifyInfo, until.SourceApk, "apk", "APK "+apkName+" "+apkVersion+" 编译完成")
+		} else {
+			event = until.EventApkFailed
+			until.Notify(until.NotifyError, until.SourceApk, "apk", "APK "+apkName+" "+apkVersion+" 编译失败，详情请查看容器日志")
+		}
+		until.FireWebhook(event, map[string]interface{}{
+			"name":    apkName,
+			"package": apkPackage,
+			"version": apkVersion,
+		})
+	}()
 	iconFile := "/config/images/icon/icon.png"
 
 	clientSource := "/client"
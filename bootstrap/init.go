@@ -68,6 +68,21 @@ func InitDB() bool {
 	dao.DB.AutoMigrate(&models.IptvMeals{})
 	dao.DB.AutoMigrate(&models.IptvMovie{})
 	dao.DB.AutoMigrate(&models.IptvMealToken{})
+
+	dao.DB.AutoMigrate(&models.IptvEpgAlias{})
+	initEpgAlias()
+	dao.DB.AutoMigrate(&models.IptvEpgCustom{})
+	dao.DB.AutoMigrate(&models.IptvActCode{}, &models.IptvActCodeLog{})
+	dao.DB.AutoMigrate(&models.IptvTrial{})
+	dao.DB.AutoMigrate(&models.IptvAccount{})
+	dao.DB.AutoMigrate(&models.IptvUserIP{})
+	dao.DB.AutoMigrate(&models.IptvAccessLog{})
+	dao.DB.AutoMigrate(&models.IptvIPPolicy{})
+	dao.DB.AutoMigrate(&models.IptvAdminSession{})
+	dao.DB.AutoMigrate(&models.IptvAuditLog{})
+	dao.DB.AutoMigrate(&models.IptvApiKey{})
+	dao.DB.AutoMigrate(&models.IptvWebhook{}, &models.IptvWebhookDelivery{})
+	dao.DB.AutoMigrate(&models.IptvNotification{})
 	return true
 }
 
@@ -105,6 +120,29 @@ func InitAlias() {
 	until.CopyFile("./alias.json", "/config/alias.json")
 }
 
+// 别名表为空时从 alias.json 导入
+func initEpgAlias() {
+	var count int64
+	if err := dao.DB.Model(&models.IptvEpgAlias{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	path := until.AliasFile
+	if !until.Exists(path) {
+		path = "./alias.json"
+	}
+	aliasMap, err := until.LoadAliasFile(path)
+	if err != nil {
+		log.Println("读取别名文件失败:", err)
+		return
+	}
+	add, _, err := until.ImportAlias(aliasMap, "builtin", false)
+	if err != nil {
+		log.Println("导入别名失败:", err)
+		return
+	}
+	log.Printf("导入EPG别名 %d 条", add)
+}
+
 func initIptvCategory() {
 	has := dao.DB.Migrator().HasColumn(&IptvCategory{}, "url")
 	if has {
@@ -192,7 +230,7 @@ func initEpg() {
 			if epg.ID <= 18 {
 				epg.Name = strings.SplitN(epg.Name, "-", 2)[1]
 				update = true
-				epg.FromListStr = "0"
+				epg.FromListStr = until.EpgProviderCntv
 				dao.DB.Save(&epg)
 			} else {
 				if strings.SplitN(epg.Name, "-", 2)[0] == "CCTV" {
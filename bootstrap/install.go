@@ -117,6 +117,7 @@ func Install() (bool, string) {
 		return false, "初始化Logo失败"
 	}
 	InitAlias()
+	initEpgAlias()
 
 	dao.CONFIG_PATH = "/config/config.yml"
 	dao.LoadConfigFile()
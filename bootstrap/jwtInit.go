@@ -8,10 +8,14 @@ import (
 )
 
 func InitJwtKey() {
-	// 读取配置文件
-	hostname, _ := until.GetContainerID()
-	until.JwtKey = []byte(until.Md5(hostname + time.Now().Format("2006-01-02 15:04:05")))
 	cfg := dao.GetConfig()
+	// 签名密钥随机生成后保存到配置文件，重启后登录状态保持有效
+	if cfg.System.JwtSecret == "" {
+		cfg.System.JwtSecret = until.RandomHex(32)
+		dao.SetConfig(cfg)
+		log.Println("已生成后台登录签名密钥")
+	}
+	until.JwtKey = []byte(cfg.System.JwtSecret)
 	if cfg.Rss.Key == "" {
 		cfg.Rss.Key = until.Md5(time.Now().Format("2006-01-02 15:04:05"))
 		until.RssKey = []byte(cfg.Rss.Key)
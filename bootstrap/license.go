@@ -3,15 +3,28 @@ package bootstrap
 import (
 	"encoding/json"
 	"go-iptv/dao"
+	"go-iptv/until"
 	"log"
 )
 
 func InitLicense() {
 	// dao.StartLicense()
 	log.Println("引擎初始化中")
+	dao.WS.OnReconnectFailed = func(err error) {
+		reason := "重连失败"
+		if err != nil {
+			reason = err.Error()
+		}
+		until.Notify(until.NotifyError, until.SourceLicense, "license", "授权引擎连接断开: "+reason)
+		until.FireWebhook(until.EventLicenseOffline, map[string]interface{}{
+			"id":     dao.Lic.ID,
+			"reason": reason,
+		})
+	}
 	err := dao.WS.Start("ws://127.0.0.1:81/ws")
 	if err != nil {
 		log.Println("引擎初始化错误: ", err)
+		until.Notify(until.NotifyError, until.SourceLicense, "license", "授权引擎初始化失败: "+err.Error())
 		return
 	}
 	res, err := dao.WS.SendWS(dao.Request{Action: "getlic"})
@@ -0,0 +1,80 @@
+package crontab
+
+import (
+	"go-iptv/dao"
+	"go-iptv/models"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// 每天清理超过保留天数的访问记录、操作日志、Webhook投递记录和已读通知
+func AccessLogCron() {
+	c := cron.New(cron.WithSeconds())
+	c.AddFunc("0 30 4 * * *", PruneAccessLog)
+	c.AddFunc("0 35 4 * * *", PruneAuditLog)
+	c.AddFunc("0 40 4 * * *", PruneWebhookDeliveries)
+	c.AddFunc("0 45 4 * * *", PruneNotifications)
+	c.Start()
+	PruneAccessLog()
+	PruneAuditLog()
+	PruneWebhookDeliveries()
+	PruneNotifications()
+}
+
+func PruneAccessLog() {
+	days := dao.GetConfig().System.LogDays()
+	before := time.Now().AddDate(0, 0, -int(days)).Unix()
+	res := dao.DB.Where("created_at < ?", before).Delete(&models.IptvAccessLog{})
+	if res.Error != nil {
+		log.Println("清理访问记录失败:", res.Error)
+		return
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("已清理 %d 天前的访问记录 %d 条\n", days, res.RowsAffected)
+	}
+}
+
+func PruneAuditLog() {
+	days := dao.GetConfig().System.AuditDays()
+	before := time.Now().AddDate(0, 0, -int(days)).Unix()
+	res := dao.DB.Where("created_at < ?", before).Delete(&models.IptvAuditLog{})
+	if res.Error != nil {
+		log.Println("清理操作日志失败:", res.Error)
+		return
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("已清理 %d 天前的操作日志 %d 条\n", days, res.RowsAffected)
+	}
+}
+
+// Webhook 投递记录固定保留30天，未发送完的不清理
+const webhookDeliveryDays = 30
+
+func PruneWebhookDeliveries() {
+	before := time.Now().AddDate(0, 0, -webhookDeliveryDays).Unix()
+	res := dao.DB.Where("created_at < ? and status <> 0", before).Delete(&models.IptvWebhookDelivery{})
+	if res.Error != nil {
+		log.Println("清理Webhook投递记录失败:", res.Error)
+		return
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("已清理 %d 天前的Webhook投递记录 %d 条\n", webhookDeliveryDays, res.RowsAffected)
+	}
+}
+
+// 已读通知保留30天，未读的不清理
+const notificationDays = 30
+
+func PruneNotifications() {
+	before := time.Now().AddDate(0, 0, -notificationDays).Unix()
+	res := dao.DB.Where("updated_at < ? and status = 1", before).Delete(&models.IptvNotification{})
+	if res.Error != nil {
+		log.Println("清理通知失败:", res.Error)
+		return
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("已清理 %d 天前的已读通知 %d 条\n", notificationDays, res.RowsAffected)
+	}
+}
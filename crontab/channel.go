@@ -102,6 +102,7 @@ func UpdateList() {
 		req, err := http.NewRequest("GET", strings.TrimSpace(list.Url), nil)
 		if err != nil {
 			log.Println("更新频道列表失败--->创建请求错误:: ", err.Error(), " URL: ", list.Url)
+			until.ReportListFailed(list, "创建请求错误: "+err.Error())
 			continue
 		}
 
@@ -111,18 +112,21 @@ func UpdateList() {
 		resp, err := client.Do(req)
 		if err != nil {
 			log.Println("更新频道列表失败--->无法访问url: ", err.Error(), " URL: ", list.Url)
+			until.ReportListFailed(list, "无法访问url: "+err.Error())
 			continue
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
 			log.Println("更新频道列表失败--->读取响应失败-状态码：", resp.StatusCode, " URL: ", list.Url)
+			until.ReportListFailed(list, "状态码: "+strconv.Itoa(resp.StatusCode))
 			continue
 		}
 
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			log.Println("更新频道列表失败--->读取响应失败：", " URL: ", list.Url)
+			until.ReportListFailed(list, "读取响应失败: "+err.Error())
 			continue
 		}
 
@@ -0,0 +1,52 @@
+package crontab
+
+import (
+	"fmt"
+	"go-iptv/until"
+	"log"
+
+	"github.com/robfig/cron/v3"
+)
+
+// 数据目录剩余空间低于以下比例时写入通知
+const (
+	diskWarnPercent  = 10
+	diskErrorPercent = 5
+)
+
+var diskPaths = []string{"/config", "/tmp"}
+
+// 每30分钟检查一次磁盘剩余空间
+func DiskCron() {
+	c := cron.New(cron.WithSeconds())
+	c.AddFunc("0 */30 * * * *", CheckDisk)
+	c.Start()
+	CheckDisk()
+}
+
+func CheckDisk() {
+	for _, path := range diskPaths {
+		if !until.Exists(path) {
+			continue
+		}
+		total, free, err := diskUsage(path)
+		if err != nil {
+			log.Println("获取磁盘空间失败:", path, err)
+			continue
+		}
+		if total == 0 {
+			continue
+		}
+		percent := free * 100 / total
+		if percent >= diskWarnPercent {
+			continue
+		}
+		level := until.NotifyWarning
+		if percent < diskErrorPercent {
+			level = until.NotifyError
+		}
+		msg := fmt.Sprintf("%s 剩余空间不足: %.1fGB / %.1fGB (%d%%)", path, float64(free)/(1<<30), float64(total)/(1<<30), percent)
+		log.Println(msg)
+		until.Notify(level, until.SourceDisk, "disk:"+path, msg)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd)
+
+package crontab
+
+// 其他平台不检查磁盘空间
+func diskUsage(path string) (uint64, uint64, error) {
+	return 0, 0, nil
+}
@@ -0,0 +1,98 @@
+package crontab
+
+import (
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	expiryLock   sync.Mutex
+	expiryDigest dto.ExpiryDigest
+)
+
+// 每天处理过期设备、清理闲置未授权设备并生成即将到期摘要
+func ExpiryCron() {
+	c := cron.New(cron.WithSeconds())
+	c.AddFunc("0 5 0 * * *", func() { RunExpiry() })
+	c.Start()
+	RunExpiry()
+}
+
+func RunExpiry() dto.ExpiryDigest {
+	expiryLock.Lock()
+	defer expiryLock.Unlock()
+
+	cfg := dao.GetConfig()
+	now := time.Now()
+	digest := dto.ExpiryDigest{
+		Time: now.Format("2006-01-02 15:04:05"),
+		Days: cfg.Expiry.RemindDays,
+	}
+
+	// 按天授权已到期的设备转为已过期(status=2)，绑定客户账号的设备以账号为准不处理
+	res := dao.DB.Model(&models.IptvUser{}).
+		Where("status = 1 and account = 0 and exp > 0 and exp < ?", now.Unix()).
+		Update("status", 2)
+	if res.Error != nil {
+		log.Println("处理过期设备失败:", res.Error)
+	} else if res.RowsAffected > 0 {
+		digest.Expired = res.RowsAffected
+		log.Printf("已将 %d 个到期设备标记为已过期\n", res.RowsAffected)
+	}
+
+	// 清理长时间未上线的未授权设备，试用期内的设备保留
+	if days := cfg.Expiry.IdleDays; days > 0 {
+		before := now.AddDate(0, 0, -int(days)).Unix()
+		res := dao.DB.Where("status = -1 and lasttime < ? and exp <= ?", before, now.Unix()).Delete(&models.IptvUser{})
+		if res.Error != nil {
+			log.Println("清理闲置未授权设备失败:", res.Error)
+		} else if res.RowsAffected > 0 {
+			digest.Cleaned = res.RowsAffected
+			log.Printf("已清理 %d 天未上线的未授权设备 %d 个\n", days, res.RowsAffected)
+		}
+	}
+
+	if days := cfg.Expiry.RemindDays; days > 0 {
+		to := now.AddDate(0, 0, int(days))
+		query := dao.DB.Model(&models.IptvUser{}).
+			Where("status = 1 and account = 0 and exp >= ? and exp < ?", now.Unix(), to.Unix())
+		query.Count(&digest.Total)
+
+		var users []models.IptvUser
+		query.Order("exp asc").Limit(20).Find(&users)
+		for _, u := range users {
+			digest.Users = append(digest.Users, dto.ExpiringUser{
+				Name:       u.Name,
+				Mac:        u.Mac,
+				DeviceID:   u.DeviceID,
+				Marks:      u.Marks,
+				Exp:        time.Unix(u.Exp, 0).Format("2006-01-02 15:04:05"),
+				RemainDays: until.CalcRemainDays(u.Exp),
+			})
+		}
+		digest.QueryURL = "/admin/users?status=days&expto=" + to.Format("2006-01-02")
+		if digest.Total > 0 {
+			log.Printf("到期提醒: %d 天内有 %d 个设备到期\n", days, digest.Total)
+			until.Notify(until.NotifyWarning, until.SourceExpiry, "expiry", fmt.Sprintf("%d 天内有 %d 个设备到期", days, digest.Total))
+			until.FireWebhook(until.EventDeviceExpiring, digest)
+		}
+	}
+
+	expiryDigest = digest
+	return digest
+}
+
+// 最近一次到期处理的摘要
+func GetExpiryDigest() dto.ExpiryDigest {
+	expiryLock.Lock()
+	defer expiryLock.Unlock()
+	return expiryDigest
+}
@@ -60,6 +60,8 @@ type WSClient struct {
 	failCount   int
 	failLimit   int
 	backoffBase time.Duration
+
+	OnReconnectFailed func(err error) // 重连全部失败后回调
 }
 
 // ------------------ 创建客户端 ------------------
@@ -234,6 +236,7 @@ func (c *WSClient) reconnectWorker() {
 
 		backoff := c.backoffBase
 		success := false
+		var lastErr error
 		for i := 0; i < c.maxRetry; i++ {
 			if err := c.doConnect(); err != nil {
 				if !IsRunning() {
@@ -241,6 +244,7 @@ func (c *WSClient) reconnectWorker() {
 						err = errors.New("引擎停止运行")
 					}
 				}
+				lastErr = err
 				log.Printf("❌ 引擎重连第 %d 次失败: %v", i+1, err)
 				time.Sleep(backoff)
 				backoff *= 2
@@ -253,6 +257,9 @@ func (c *WSClient) reconnectWorker() {
 		if !success {
 			log.Println("❌ 重连失败，关闭连接")
 			c.CloseConn(true)
+			if c.OnReconnectFailed != nil {
+				go c.OnReconnectFailed(lastErr)
+			}
 		}
 
 		c.rw.Lock()
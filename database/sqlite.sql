@@ -3,7 +3,15 @@ BEGIN TRANSACTION;
 CREATE TABLE iptv_admin (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     username TEXT NOT NULL,
-    password TEXT NOT NULL
+    password TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'owner',
+    status INTEGER NOT NULL DEFAULT 1,
+    created_at INTEGER DEFAULT 0,
+    last_login INTEGER DEFAULT 0,
+    totp_key TEXT DEFAULT '',
+    totp INTEGER DEFAULT 0,
+    recovery_codes TEXT DEFAULT '',
+    totp_step INTEGER DEFAULT 0
 );
 
 CREATE TABLE iptv_category (
@@ -56,9 +64,10 @@ CREATE TABLE iptv_epg_list (
     status INTEGER NOT NULL DEFAULT 1,
     ua TEXT,
     lasttime BIGINT NOT NULL,
-    remarks TEXT DEFAULT NULL
+    remarks TEXT DEFAULT NULL,
+    type TEXT DEFAULT 'xmltv'
 );
-INSERT INTO iptv_epg_list VALUES(1,'51zmt','http://epg.51zmt.top:8000/e.xml',1,'',0,'51zmt');
+INSERT INTO iptv_epg_list VALUES(1,'51zmt','http://epg.51zmt.top:8000/e.xml',1,'',0,'51zmt','xmltv');
 
 CREATE TABLE iptv_epg (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
@@ -112,8 +121,12 @@ CREATE TABLE iptv_users (
     status INTEGER NOT NULL DEFAULT -1,
     lasttime BIGINT NOT NULL,
     marks TEXT DEFAULT NULL,
-    meal INTEGER NOT NULL DEFAULT 1000
+    meal INTEGER NOT NULL DEFAULT 1000,
+    account INTEGER NOT NULL DEFAULT 0,
+    multiip INTEGER NOT NULL DEFAULT 0,
+    flag TEXT DEFAULT NULL
 );
+CREATE INDEX IF NOT EXISTS idx_iptv_users_account ON iptv_users (account);
 
 CREATE TABLE short_url (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
@@ -134,4 +147,206 @@ CREATE TABLE IF NOT EXISTS "iptv_meal_tokens" (
     remark TEXT,
     FOREIGN KEY (meal_id) REFERENCES iptv_meals(id) ON DELETE CASCADE
 );
+
+CREATE TABLE IF NOT EXISTS "iptv_epg_alias" (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    alias TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    source TEXT DEFAULT 'local',
+    updated_at BIGINT DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS "iptv_epg_custom" (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    channel TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    type TEXT DEFAULT 'weekly',
+    weekdays TEXT,
+    start_time TEXT,
+    end_time TEXT,
+    start_at BIGINT DEFAULT 0,
+    end_at BIGINT DEFAULT 0,
+    status INTEGER DEFAULT 1,
+    updated_at BIGINT DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_iptv_epg_custom_channel ON iptv_epg_custom (channel);
+CREATE TABLE IF NOT EXISTS "iptv_act_codes" (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    code TEXT NOT NULL UNIQUE,
+    meal INTEGER NOT NULL,
+    days INTEGER DEFAULT 0,
+    max_uses INTEGER DEFAULT 1,
+    used_count INTEGER DEFAULT 0,
+    expires_at BIGINT DEFAULT 0,
+    batch TEXT,
+    status INTEGER DEFAULT 1,
+    author TEXT,
+    created_at BIGINT DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_iptv_act_codes_batch ON iptv_act_codes (batch);
+CREATE TABLE IF NOT EXISTS "iptv_act_code_logs" (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    code_id INTEGER NOT NULL,
+    code TEXT,
+    user_name INTEGER NOT NULL,
+    deviceid TEXT,
+    mac TEXT,
+    ip TEXT,
+    client TEXT,
+    meal INTEGER,
+    days INTEGER,
+    exp BIGINT,
+    created_at BIGINT DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_act_code_user ON iptv_act_code_logs (code_id, user_name);
+CREATE INDEX IF NOT EXISTS idx_iptv_act_code_logs_code ON iptv_act_code_logs (code);
+CREATE TABLE IF NOT EXISTS iptv_trials (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_name INTEGER,
+    mac TEXT,
+    deviceid TEXT,
+    model TEXT,
+    ip TEXT,
+    client TEXT,
+    meal INTEGER,
+    exp BIGINT,
+    created_at BIGINT DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_iptv_trials_mac ON iptv_trials (mac);
+CREATE INDEX IF NOT EXISTS idx_iptv_trials_deviceid ON iptv_trials (deviceid);
+CREATE INDEX IF NOT EXISTS idx_iptv_trials_model ON iptv_trials (model);
+CREATE INDEX IF NOT EXISTS idx_iptv_trials_ip ON iptv_trials (ip);
+CREATE TABLE IF NOT EXISTS iptv_accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    meal INTEGER NOT NULL,
+    exp BIGINT DEFAULT 0,
+    max_devices INTEGER DEFAULT 0,
+    pair_code TEXT UNIQUE,
+    status INTEGER DEFAULT 1,
+    marks TEXT,
+    created_at BIGINT DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS iptv_user_ips (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_name INTEGER NOT NULL,
+    ip TEXT NOT NULL,
+    region TEXT,
+    last_seen BIGINT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_ip ON iptv_user_ips (user_name, ip);
+CREATE INDEX IF NOT EXISTS idx_iptv_user_ips_last_seen ON iptv_user_ips (last_seen);
+CREATE TABLE IF NOT EXISTS iptv_access_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_name INTEGER,
+    deviceid TEXT,
+    mac TEXT,
+    ip TEXT,
+    region TEXT,
+    appname TEXT,
+    appver TEXT,
+    endpoint TEXT,
+    result TEXT,
+    created_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS idx_iptv_access_logs_user_name ON iptv_access_logs (user_name);
+CREATE INDEX IF NOT EXISTS idx_iptv_access_logs_ip ON iptv_access_logs (ip);
+CREATE INDEX IF NOT EXISTS idx_iptv_access_logs_created_at ON iptv_access_logs (created_at);
+CREATE TABLE IF NOT EXISTS iptv_ip_policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope TEXT NOT NULL,
+    target INTEGER DEFAULT 0,
+    type TEXT NOT NULL,
+    value TEXT NOT NULL,
+    status INTEGER DEFAULT 1,
+    marks TEXT,
+    created_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS idx_iptv_ip_policies_scope ON iptv_ip_policies (scope);
+CREATE TABLE IF NOT EXISTS iptv_admin_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    sid TEXT NOT NULL,
+    admin_id INTEGER NOT NULL,
+    ua TEXT,
+    ip TEXT,
+    created_at BIGINT,
+    last_used BIGINT,
+    expires_at BIGINT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_iptv_admin_sessions_sid ON iptv_admin_sessions (sid);
+CREATE INDEX IF NOT EXISTS idx_iptv_admin_sessions_admin_id ON iptv_admin_sessions (admin_id);
+CREATE INDEX IF NOT EXISTS idx_iptv_admin_sessions_expires_at ON iptv_admin_sessions (expires_at);
+CREATE TABLE IF NOT EXISTS iptv_audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    admin TEXT,
+    role TEXT,
+    ip TEXT,
+    method TEXT,
+    path TEXT,
+    action TEXT,
+    target TEXT,
+    params TEXT,
+    changes TEXT,
+    code INTEGER,
+    result TEXT,
+    created_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS idx_iptv_audit_logs_admin ON iptv_audit_logs (admin);
+CREATE INDEX IF NOT EXISTS idx_iptv_audit_logs_path ON iptv_audit_logs (path);
+CREATE INDEX IF NOT EXISTS idx_iptv_audit_logs_created_at ON iptv_audit_logs (created_at);
+CREATE TABLE IF NOT EXISTS iptv_api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    prefix TEXT,
+    key_hash TEXT NOT NULL,
+    scopes TEXT,
+    created_by TEXT,
+    created_at BIGINT,
+    last_used BIGINT,
+    last_ip TEXT,
+    expires_at BIGINT,
+    status INTEGER DEFAULT 1
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_iptv_api_keys_key_hash ON iptv_api_keys (key_hash);
+CREATE TABLE IF NOT EXISTS iptv_webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT,
+    events TEXT,
+    enable INTEGER DEFAULT 1,
+    created_at BIGINT
+);
+CREATE TABLE IF NOT EXISTS iptv_webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER,
+    event TEXT,
+    uuid TEXT,
+    payload TEXT,
+    status INTEGER DEFAULT 0,
+    attempts INTEGER DEFAULT 0,
+    next_at BIGINT,
+    code INTEGER,
+    response TEXT,
+    error TEXT,
+    created_at BIGINT,
+    updated_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS idx_iptv_webhook_deliveries_webhook_id ON iptv_webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_iptv_webhook_deliveries_next_at ON iptv_webhook_deliveries (next_at);
+CREATE INDEX IF NOT EXISTS idx_iptv_webhook_deliveries_created_at ON iptv_webhook_deliveries (created_at);
+CREATE TABLE IF NOT EXISTS iptv_notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    level TEXT,
+    source TEXT,
+    notify_key TEXT,
+    message TEXT,
+    count INTEGER DEFAULT 1,
+    status INTEGER DEFAULT 0,
+    created_at BIGINT,
+    updated_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS idx_iptv_notifications_notify_key ON iptv_notifications (notify_key);
+CREATE INDEX IF NOT EXISTS idx_iptv_notifications_status ON iptv_notifications (status);
+CREATE INDEX IF NOT EXISTS idx_iptv_notifications_updated_at ON iptv_notifications (updated_at);
 COMMIT;
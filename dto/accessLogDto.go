@@ -0,0 +1,32 @@
+package dto
+
+import "go-iptv/models"
+
+// 客户端请求信息，用于写入访问记录
+type AccessClient struct {
+	IP       string
+	AppName  string
+	AppVer   string
+	Endpoint string
+}
+
+type AdminAccessLogsDto struct {
+	LoginUser string          `json:"loginuser"`
+	Title     string          `json:"title"`
+	Logs      []AccessLogItem `json:"logs"`
+	User      models.IptvUser `json:"user"` // 按设备筛选时的设备信息
+	UserName  string          `json:"username"`
+	IP        string          `json:"ip"`
+	Result    string          `json:"result"` // ok/deny
+	Days      int64           `json:"days"`   // 保留天数
+	Count     int64           `json:"count"`
+	PageCount int64           `json:"pagecount"`
+	Page      int64           `json:"page"`      // 当前页数
+	RecCounts int64           `json:"recCounts"` // 每页显示条数
+}
+
+type AccessLogItem struct {
+	models.IptvAccessLog
+	TimeStr    string `json:"timestr"`
+	ResultText string `json:"resulttext"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "go-iptv/models"
+
+type AdminAccountsDto struct {
+	LoginUser string             `json:"loginuser"`
+	Title     string             `json:"title"`
+	Accounts  []AccountItem      `json:"accounts"`
+	Meals     []models.IptvMeals `json:"meals"`
+	Count     int64              `json:"count"`
+	PageCount int64              `json:"pagecount"`
+	Page      int64              `json:"page"`      // 当前页数
+	Keywords  string             `json:"keywords"`  // 搜索关键字
+	RecCounts int64              `json:"recCounts"` // 每页显示条数
+}
+
+type AccountItem struct {
+	models.IptvAccount
+	MealName string `json:"mealname"`
+	ExpStr   string `json:"expstr"`
+	ExpDate  string `json:"expdate"` // 编辑用 2006-01-02
+	ExpDays  string `json:"expdays"`
+	Devices  int64  `json:"devices"` // 已绑定设备数
+}
+
+type AdminAccountDevicesDto struct {
+	LoginUser string                `json:"loginuser"`
+	Title     string                `json:"title"`
+	Account   AccountItem           `json:"account"`
+	Devices   []models.IptvUserShow `json:"devices"`
+	Accounts  []models.IptvAccount  `json:"accounts"` // 迁移目标
+}
@@ -0,0 +1,60 @@
+package dto
+
+import "go-iptv/models"
+
+type AdminActCodesDto struct {
+	LoginUser string             `json:"loginuser"`
+	Title     string             `json:"title"`
+	Codes     []ActCodeItem      `json:"codes"`
+	Meals     []models.IptvMeals `json:"meals"`
+	Batches   []string           `json:"batches"`
+	Count     int64              `json:"count"`
+	PageCount int64              `json:"pagecount"`
+	Page      int64              `json:"page"`      // 当前页数
+	Keywords  string             `json:"keywords"`  // 搜索关键字
+	Batch     string             `json:"batch"`     // 批次筛选
+	State     string             `json:"state"`     // 状态筛选 unused/used/disabled/expired
+	RecCounts int64              `json:"recCounts"` // 每页显示条数
+}
+
+type ActCodeItem struct {
+	models.IptvActCode
+	MealName     string `json:"mealname"`
+	ExpiresStr   string `json:"expiresstr"`
+	CreatedStr   string `json:"createdstr"`
+	StateDesc    string `json:"statedesc"`
+	UsedByDevice string `json:"usedbydevice"` // 最近使用设备
+}
+
+type AdminActCodeLogsDto struct {
+	LoginUser string           `json:"loginuser"`
+	Title     string           `json:"title"`
+	Logs      []ActCodeLogItem `json:"logs"`
+	Count     int64            `json:"count"`
+	PageCount int64            `json:"pagecount"`
+	Page      int64            `json:"page"`      // 当前页数
+	Keywords  string           `json:"keywords"`  // 搜索关键字
+	RecCounts int64            `json:"recCounts"` // 每页显示条数
+}
+
+type ActCodeLogItem struct {
+	models.IptvActCodeLog
+	MealName   string `json:"mealname"`
+	ExpStr     string `json:"expstr"`
+	CreatedStr string `json:"createdstr"`
+}
+
+// 客户端兑换卡密请求
+type RedeemReqDto struct {
+	Mac      string `json:"mac" form:"mac"`
+	DeviceID string `json:"androidid" form:"deviceId"`
+	Code     string `json:"code" form:"code"`
+}
+
+type RedeemResDto struct {
+	Code     int    `json:"code"`
+	Msg      string `json:"msg"`
+	MealName string `json:"mealname,omitempty"`
+	Exp      int64  `json:"exp"`  // 到期时间戳，0为永久
+	Days     int64  `json:"days"` // 剩余天数
+}
@@ -1,11 +1,50 @@
 package dto
 
-import "go-iptv/models"
+import (
+	"go-iptv/models"
+	"html/template"
+)
 
 type AdminsDto struct {
 	LoginUser string             `json:"loginuser"`
 	Title     string             `json:"title"`
-	Admins    []models.IptvAdmin `json:"admins"`
+	Admin     models.IptvAdmin   `json:"admin"`    // 当前登录管理员
+	RoleName  string             `json:"rolename"` // 当前角色名称
+	IsOwner   bool               `json:"isowner"`
+	Admins    []AdminItem        `json:"admins"` // 管理员列表，仅所有者可见
+	Roles     []RoleOption       `json:"roles"`
+	TotpQR    template.URL       `json:"totpqr"`   // 待验证的两步验证二维码
+	TotpKey   string             `json:"totpkey"`  // 待验证的密钥，用于手动输入
+	Recovery  int                `json:"recovery"` // 剩余恢复码数量
+	Sessions  []AdminSessionItem `json:"sessions"` // 登录会话，所有者可见全部管理员
+}
+
+type AdminItem struct {
+	ID        int64  `json:"id"`
+	UserName  string `json:"username"`
+	Role      string `json:"role"`
+	RoleName  string `json:"rolename"`
+	Status    int64  `json:"status"`
+	Totp      int64  `json:"totp"`
+	CreatedAt string `json:"created_at"`
+	LastLogin string `json:"last_login"`
+}
+
+type AdminSessionItem struct {
+	ID        int64  `json:"id"`
+	UserName  string `json:"username"`
+	Device    string `json:"device"`
+	UA        string `json:"ua"`
+	IP        string `json:"ip"`
+	CreatedAt string `json:"created_at"`
+	LastUsed  string `json:"last_used"`
+	ExpiresAt string `json:"expires_at"`
+	Current   bool   `json:"current"` // 当前浏览器的会话
+}
+
+type RoleOption struct {
+	Value string `json:"value"`
+	Name  string `json:"name"`
 }
 
 type UdataDto struct {
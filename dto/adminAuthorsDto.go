@@ -15,4 +15,6 @@ type AdminAuthorsDto struct {
 	Meals             []models.IptvMeals    `json:"meals"`     // 会员套餐列表
 	RecCounts         int64                 `json:"recCounts"` // 每页显示条数
 	PageCount         int64                 `json:"pageCount"` // 总页数
+	Flagged           string                `json:"flagged"`   // 1为只看异常设备
+	FlaggedTotal      int64                 `json:"flaggedtotal"`
 }
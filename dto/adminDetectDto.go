@@ -0,0 +1,10 @@
+package dto
+
+type AdminDetectDto struct {
+	LoginUser    string `json:"loginuser"`
+	Title        string `json:"title"`
+	Detect       Detect `json:"detect"`
+	Cidrs        string `json:"cidrs"`
+	CidrCount    int    `json:"cidrcount"`
+	FlaggedTotal int64  `json:"flaggedtotal"`
+}
@@ -5,16 +5,17 @@ import (
 )
 
 type AdminEpgsDto struct {
-	LoginUser   string                `json:"loginuser"`
-	Title       string                `json:"title"`
-	Epgs        []models.IptvEpg      `json:"epgs"`
-	PageCount   int64                 `json:"pagecount"`
-	EpgFromDb   []models.IptvEpgList  `json:"epgfromdb"`
-	CaList      []models.IptvCategory `json:"calist"`
-	EpgFromList map[string]string     `json:"epgfromlist"`
-	Page        int64                 `json:"page"`      // 当前页数
-	Keywords    string                `json:"keywords"`  // 搜索关键字
-	RecCounts   int64                 `json:"recCounts"` // 每页显示条数
+	LoginUser    string                `json:"loginuser"`
+	Title        string                `json:"title"`
+	Epgs         []models.IptvEpg      `json:"epgs"`
+	PageCount    int64                 `json:"pagecount"`
+	EpgFromDb    []models.IptvEpgList  `json:"epgfromdb"`
+	EpgProviders []EpgsReturnDto       `json:"epgproviders"` // 内置EPG来源
+	CaList       []models.IptvCategory `json:"calist"`
+	EpgFromList  map[string]string     `json:"epgfromlist"`
+	Page         int64                 `json:"page"`      // 当前页数
+	Keywords     string                `json:"keywords"`  // 搜索关键字
+	RecCounts    int64                 `json:"recCounts"` // 每页显示条数
 	// EpgErr    EPGErrors        `json:"epgerr"` // epg错误信息
 	// EPGApiChk int64            `json:"epgapichk"`
 }
@@ -24,3 +25,38 @@ type EpgsReturnDto struct {
 	Name     string `json:"name"`
 	Selected bool   `json:"selected"`
 }
+
+type AdminEpgAliasDto struct {
+	LoginUser string                `json:"loginuser"`
+	Title     string                `json:"title"`
+	Aliases   []models.IptvEpgAlias `json:"aliases"`
+	EpgNames  []string              `json:"epgnames"`
+	Count     int64                 `json:"count"`
+	PageCount int64                 `json:"pagecount"`
+	Page      int64                 `json:"page"`      // 当前页数
+	Keywords  string                `json:"keywords"`  // 搜索关键字
+	Source    string                `json:"source"`    // 来源筛选
+	RecCounts int64                 `json:"recCounts"` // 每页显示条数
+}
+
+type AdminEpgCustomDto struct {
+	LoginUser string          `json:"loginuser"`
+	Title     string          `json:"title"`
+	Customs   []EpgCustomItem `json:"customs"`
+	Channels  []string        `json:"channels"` // 已有自定义频道
+	EpgNames  []string        `json:"epgnames"`
+	Count     int64           `json:"count"`
+	PageCount int64           `json:"pagecount"`
+	Page      int64           `json:"page"`      // 当前页数
+	Keywords  string          `json:"keywords"`  // 搜索关键字
+	Channel   string          `json:"channel"`   // 频道筛选
+	RecCounts int64           `json:"recCounts"` // 每页显示条数
+}
+
+type EpgCustomItem struct {
+	models.IptvEpgCustom
+	TimeStr    string `json:"timestr"`    // 播出时间
+	WeekStr    string `json:"weekstr"`    // 每周重复
+	StartAtStr string `json:"startatstr"` // 单次节目开始时间
+	EndAtStr   string `json:"endatstr"`   // 单次节目结束时间
+}
@@ -0,0 +1,10 @@
+package dto
+
+type AdminGeoIPDto struct {
+	LoginUser string `json:"loginuser"`
+	Title     string `json:"title"`
+	Loaded    bool   `json:"loaded"`
+	Size      string `json:"size"`
+	UpdatedAt string `json:"updatedat"`
+	IPRemote  int64  `json:"ip_remote"`
+}
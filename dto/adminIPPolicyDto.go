@@ -0,0 +1,19 @@
+package dto
+
+import "go-iptv/models"
+
+type AdminIPPoliciesDto struct {
+	LoginUser string                 `json:"loginuser"`
+	Title     string                 `json:"title"`
+	Policies  []IPPolicyItem         `json:"policies"`
+	Meals     []models.IptvMeals     `json:"meals"`
+	Tokens    []models.IptvMealToken `json:"tokens"`
+}
+
+type IPPolicyItem struct {
+	models.IptvIPPolicy
+	ScopeText  string `json:"scopetext"`
+	TargetName string `json:"targetname"`
+	TypeText   string `json:"typetext"`
+	CreatedStr string `json:"createdstr"`
+}
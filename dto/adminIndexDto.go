@@ -12,6 +12,10 @@ type AdminIndexDto struct {
 	EpgCount         int64         `json:"epgcount"`
 	ChannelCount     int64         `json:"channelcount"`
 	ChannelTypeList  []ChannelType `json:"channeltypelist"`
+	Expiry           ExpiryDigest  `json:"expiry"` // 到期提醒摘要
+
+	Notifications []NotificationItem `json:"notifications"` // 最近通知
+	Unread        int64              `json:"unread"`        // 未读通知数
 }
 
 type ChannelType struct {
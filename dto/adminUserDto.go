@@ -1,6 +1,9 @@
 package dto
 
-import "go-iptv/models"
+import (
+	"go-iptv/models"
+	"html/template"
+)
 
 type AdminUserDto struct {
 	LoginUser string                `json:"loginuser"`
@@ -14,4 +17,8 @@ type AdminUserDto struct {
 	Users     []models.IptvUserShow `json:"users"`     // 用户列表
 	Meals     []models.IptvMeals    `json:"meals"`     // 会员套餐列表
 	RecCounts int64                 `json:"recCounts"` // 每页显示条数
+	Filter    UserFilter            `json:"filter"`
+	FilterURL template.URL          `json:"-"` // 筛选条件查询串，用于分页和导出链接
+
+	FilterValues map[string]string `json:"-"` // 筛选条件，批量操作表单中回传
 }
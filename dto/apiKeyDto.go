@@ -0,0 +1,26 @@
+package dto
+
+import "go-iptv/models"
+
+type AdminApiKeysDto struct {
+	LoginUser string       `json:"loginuser"`
+	Title     string       `json:"title"`
+	Keys      []ApiKeyItem `json:"keys"`
+	Scopes    []ApiScope   `json:"scopes"`
+	BaseUrl   string       `json:"baseurl"` // 接口地址
+}
+
+type ApiKeyItem struct {
+	models.IptvApiKey
+	ScopeNames  []string `json:"scope_names"`
+	CreatedStr  string   `json:"created_str"`
+	LastUsedStr string   `json:"last_used_str"`
+	ExpiresStr  string   `json:"expires_str"`
+	Expired     bool     `json:"expired"`
+}
+
+// 接口权限范围
+type ApiScope struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
@@ -51,6 +51,8 @@ type ApkUser struct {
 	Region   string `json:"region"`
 	NetType  string `json:"nettype"`
 	AppName  string `json:"appname"`
+	AppVer   string `json:"appver"`   // 客户端版本，可选
+	PairCode string `json:"paircode"` // 客户账号配对码，可选
 }
 
 type MovieEngine struct {
@@ -0,0 +1,24 @@
+package dto
+
+import "go-iptv/models"
+
+type AdminAuditLogsDto struct {
+	LoginUser string         `json:"loginuser"`
+	Title     string         `json:"title"`
+	Logs      []AuditLogItem `json:"logs"`
+	Admins    []string       `json:"admins"` // 用于筛选的管理员列表
+	Admin     string         `json:"admin"`
+	Keywords  string         `json:"keywords"`
+	Result    string         `json:"result"` // ok/fail
+	Days      int64          `json:"days"`   // 保留天数
+	Count     int64          `json:"count"`
+	PageCount int64          `json:"pagecount"`
+	Page      int64          `json:"page"`      // 当前页数
+	RecCounts int64          `json:"recCounts"` // 每页显示条数
+}
+
+type AuditLogItem struct {
+	models.IptvAuditLog
+	TimeStr  string `json:"timestr"`
+	RoleName string `json:"rolename"`
+}
@@ -23,7 +23,10 @@ type App struct {
 	NeedAuthor  int64 `mapstructure:"needauthor" json:"needauthor" yaml:"needauthor"`
 	BuffTimeout int64 `mapstructure:"buff_time_out" json:"buff_time_out" yaml:"buff_time_out"`
 	Decoder     int64 `mapstructure:"decoder" json:"decoder" yaml:"decoder"`
-	// TrialDays   int64 `mapstructure:"trialdays" json:"trialdays" yaml:"trialdays"`
+	DefaultMeal int64 `mapstructure:"default_meal" json:"default_meal" yaml:"default_meal"`          // 新设备默认套餐，0为默认套餐1000
+	TrialDays   int64 `mapstructure:"trialdays" json:"trialdays" yaml:"trialdays"`                   // 需要授权时新设备试用天数，0为不试用
+	TrialMaxIP  int64 `mapstructure:"trial_max_ip" json:"trial_max_ip" yaml:"trial_max_ip"`          // 同一IP最多试用设备数，0为不限
+	TrialMaxMod int64 `mapstructure:"trial_max_model" json:"trial_max_model" yaml:"trial_max_model"` // 同一型号最多试用设备数，0为不限
 	// EPGApiChk     int64     `mapstructure:"epgapi_chk" json:"epgapi_chk" yaml:"epgapi_chk"`
 	// MaxSameIPUser int64     `mapstructure:"max_sameip_user" json:"max_sameip_user" yaml:"max_sameip_user"`
 	// IPCount       int64     `mapstructure:"ipcount" json:"ipcount" yaml:"ipcount"`
@@ -35,6 +38,7 @@ type Tips struct {
 	UserExpired   string `mapstructure:"user_expired" json:"user_expired" yaml:"user_expired"`
 	UserForbidden string `mapstructure:"user_forbidden" json:"user_forbidden" yaml:"user_forbidden"`
 	UserNoReg     string `mapstructure:"user_noreg" json:"user_noreg" yaml:"user_noreg"`
+	UserExpiring  string `mapstructure:"user_expiring" json:"user_expiring" yaml:"user_expiring"` // 即将到期提示，{days} 替换为剩余天数
 }
 
 type Ad struct {
@@ -83,9 +87,80 @@ type Epg struct {
 	Fuzz int64 `mapstructure:"fuzz" json:"fuzz" yaml:"fuzz"`
 }
 
+// 异常检测规则，动作: 0关闭 1标记 2重新授权 3封禁
+type Detect struct {
+	IDChange    int64 `mapstructure:"id_change" json:"id_change" yaml:"id_change"`          // 同一MAC设备ID变更
+	MultiIP     int64 `mapstructure:"multi_ip" json:"multi_ip" yaml:"multi_ip"`             // 短时间内多IP/多地区
+	IPCount     int64 `mapstructure:"ip_count" json:"ip_count" yaml:"ip_count"`             // 窗口内IP数阈值，0为不检查
+	RegionCount int64 `mapstructure:"region_count" json:"region_count" yaml:"region_count"` // 窗口内地区数阈值，0为不检查
+	Window      int64 `mapstructure:"window" json:"window" yaml:"window"`                   // 统计窗口，小时
+	VPN         int64 `mapstructure:"vpn" json:"vpn" yaml:"vpn"`                            // 机房/VPN IP段
+}
+
+// 到期处理
+type Expiry struct {
+	RemindDays int64 `mapstructure:"remind_days" json:"remind_days" yaml:"remind_days"` // 到期前N天提醒，0为不提醒
+	IdleDays   int64 `mapstructure:"idle_days" json:"idle_days" yaml:"idle_days"`       // 未授权设备闲置超过N天自动删除，0为不清理
+}
+
+// 访问频率限制，数值为0时使用默认值
+type RateLimit struct {
+	Disable     int64 `mapstructure:"disable" json:"disable" yaml:"disable"`                // 1为关闭频率限制
+	IPRate      int64 `mapstructure:"ip_rate" json:"ip_rate" yaml:"ip_rate"`                // 每个IP每分钟请求数，默认120
+	KeyRate     int64 `mapstructure:"key_rate" json:"key_rate" yaml:"key_rate"`             // 每个账号/设备/token每分钟请求数，默认60
+	LoginFails  int64 `mapstructure:"login_fails" json:"login_fails" yaml:"login_fails"`    // 管理员连续登录失败次数，默认5
+	LockMinutes int64 `mapstructure:"lock_minutes" json:"lock_minutes" yaml:"lock_minutes"` // 首次锁定分钟数，之后每次翻倍，默认5
+}
+
+func (r RateLimit) GetIPRate() int64 {
+	if r.IPRate <= 0 {
+		return 120
+	}
+	return r.IPRate
+}
+
+func (r RateLimit) GetKeyRate() int64 {
+	if r.KeyRate <= 0 {
+		return 60
+	}
+	return r.KeyRate
+}
+
+func (r RateLimit) GetLoginFails() int64 {
+	if r.LoginFails <= 0 {
+		return 5
+	}
+	return r.LoginFails
+}
+
+func (r RateLimit) GetLockMinutes() int64 {
+	if r.LockMinutes <= 0 {
+		return 5
+	}
+	return r.LockMinutes
+}
+
 type System struct {
-	DisPay   int64 `mapstructure:"dispay" json:"dispay" yaml:"dispay"`
-	ShortURL int64 `mapstructure:"short_url" json:"short_url" yaml:"short_url"`
+	DisPay        int64  `mapstructure:"dispay" json:"dispay" yaml:"dispay"`
+	ShortURL      int64  `mapstructure:"short_url" json:"short_url" yaml:"short_url"`
+	AccessLogDays int64  `mapstructure:"access_log_days" json:"access_log_days" yaml:"access_log_days"` // 访问记录保留天数，0为默认30天
+	IPRemote      int64  `mapstructure:"ip_remote" json:"ip_remote" yaml:"ip_remote"`                   // 离线IP库未命中时使用在线接口查询地区
+	JwtSecret     string `mapstructure:"jwt_secret" json:"-" yaml:"jwt_secret"`                         // 后台登录签名密钥，为空时启动自动生成
+	AuditLogDays  int64  `mapstructure:"audit_log_days" json:"audit_log_days" yaml:"audit_log_days"`    // 操作日志保留天数，0为默认180天
+}
+
+func (s System) LogDays() int64 {
+	if s.AccessLogDays <= 0 {
+		return 30
+	}
+	return s.AccessLogDays
+}
+
+func (s System) AuditDays() int64 {
+	if s.AuditLogDays <= 0 {
+		return 180
+	}
+	return s.AuditLogDays
 }
 
 type Config struct {
@@ -101,6 +176,9 @@ type Config struct {
 	Epg         Epg           `mapstructure:"epg" json:"epg" yaml:"epg"`
 	Aggregation Aggregation   `mapstructure:"aggregation" json:"aggregation" yaml:"aggregation"`
 	System      System        `mapstructure:"system" json:"system" yaml:"system"`
+	Detect      Detect        `mapstructure:"detect" json:"detect" yaml:"detect"`
+	Expiry      Expiry        `mapstructure:"expiry" json:"expiry" yaml:"expiry"`
+	RateLimit   RateLimit     `mapstructure:"rate_limit" json:"rate_limit" yaml:"rate_limit"`
 	MyTV        MyTV          `mapstructure:"mytv" json:"mytv" yaml:"mytv"`
 	PHPWeb      int64         `mapstructure:"php_web" json:"php_web" yaml:"php_web"`
 	// Weather   Weather   `mapstructure:"weather" json:"weather" yaml:"weather"`
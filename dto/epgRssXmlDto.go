@@ -56,3 +56,32 @@ type CntvJsonChannel struct {
 }
 
 type CntvData map[string]CntvJsonChannel
+
+// DIYP 接口返回结构 ?ch=CCTV1&date=2025-01-01
+type DiypProgram struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Title string `json:"title"`
+	Desc  string `json:"desc"`
+}
+
+type DiypData struct {
+	ChannelName string        `json:"channel_name"`
+	Date        string        `json:"date"`
+	EpgData     []DiypProgram `json:"epg_data"`
+}
+
+// 当前/下一个节目
+type EpgNowNextItem struct {
+	Title   string `json:"title"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	StartTs int64  `json:"start_ts"`
+	EndTs   int64  `json:"end_ts"`
+}
+
+type EpgNowNext struct {
+	Channel string          `json:"channel"`
+	Now     *EpgNowNextItem `json:"now"`
+	Next    *EpgNowNextItem `json:"next"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+// 到期处理结果及即将到期设备摘要
+type ExpiryDigest struct {
+	Time     string         `json:"time"`     // 生成时间
+	Days     int64          `json:"days"`     // 提醒天数
+	Expired  int64          `json:"expired"`  // 本次转为已过期的设备数
+	Cleaned  int64          `json:"cleaned"`  // 本次清理的闲置未授权设备数
+	Total    int64          `json:"total"`    // 即将到期设备总数
+	Users    []ExpiringUser `json:"users"`    // 即将到期设备，最多显示前20个
+	QueryURL string         `json:"queryurl"` // 设备列表筛选链接
+}
+
+type ExpiringUser struct {
+	Name       int64  `json:"name"`
+	Mac        string `json:"mac"`
+	DeviceID   string `json:"deviceid"`
+	Marks      string `json:"marks"`
+	Exp        string `json:"exp"`
+	RemainDays int64  `json:"remaindays"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "go-iptv/models"
+
+type NotificationItem struct {
+	models.IptvNotification
+	SourceName string `json:"source_name"`
+	LevelName  string `json:"level_name"`
+	LevelClass string `json:"level_class"` // 标签样式
+	TimeStr    string `json:"time_str"`
+}
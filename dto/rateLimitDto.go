@@ -0,0 +1,18 @@
+package dto
+
+type AdminRateLimitDto struct {
+	LoginUser string      `json:"loginuser"`
+	Title     string      `json:"title"`
+	RateLimit RateLimit   `json:"ratelimit"`
+	Blocks    []RateBlock `json:"blocks"`
+}
+
+type RateBlock struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Scope  string `json:"scope"`
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+	Until  string `json:"until"`
+	Remain string `json:"remain"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+// 设备列表筛选条件，日期格式 2006-01-02
+type UserFilter struct {
+	Keywords string `json:"keywords" form:"keywords"`
+	Status   string `json:"status" form:"status"` // 空为已授权，all/days/forever/expired/forbidden/unauth
+	Meal     int64  `json:"meal" form:"meal"`
+	ExpFrom  string `json:"expfrom" form:"expfrom"`
+	ExpTo    string `json:"expto" form:"expto"`
+	Region   string `json:"region" form:"region"`
+	LastFrom string `json:"lastfrom" form:"lastfrom"`
+	LastTo   string `json:"lastto" form:"lastto"`
+	Model    string `json:"model" form:"model"`
+	Mark     string `json:"mark" form:"mark"`
+	Order    string `json:"order" form:"order"`
+	Desc     bool   `json:"desc" form:"desc"`
+}
+
+// 设备导入结果
+type UserImportRes struct {
+	Created int64    `json:"created"`
+	Updated int64    `json:"updated"`
+	Skipped int64    `json:"skipped"`
+	Errors  []string `json:"errors"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "go-iptv/models"
+
+type AdminWebhooksDto struct {
+	LoginUser  string            `json:"loginuser"`
+	Title      string            `json:"title"`
+	Events     []WebhookEvent    `json:"events"`
+	Webhooks   []WebhookItem     `json:"webhooks"`
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	WebhookID  int64             `json:"webhook_id"` // 投递记录筛选
+	Status     string            `json:"status"`
+	Count      int64             `json:"count"`
+	PageCount  int64             `json:"pagecount"`
+	Page       int64             `json:"page"`      // 当前页数
+	RecCounts  int64             `json:"recCounts"` // 每页显示条数
+}
+
+// 可订阅的事件
+type WebhookEvent struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type WebhookItem struct {
+	models.IptvWebhook
+	EventNames []string `json:"event_names"`
+	CreatedStr string   `json:"created_str"`
+	Success    int64    `json:"success"` // 最近24小时成功次数
+	Failed     int64    `json:"failed"`  // 最近24小时失败次数
+}
+
+type WebhookDelivery struct {
+	models.IptvWebhookDelivery
+	WebhookName string `json:"webhook_name"`
+	TimeStr     string `json:"time_str"`
+	NextStr     string `json:"next_str"`
+}
@@ -0,0 +1,69 @@
+package html
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/service"
+	"go-iptv/until"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AccessLogs(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminAccessLogsDto{
+		LoginUser: username,
+		Title:     "访问记录",
+		Days:      dao.GetConfig().System.LogDays(),
+	}
+	pageData.RecCounts, pageData.Page = getPageParams(c)
+
+	pageData.UserName = c.DefaultQuery("user", "")
+	pageData.IP = c.DefaultQuery("ip", "")
+	if !until.IsSafe(pageData.UserName) {
+		pageData.UserName = ""
+	}
+	// IP前缀只允许数字、十六进制字母、点和冒号（IPv6）
+	if strings.Trim(pageData.IP, "0123456789abcdefABCDEF.:") != "" {
+		pageData.IP = ""
+	}
+	pageData.Result = c.DefaultQuery("result", "")
+	if pageData.Result != "ok" && pageData.Result != "deny" {
+		pageData.Result = ""
+	}
+	if pageData.UserName != "" {
+		dao.DB.Model(&models.IptvUser{}).Where("name = ?", pageData.UserName).First(&pageData.User)
+	}
+
+	dbQuery := service.AccessLogsQuery(pageData.UserName, pageData.IP, pageData.Result)
+	err := dbQuery.Count(&pageData.Count).Error
+	if err != nil || pageData.Count == 0 {
+		pageData.PageCount = 1
+	} else {
+		pageData.PageCount = int64(math.Ceil(float64(pageData.Count) / float64(pageData.RecCounts)))
+	}
+
+	var list []models.IptvAccessLog
+	recStart := pageData.RecCounts * (pageData.Page - 1)
+	if err := dbQuery.Order("id desc").Offset(int(recStart)).Limit(int(pageData.RecCounts)).Find(&list).Error; err != nil {
+		log.Println("查询访问记录失败:", err)
+	}
+	for _, v := range list {
+		pageData.Logs = append(pageData.Logs, dto.AccessLogItem{
+			IptvAccessLog: v,
+			TimeStr:       time.Unix(v.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+			ResultText:    service.AccessResultText(v.Result),
+		})
+	}
+
+	c.HTML(200, "admin_access_logs.html", pageData)
+}
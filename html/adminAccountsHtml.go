@@ -0,0 +1,129 @@
+package html
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func accountItem(account models.IptvAccount, mealNames map[int64]string) dto.AccountItem {
+	item := dto.AccountItem{
+		IptvAccount: account,
+		MealName:    mealNames[account.Meal],
+		ExpStr:      "永不到期",
+		ExpDays:     "永久",
+	}
+	if account.Exp > 0 {
+		item.ExpStr = time.Unix(account.Exp, 0).Format("2006-01-02 15:04:05")
+		item.ExpDate = time.Unix(account.Exp, 0).Format("2006-01-02")
+		if days := until.CalcRemainDays(account.Exp); days > 0 {
+			item.ExpDays = "剩" + strconv.FormatInt(days, 10) + "天"
+		} else {
+			item.ExpDays = "过期"
+		}
+	}
+	return item
+}
+
+func Accounts(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminAccountsDto{
+		LoginUser: username,
+		Title:     "客户账号",
+	}
+	pageData.RecCounts, pageData.Page = getPageParams(c)
+
+	pageData.Keywords = c.DefaultQuery("keywords", "")
+	if !until.IsSafe(pageData.Keywords) {
+		pageData.Keywords = ""
+	}
+
+	dbQuery := dao.DB.Model(&models.IptvAccount{})
+	if pageData.Keywords != "" {
+		keywords := "%" + pageData.Keywords + "%"
+		dbQuery = dbQuery.Where("name like ? or pair_code like ? or marks like ?", keywords, keywords, keywords)
+	}
+
+	err := dbQuery.Count(&pageData.Count).Error
+	if err != nil || pageData.Count == 0 {
+		pageData.PageCount = 1
+	} else {
+		pageData.PageCount = int64(math.Ceil(float64(pageData.Count) / float64(pageData.RecCounts)))
+	}
+
+	var list []models.IptvAccount
+	recStart := pageData.RecCounts * (pageData.Page - 1)
+	if err := dbQuery.Order("id desc").Offset(int(recStart)).Limit(int(pageData.RecCounts)).Find(&list).Error; err != nil {
+		log.Println("查询客户账号失败:", err)
+	}
+
+	ids := make([]int64, 0, len(list))
+	for _, v := range list {
+		ids = append(ids, v.ID)
+	}
+	var counts []struct {
+		Account int64
+		Total   int64
+	}
+	dao.DB.Model(&models.IptvUser{}).Select("account, count(*) as total").Where("account in ?", ids).Group("account").Scan(&counts)
+	deviceCount := make(map[int64]int64, len(counts))
+	for _, v := range counts {
+		deviceCount[v.Account] = v.Total
+	}
+
+	mealNames := getMealNames()
+	for _, v := range list {
+		item := accountItem(v, mealNames)
+		item.Devices = deviceCount[v.ID]
+		pageData.Accounts = append(pageData.Accounts, item)
+	}
+
+	dao.DB.Model(&models.IptvMeals{}).Find(&pageData.Meals)
+
+	c.HTML(200, "admin_accounts.html", pageData)
+}
+
+func AccountDevices(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminAccountDevicesDto{
+		LoginUser: username,
+		Title:     "账号设备",
+	}
+
+	id := c.DefaultQuery("id", "")
+	var account models.IptvAccount
+	if !until.IsSafe(id) || dao.DB.Model(&models.IptvAccount{}).Where("id = ?", id).First(&account).Error != nil {
+		c.Redirect(302, "/admin/accounts")
+		return
+	}
+	pageData.Account = accountItem(account, getMealNames())
+	pageData.Title = "账号设备 - " + account.Name
+
+	err := dao.DB.Table(models.IptvUserShow{}.TableName()+" u").Select(`u.*, m.name AS mealname`).
+		Joins("LEFT JOIN iptv_meals m ON u.meal = m.id").
+		Where("u.account = ?", account.ID).Order("u.lasttime desc").Find(&pageData.Devices).Error
+	if err != nil {
+		log.Println("查询账号设备失败:", err)
+	}
+	pageData.Devices = until.CheckUserDay(pageData.Devices)
+	pageData.Account.Devices = int64(len(pageData.Devices))
+
+	dao.DB.Model(&models.IptvAccount{}).Where("id <> ?", account.ID).Order("id desc").Find(&pageData.Accounts)
+
+	c.HTML(200, "admin_account_devices.html", pageData)
+}
@@ -0,0 +1,178 @@
+package html
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/service"
+	"go-iptv/until"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 分页参数，返回每页条数和当前页
+func getPageParams(c *gin.Context) (int64, int64) {
+	recCountsStr := c.DefaultQuery("recCounts", "20")
+	jumptoStr := c.DefaultQuery("jumpto", "")
+	pageStr := c.DefaultQuery("page", "")
+
+	if !until.IsSafe(recCountsStr) || !until.IsSafe(jumptoStr) || !until.IsSafe(pageStr) {
+		recCountsStr = "20"
+		jumptoStr = ""
+		pageStr = ""
+	}
+
+	recCounts, err := strconv.ParseInt(recCountsStr, 10, 64)
+	if err != nil || recCounts <= 0 {
+		recCounts = 20
+	}
+
+	var page int64 = 1
+	if jumptoStr != "" {
+		page, err = strconv.ParseInt(jumptoStr, 10, 64)
+	} else if pageStr != "" {
+		page, err = strconv.ParseInt(pageStr, 10, 64)
+	}
+	if err != nil || page < 1 {
+		page = 1
+	}
+	return recCounts, page
+}
+
+func getMealNames() map[int64]string {
+	var meals []models.IptvMeals
+	dao.DB.Model(&models.IptvMeals{}).Find(&meals)
+	res := make(map[int64]string, len(meals))
+	for _, m := range meals {
+		res[m.ID] = m.Name
+	}
+	return res
+}
+
+func ActCodes(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminActCodesDto{
+		LoginUser: username,
+		Title:     "卡密管理",
+	}
+	pageData.RecCounts, pageData.Page = getPageParams(c)
+
+	pageData.Keywords = c.DefaultQuery("keywords", "")
+	pageData.Batch = c.DefaultQuery("batch", "")
+	pageData.State = c.DefaultQuery("state", "")
+	if !until.IsSafe(pageData.Keywords) || !until.IsSafe(pageData.Batch) {
+		pageData.Keywords = ""
+		pageData.Batch = ""
+	}
+	switch pageData.State {
+	case "unused", "used", "disabled", "expired":
+	default:
+		pageData.State = ""
+	}
+
+	dbQuery := service.ActCodesQuery(pageData.Batch, pageData.State, pageData.Keywords)
+	err := dbQuery.Count(&pageData.Count).Error
+	if err != nil || pageData.Count == 0 {
+		pageData.PageCount = 1
+	} else {
+		pageData.PageCount = int64(math.Ceil(float64(pageData.Count) / float64(pageData.RecCounts)))
+	}
+
+	var list []models.IptvActCode
+	recStart := pageData.RecCounts * (pageData.Page - 1)
+	if err := dbQuery.Order("id desc").Offset(int(recStart)).Limit(int(pageData.RecCounts)).Find(&list).Error; err != nil {
+		log.Println("查询卡密失败:", err)
+	}
+
+	mealNames := getMealNames()
+	for _, v := range list {
+		item := dto.ActCodeItem{
+			IptvActCode: v,
+			MealName:    mealNames[v.Meal],
+			ExpiresStr:  "不限",
+			CreatedStr:  time.Unix(v.CreatedAt, 0).Format("2006-01-02 15:04"),
+			StateDesc:   service.ActCodeStateDesc(v),
+		}
+		item.Code = service.FormatActCode(v.Code)
+		if v.ExpiresAt > 0 {
+			item.ExpiresStr = time.Unix(v.ExpiresAt, 0).Format("2006-01-02")
+		}
+		if v.UsedCount > 0 {
+			var last models.IptvActCodeLog
+			dao.DB.Model(&models.IptvActCodeLog{}).Where("code_id = ?", v.ID).Order("id desc").First(&last)
+			item.UsedByDevice = last.DeviceID
+			if item.UsedByDevice == "" {
+				item.UsedByDevice = last.Mac
+			}
+		}
+		pageData.Codes = append(pageData.Codes, item)
+	}
+
+	dao.DB.Model(&models.IptvMeals{}).Where("status = 1").Find(&pageData.Meals)
+	dao.DB.Model(&models.IptvActCode{}).Distinct("batch").Order("batch desc").Pluck("batch", &pageData.Batches)
+
+	c.HTML(200, "admin_act_codes.html", pageData)
+}
+
+func ActCodeLogs(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminActCodeLogsDto{
+		LoginUser: username,
+		Title:     "卡密使用记录",
+	}
+	pageData.RecCounts, pageData.Page = getPageParams(c)
+
+	pageData.Keywords = c.DefaultQuery("keywords", "")
+	if !until.IsSafe(pageData.Keywords) {
+		pageData.Keywords = ""
+	}
+
+	dbQuery := dao.DB.Model(&models.IptvActCodeLog{})
+	if pageData.Keywords != "" {
+		keywords := "%" + pageData.Keywords + "%"
+		dbQuery = dbQuery.Where("code like ? or CAST(user_name AS TEXT) like ? or deviceid like ? or mac like ? or ip like ?",
+			"%"+service.NormalizeActCode(pageData.Keywords)+"%", keywords, keywords, keywords, keywords)
+	}
+
+	err := dbQuery.Count(&pageData.Count).Error
+	if err != nil || pageData.Count == 0 {
+		pageData.PageCount = 1
+	} else {
+		pageData.PageCount = int64(math.Ceil(float64(pageData.Count) / float64(pageData.RecCounts)))
+	}
+
+	var list []models.IptvActCodeLog
+	recStart := pageData.RecCounts * (pageData.Page - 1)
+	if err := dbQuery.Order("id desc").Offset(int(recStart)).Limit(int(pageData.RecCounts)).Find(&list).Error; err != nil {
+		log.Println("查询卡密使用记录失败:", err)
+	}
+
+	mealNames := getMealNames()
+	for _, v := range list {
+		item := dto.ActCodeLogItem{
+			IptvActCodeLog: v,
+			MealName:       mealNames[v.Meal],
+			ExpStr:         "永不到期",
+			CreatedStr:     time.Unix(v.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+		}
+		item.Code = service.FormatActCode(v.Code)
+		if v.Exp > 0 {
+			item.ExpStr = time.Unix(v.Exp, 0).Format("2006-01-02 15:04")
+		}
+		pageData.Logs = append(pageData.Logs, item)
+	}
+
+	c.HTML(200, "admin_act_code_logs.html", pageData)
+}
@@ -4,8 +4,11 @@ import (
 	"go-iptv/dao"
 	"go-iptv/dto"
 	"go-iptv/models"
+	"go-iptv/service"
 	"go-iptv/until"
+	"html/template"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,7 +25,40 @@ func Admins(c *gin.Context) {
 		Title:     "管理员设置",
 	}
 
-	dao.DB.Model(&models.IptvAdmin{}).Where("id = 1").First(&pageData.Admins)
+	dao.DB.Model(&models.IptvAdmin{}).Where("username = ?", username).First(&pageData.Admin)
+	pageData.RoleName = until.RoleName(pageData.Admin.Role)
+	if qr := service.TotpQR(pageData.Admin); qr != "" {
+		pageData.TotpQR = template.URL(qr)
+		pageData.TotpKey = pageData.Admin.TotpKey
+	}
+	pageData.Recovery = service.RecoveryCount(pageData.Admin)
+	pageData.IsOwner = until.HasPerm(until.GetAuthRole(c), until.PermAdmins)
+	pageData.Sessions = service.AdminSessions(username, until.GetAuthSid(c), pageData.IsOwner)
+	for _, r := range until.Roles {
+		pageData.Roles = append(pageData.Roles, dto.RoleOption{Value: r, Name: until.RoleName(r)})
+	}
+
+	if pageData.IsOwner {
+		var admins []models.IptvAdmin
+		dao.DB.Model(&models.IptvAdmin{}).Order("id asc").Find(&admins)
+		for _, a := range admins {
+			item := dto.AdminItem{
+				ID:       a.ID,
+				UserName: a.UserName,
+				Role:     a.Role,
+				RoleName: until.RoleName(a.Role),
+				Status:   a.Status,
+				Totp:     a.Totp,
+			}
+			if a.CreatedAt > 0 {
+				item.CreatedAt = time.Unix(a.CreatedAt, 0).Format("2006-01-02 15:04:05")
+			}
+			if a.LastLogin > 0 {
+				item.LastLogin = time.Unix(a.LastLogin, 0).Format("2006-01-02 15:04:05")
+			}
+			pageData.Admins = append(pageData.Admins, item)
+		}
+	}
 
 	c.HTML(http.StatusOK, "admin_admins.html", pageData)
 }
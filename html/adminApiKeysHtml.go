@@ -0,0 +1,28 @@
+package html
+
+import (
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApiKeys(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+
+	var pageData = dto.AdminApiKeysDto{
+		LoginUser: username,
+		Title:     "开放接口",
+		Keys:      service.ApiKeys(),
+		Scopes:    service.ApiScopes,
+		BaseUrl:   strings.TrimSuffix(until.GetUrl(c), "/admin") + "/api/v1",
+	}
+
+	c.HTML(200, "admin_api_keys.html", pageData)
+}
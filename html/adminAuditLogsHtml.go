@@ -0,0 +1,62 @@
+package html
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/service"
+	"go-iptv/until"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AuditLogs(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminAuditLogsDto{
+		LoginUser: username,
+		Title:     "操作日志",
+		Days:      dao.GetConfig().System.AuditDays(),
+	}
+	pageData.RecCounts, pageData.Page = getPageParams(c)
+
+	pageData.Admin = c.DefaultQuery("admin", "")
+	pageData.Keywords = c.DefaultQuery("keywords", "")
+	if !until.IsSafe(pageData.Admin) || !until.IsSafe(pageData.Keywords) {
+		pageData.Admin, pageData.Keywords = "", ""
+	}
+	pageData.Result = c.DefaultQuery("result", "")
+	if pageData.Result != "ok" && pageData.Result != "fail" {
+		pageData.Result = ""
+	}
+	dao.DB.Model(&models.IptvAuditLog{}).Distinct().Order("admin").Pluck("admin", &pageData.Admins)
+
+	dbQuery := service.AuditLogsQuery(pageData.Admin, pageData.Keywords, pageData.Result)
+	err := dbQuery.Count(&pageData.Count).Error
+	if err != nil || pageData.Count == 0 {
+		pageData.PageCount = 1
+	} else {
+		pageData.PageCount = int64(math.Ceil(float64(pageData.Count) / float64(pageData.RecCounts)))
+	}
+
+	var list []models.IptvAuditLog
+	recStart := pageData.RecCounts * (pageData.Page - 1)
+	if err := dbQuery.Order("id desc").Offset(int(recStart)).Limit(int(pageData.RecCounts)).Find(&list).Error; err != nil {
+		log.Println("查询操作日志失败:", err)
+	}
+	for _, v := range list {
+		pageData.Logs = append(pageData.Logs, dto.AuditLogItem{
+			IptvAuditLog: v,
+			TimeStr:      time.Unix(v.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+			RoleName:     until.RoleName(v.Role),
+		})
+	}
+
+	c.HTML(200, "admin_audit_logs.html", pageData)
+}
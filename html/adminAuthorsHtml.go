@@ -73,8 +73,18 @@ func Authors(c *gin.Context) {
 
 	// 基础查询
 	dbQuery := dao.DB.Model(&models.IptvUser{}).
-		Select(`name,deviceid,model,ip,region,lasttime,exp,status`).
-		Where("status <= ?", 0)
+		Select(`name,deviceid,model,ip,region,lasttime,exp,status,vpn,idchange,multiip,flag`)
+
+	// 异常设备不区分授权状态
+	flaggedWhere := "vpn > 0 or multiip > 0 or flag <> ''"
+	dao.DB.Model(&models.IptvUser{}).Where(flaggedWhere).Count(&pageData.FlaggedTotal)
+	pageData.Flagged = c.DefaultQuery("flagged", "")
+	if pageData.Flagged == "1" {
+		dbQuery = dbQuery.Where(flaggedWhere)
+	} else {
+		pageData.Flagged = ""
+		dbQuery = dbQuery.Where("status <= ?", 0)
+	}
 
 	dbQuery = dbQuery.Where(
 		"name LIKE ? OR deviceid LIKE ? OR model LIKE ? OR ip LIKE ? OR region LIKE ? OR status LIKE ?",
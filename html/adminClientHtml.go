@@ -4,6 +4,7 @@ import (
 	"go-iptv/bootstrap"
 	"go-iptv/dao"
 	"go-iptv/dto"
+	"go-iptv/models"
 	"go-iptv/until"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +25,7 @@ func Client(c *gin.Context) {
 		Build:       cfg.Build,
 		App:         cfg.App,
 		Tips:        cfg.Tips,
+		Expiry:      cfg.Expiry,
 		ApkUrl:      "/app/" + cfg.Build.Name + ".apk",
 		ApkName:     cfg.Build.Name + ".apk",
 		UpSize:      until.GetFileSize("/config/app/" + cfg.Build.Name + ".apk"),
@@ -35,6 +37,7 @@ func Client(c *gin.Context) {
 	}
 
 	pageData.BjUrl, _ = until.GetPngFileNames("/config/images/bj")
+	dao.DB.Model(&models.IptvMeals{}).Find(&pageData.Meals)
 
 	c.HTML(200, "admin_client.html", pageData)
 }
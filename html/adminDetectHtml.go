@@ -0,0 +1,31 @@
+package html
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Detect(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+
+	var pageData = dto.AdminDetectDto{
+		LoginUser: username,
+		Title:     "异常检测",
+		Detect:    dao.GetConfig().Detect,
+		CidrCount: until.VpnCidrCount(),
+	}
+	if until.Exists(until.VpnCidrFile) {
+		pageData.Cidrs = until.ReadFile(until.VpnCidrFile)
+	}
+	dao.DB.Model(&models.IptvUser{}).Where("vpn > 0 or multiip > 0 or flag <> ''").Count(&pageData.FlaggedTotal)
+
+	c.HTML(200, "admin_detect.html", pageData)
+}
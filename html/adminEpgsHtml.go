@@ -98,6 +98,15 @@ func Epgs(c *gin.Context) {
 
 	logoList := until.GetLogos() // 获取logo列表
 
+	fromNames := make(map[string]string) // 来源ID -> 名称
+	for _, p := range until.GetFixedEpgProviders() {
+		fromNames[p.ID()] = p.Name()
+		pageData.EpgProviders = append(pageData.EpgProviders, dto.EpgsReturnDto{Name: p.Name(), Value: p.ID()})
+	}
+	for _, b := range pageData.EpgFromDb {
+		fromNames[fmt.Sprintf("%d", b.ID)] = b.Name
+	}
+
 	for k, v := range pageData.Epgs {
 		for _, logo := range logoList {
 			logoName := strings.Split(logo, ".")[0]
@@ -106,14 +115,8 @@ func Epgs(c *gin.Context) {
 			}
 		}
 		for _, a := range strings.Split(v.FromListStr, ",") {
-			if a == "0" {
-				pageData.Epgs[k].FromName += "CCTV官网,"
-				continue
-			}
-			for _, b := range pageData.EpgFromDb {
-				if a == fmt.Sprintf("%d", b.ID) {
-					pageData.Epgs[k].FromName += b.Name + ","
-				}
+			if name, ok := fromNames[a]; ok {
+				pageData.Epgs[k].FromName += name + ","
 			}
 		}
 		pageData.Epgs[k].FromName = strings.TrimRight(pageData.Epgs[k].FromName, ",")
@@ -143,3 +146,190 @@ func EpgsFrom(c *gin.Context) {
 
 	c.HTML(200, "admin_epgs_list.html", pageData)
 }
+
+func EpgAlias(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminEpgAliasDto{
+		LoginUser: username,
+		Title:     "EPG别名",
+	}
+
+	recCountsStr := c.DefaultQuery("recCounts", "20")
+	jumptoStr := c.DefaultQuery("jumpto", "")
+	pageStr := c.DefaultQuery("page", "")
+
+	if !until.IsSafe(recCountsStr) || !until.IsSafe(jumptoStr) || !until.IsSafe(pageStr) {
+		recCountsStr = "20"
+		jumptoStr = ""
+		pageStr = ""
+	}
+
+	recCounts, err := strconv.ParseInt(recCountsStr, 10, 64)
+	if err != nil || recCounts <= 0 {
+		recCounts = 20
+	}
+	pageData.RecCounts = recCounts
+
+	if jumptoStr != "" {
+		pageData.Page, err = strconv.ParseInt(jumptoStr, 10, 64)
+		if err != nil {
+			pageData.Page = 1
+		}
+	} else if pageStr != "" {
+		pageData.Page, err = strconv.ParseInt(pageStr, 10, 64)
+		if err != nil {
+			pageData.Page = 1
+		}
+	} else {
+		pageData.Page = 1
+	}
+	if pageData.Page < 1 {
+		pageData.Page = 1
+	}
+
+	pageData.Keywords = c.DefaultQuery("keywords", "")
+	if !until.IsSafe(pageData.Keywords) {
+		pageData.Keywords = ""
+	}
+	pageData.Source = c.DefaultQuery("source", "")
+	if pageData.Source != "builtin" && pageData.Source != "local" && pageData.Source != "community" {
+		pageData.Source = ""
+	}
+
+	recStart := recCounts * (pageData.Page - 1)
+	dbQuery := dao.DB.Model(&models.IptvEpgAlias{})
+	if pageData.Keywords != "" {
+		keywords := "%" + pageData.Keywords + "%" // 模糊查询
+		dbQuery = dbQuery.Where("alias like ? or name like ?", keywords, keywords)
+	}
+	if pageData.Source != "" {
+		dbQuery = dbQuery.Where("source = ?", pageData.Source)
+	}
+
+	err = dbQuery.Count(&pageData.Count).Error
+	if err != nil || pageData.Count == 0 {
+		pageData.PageCount = 1
+	} else {
+		pageData.PageCount = int64(math.Ceil(float64(pageData.Count) / float64(recCounts)))
+	}
+
+	err = dbQuery.Order("id desc").Offset(int(recStart)).Limit(int(recCounts)).Find(&pageData.Aliases).Error
+	if err != nil {
+		log.Println("查询epg别名失败:", err)
+	}
+
+	dao.DB.Model(&models.IptvEpg{}).Distinct("name").Pluck("name", &pageData.EpgNames)
+
+	c.HTML(200, "admin_epg_alias.html", pageData)
+}
+
+func EpgCustom(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminEpgCustomDto{
+		LoginUser: username,
+		Title:     "自定义节目单",
+	}
+
+	recCountsStr := c.DefaultQuery("recCounts", "20")
+	jumptoStr := c.DefaultQuery("jumpto", "")
+	pageStr := c.DefaultQuery("page", "")
+
+	if !until.IsSafe(recCountsStr) || !until.IsSafe(jumptoStr) || !until.IsSafe(pageStr) {
+		recCountsStr = "20"
+		jumptoStr = ""
+		pageStr = ""
+	}
+
+	recCounts, err := strconv.ParseInt(recCountsStr, 10, 64)
+	if err != nil || recCounts <= 0 {
+		recCounts = 20
+	}
+	pageData.RecCounts = recCounts
+
+	if jumptoStr != "" {
+		pageData.Page, err = strconv.ParseInt(jumptoStr, 10, 64)
+		if err != nil {
+			pageData.Page = 1
+		}
+	} else if pageStr != "" {
+		pageData.Page, err = strconv.ParseInt(pageStr, 10, 64)
+		if err != nil {
+			pageData.Page = 1
+		}
+	} else {
+		pageData.Page = 1
+	}
+	if pageData.Page < 1 {
+		pageData.Page = 1
+	}
+
+	pageData.Keywords = c.DefaultQuery("keywords", "")
+	if !until.IsSafe(pageData.Keywords) {
+		pageData.Keywords = ""
+	}
+	pageData.Channel = c.DefaultQuery("channel", "")
+	if !until.IsSafe(pageData.Channel) {
+		pageData.Channel = ""
+	}
+
+	recStart := recCounts * (pageData.Page - 1)
+	dbQuery := dao.DB.Model(&models.IptvEpgCustom{})
+	if pageData.Keywords != "" {
+		keywords := "%" + pageData.Keywords + "%" // 模糊查询
+		dbQuery = dbQuery.Where("title like ? or description like ?", keywords, keywords)
+	}
+	if pageData.Channel != "" {
+		dbQuery = dbQuery.Where("channel = ?", pageData.Channel)
+	}
+
+	err = dbQuery.Count(&pageData.Count).Error
+	if err != nil || pageData.Count == 0 {
+		pageData.PageCount = 1
+	} else {
+		pageData.PageCount = int64(math.Ceil(float64(pageData.Count) / float64(recCounts)))
+	}
+
+	var list []models.IptvEpgCustom
+	err = dbQuery.Order("channel, type desc, start_at, start_time").Offset(int(recStart)).Limit(int(recCounts)).Find(&list).Error
+	if err != nil {
+		log.Println("查询自定义节目失败:", err)
+	}
+
+	weekNames := []string{"日", "一", "二", "三", "四", "五", "六"}
+	for _, v := range list {
+		item := dto.EpgCustomItem{IptvEpgCustom: v}
+		if v.Type == "once" {
+			item.StartAtStr = until.FormatEpgCustomTime(v.StartAt)
+			item.EndAtStr = until.FormatEpgCustomTime(v.EndAt)
+			item.TimeStr = item.StartAtStr + " ~ " + item.EndAtStr
+			item.WeekStr = "单次"
+		} else {
+			item.TimeStr = v.StartTime + " ~ " + v.EndTime
+			if v.Weekdays == "" {
+				item.WeekStr = "每天"
+			} else {
+				var days []string
+				for _, d := range strings.Split(v.Weekdays, ",") {
+					if n, err := strconv.Atoi(d); err == nil && n >= 0 && n < 7 {
+						days = append(days, weekNames[n])
+					}
+				}
+				item.WeekStr = "周" + strings.Join(days, "、")
+			}
+		}
+		pageData.Customs = append(pageData.Customs, item)
+	}
+
+	dao.DB.Model(&models.IptvEpgCustom{}).Distinct("channel").Order("channel").Pluck("channel", &pageData.Channels)
+	dao.DB.Model(&models.IptvEpg{}).Distinct("name").Pluck("name", &pageData.EpgNames)
+
+	c.HTML(200, "admin_epg_custom.html", pageData)
+}
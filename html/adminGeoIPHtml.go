@@ -0,0 +1,31 @@
+package html
+
+import (
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GeoIP(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+
+	var pageData = dto.AdminGeoIPDto{
+		LoginUser: username,
+		Title:     "IP地址库",
+		IPRemote:  dao.GetConfig().System.IPRemote,
+	}
+	if size, modTime := until.GeoIPInfo(); size > 0 {
+		pageData.Loaded = true
+		pageData.Size = fmt.Sprintf("%.2f MB", float64(size)/1024/1024)
+		pageData.UpdatedAt = modTime.Format("2006-01-02 15:04:05")
+	}
+
+	c.HTML(200, "admin_geoip.html", pageData)
+}
@@ -0,0 +1,53 @@
+package html
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/service"
+	"go-iptv/until"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func IPPolicies(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminIPPoliciesDto{
+		LoginUser: username,
+		Title:     "IP访问策略",
+	}
+
+	dao.DB.Model(&models.IptvMeals{}).Find(&pageData.Meals)
+	dao.DB.Model(&models.IptvMealToken{}).Order("id desc").Find(&pageData.Tokens)
+	mealNames := getMealNames()
+	tokenNames := map[int64]string{}
+	for _, t := range pageData.Tokens {
+		tokenNames[t.ID] = "#" + strconv.FormatInt(t.ID, 10) + " " + t.Remark
+	}
+
+	var list []models.IptvIPPolicy
+	dao.DB.Model(&models.IptvIPPolicy{}).Order("scope, target, id").Find(&list)
+	for _, v := range list {
+		item := dto.IPPolicyItem{
+			IptvIPPolicy: v,
+			ScopeText:    service.PolicyScopeText(v.Scope),
+			TypeText:     service.PolicyTypeText(v.Type),
+			CreatedStr:   time.Unix(v.CreatedAt, 0).Format("2006-01-02 15:04"),
+		}
+		switch v.Scope {
+		case service.PolicyMeal:
+			item.TargetName = mealNames[v.Target]
+		case service.PolicyToken:
+			item.TargetName = tokenNames[v.Target]
+		}
+		pageData.Policies = append(pageData.Policies, item)
+	}
+
+	c.HTML(200, "admin_ip_policies.html", pageData)
+}
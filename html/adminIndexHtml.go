@@ -1,9 +1,11 @@
 package html
 
 import (
+	"go-iptv/crontab"
 	"go-iptv/dao"
 	"go-iptv/dto"
 	"go-iptv/models"
+	"go-iptv/service"
 	"go-iptv/until"
 	"time"
 
@@ -54,5 +56,9 @@ func Index(c *gin.Context) {
 		pageData.ChannelTypeList = append(pageData.ChannelTypeList, channelType)
 	}
 
+	pageData.Expiry = crontab.GetExpiryDigest()
+	pageData.Notifications = service.Notifications()
+	pageData.Unread = until.UnreadNotifications()
+
 	c.HTML(200, "admin_index.html", pageData)
 }
@@ -0,0 +1,27 @@
+package html
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/service"
+	"go-iptv/until"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RateLimits(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+
+	var pageData = dto.AdminRateLimitDto{
+		LoginUser: username,
+		Title:     "访问频率限制",
+		RateLimit: dao.GetConfig().RateLimit,
+		Blocks:    service.RateBlocks(),
+	}
+
+	c.HTML(200, "admin_rate_limits.html", pageData)
+}
@@ -4,7 +4,9 @@ import (
 	"go-iptv/dao"
 	"go-iptv/dto"
 	"go-iptv/models"
+	"go-iptv/service"
 	"go-iptv/until"
+	"html/template"
 	"log"
 	"math"
 	"strconv"
@@ -55,12 +57,21 @@ func Users(c *gin.Context) {
 		pageData.Page = 1
 	}
 
-	pageData.Order = c.DefaultQuery("order", "id")
-	pageData.Keywords = c.DefaultQuery("keywords", "")
+	if pageData.Page < 1 {
+		pageData.Page = 1
+	}
+	if pageData.RecCounts <= 0 {
+		pageData.RecCounts = 20
+	}
 
-	if !until.IsSafe(pageData.Order) || !until.IsSafe(pageData.Keywords) {
-		pageData.Order = "id"
-		pageData.Keywords = ""
+	pageData.Filter = service.ParseUserFilter(c.Request.URL.Query())
+	pageData.Order = pageData.Filter.Order
+	pageData.Keywords = pageData.Filter.Keywords
+	filterValues := service.UserFilterValues(pageData.Filter)
+	pageData.FilterURL = template.URL(filterValues.Encode())
+	pageData.FilterValues = make(map[string]string, len(filterValues))
+	for k := range filterValues {
+		pageData.FilterValues[k] = filterValues.Get(k)
 	}
 
 	today := time.Now().Truncate(24 * time.Hour).Unix()
@@ -68,35 +79,15 @@ func Users(c *gin.Context) {
 	// dao.DB.Model(&models.IptvUserShow{}).Count(&pageData.UserTotal)
 	dao.DB.Model(&models.IptvUserShow{}).Where("lasttime > ?", today).Count(&pageData.UserToday)
 
-	recStart := recCounts * (pageData.Page - 1)
-	keywords := "%" + pageData.Keywords + "%"
-
-	// 基础查询
-	dbQuery := dao.DB.Table(models.IptvUserShow{}.TableName()+" u").Select(`u.*, m.name AS mealname`).
-		Joins("LEFT JOIN iptv_meals m ON u.meal = m.id").Where("u.status > ?", 0)
-
-	// 增加搜索条件
-	dbQuery = dbQuery.Where(
-		"u.name LIKE ? OR u.deviceid LIKE ? OR u.mac LIKE ? OR u.model LIKE ? OR u.ip LIKE ? OR u.region LIKE ? OR u.author LIKE ? OR u.marks LIKE ? OR CAST(u.status AS CHAR) LIKE ?",
-		keywords, keywords, keywords, keywords, keywords, keywords, keywords, keywords, keywords,
-	)
-
-	err = dbQuery.Count(&pageData.UserTotal).Error
+	pageData.Users, pageData.UserTotal, err = service.QueryUsers(pageData.Filter, pageData.Page, pageData.RecCounts)
 	if err != nil {
+		log.Println("查询用户失败:", err)
+	}
+	if pageData.UserTotal == 0 {
 		pageData.PageCount = 1
 	} else {
-		if pageData.UserTotal == 0 {
-			pageData.PageCount = 1
-		} else {
-			pageData.PageCount = int64(math.Ceil(float64(pageData.UserTotal) / float64(recCounts)))
-		}
-	}
-
-	err = dbQuery.Offset(int(recStart)).Limit(int(recCounts)).Order("u." + pageData.Order).Find(&pageData.Users).Error
-	if err != nil {
-		log.Println("查询用户失败:", err)
+		pageData.PageCount = int64(math.Ceil(float64(pageData.UserTotal) / float64(pageData.RecCounts)))
 	}
-	pageData.Users = until.CheckUserDay(pageData.Users)
 
 	dao.DB.Model(&models.IptvMeals{}).Find(&pageData.Meals)
 
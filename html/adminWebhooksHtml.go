@@ -0,0 +1,66 @@
+package html
+
+import (
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/service"
+	"go-iptv/until"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Webhooks(c *gin.Context) {
+	username, ok := until.GetAuthName(c)
+	if !ok {
+		c.JSON(200, dto.NewAdminRedirectDto())
+		return
+	}
+	var pageData = dto.AdminWebhooksDto{
+		LoginUser: username,
+		Title:     "Webhook推送",
+		Events:    until.WebhookEvents,
+		Webhooks:  service.Webhooks(),
+	}
+	pageData.RecCounts, pageData.Page = getPageParams(c)
+
+	pageData.WebhookID, _ = strconv.ParseInt(c.DefaultQuery("webhook", "0"), 10, 64)
+	pageData.Status = c.DefaultQuery("status", "")
+	if pageData.Status != "0" && pageData.Status != "1" && pageData.Status != "2" {
+		pageData.Status = ""
+	}
+
+	dbQuery := service.WebhookDeliveriesQuery(pageData.WebhookID, pageData.Status)
+	err := dbQuery.Count(&pageData.Count).Error
+	if err != nil || pageData.Count == 0 {
+		pageData.PageCount = 1
+	} else {
+		pageData.PageCount = int64(math.Ceil(float64(pageData.Count) / float64(pageData.RecCounts)))
+	}
+
+	names := make(map[int64]string)
+	for _, h := range pageData.Webhooks {
+		names[h.ID] = h.Name
+	}
+	var list []models.IptvWebhookDelivery
+	recStart := pageData.RecCounts * (pageData.Page - 1)
+	if err := dbQuery.Order("id desc").Offset(int(recStart)).Limit(int(pageData.RecCounts)).Find(&list).Error; err != nil {
+		log.Println("查询Webhook投递记录失败:", err)
+	}
+	for _, v := range list {
+		item := dto.WebhookDelivery{
+			IptvWebhookDelivery: v,
+			WebhookName:         names[v.WebhookID],
+			TimeStr:             time.Unix(v.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+		}
+		if v.Status == 0 {
+			item.NextStr = time.Unix(v.NextAt, 0).Format("2006-01-02 15:04:05")
+		}
+		pageData.Deliveries = append(pageData.Deliveries, item)
+	}
+
+	c.HTML(200, "admin_webhooks.html", pageData)
+}
@@ -111,10 +111,14 @@ func main() {
 
 	go crontab.Crontab()
 	go crontab.EpgCron()
+	go crontab.AccessLogCron()
+	go crontab.ExpiryCron()
+	go crontab.DiskCron()
 	go until.InitCacheRebuild()
+	go until.WebhookWorker()
 
+	bootstrap.InitJwtKey() // 初始化JWTkey
 	if !debug {
-		bootstrap.InitJwtKey() // 初始化JWTkey
 		if build {
 			go bootstrap.BuildAPK()
 		}
@@ -0,0 +1,20 @@
+package models
+
+// 设备访问记录，只追加，按保留天数定期清理
+type IptvAccessLog struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserName  int64  `gorm:"column:user_name;index" json:"user_name"`
+	DeviceID  string `gorm:"column:deviceid" json:"deviceid"`
+	Mac       string `gorm:"column:mac" json:"mac"`
+	IP        string `gorm:"column:ip;index" json:"ip"`
+	Region    string `gorm:"column:region" json:"region"`
+	AppName   string `gorm:"column:appname" json:"appname"`
+	AppVer    string `gorm:"column:appver" json:"appver"`
+	Endpoint  string `gorm:"column:endpoint" json:"endpoint"`
+	Result    string `gorm:"column:result" json:"result"` // ok 或授权拒绝原因
+	CreatedAt int64  `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (IptvAccessLog) TableName() string {
+	return "iptv_access_logs"
+}
@@ -0,0 +1,18 @@
+package models
+
+// 客户账号，一个账号下可绑定多台设备，套餐和到期时间以账号为准
+type IptvAccount struct {
+	ID         int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name       string `gorm:"column:name;not null" json:"name"`
+	Meal       int64  `gorm:"column:meal;not null" json:"meal"`
+	Exp        int64  `gorm:"column:exp;default:0" json:"exp"`                 // 到期时间，0为永久
+	MaxDevices int64  `gorm:"column:max_devices;default:0" json:"max_devices"` // 最多设备数，0为不限
+	PairCode   string `gorm:"column:pair_code;unique" json:"pair_code"`        // 设备配对码
+	Status     int64  `gorm:"column:status;default:1" json:"status"`
+	Marks      string `gorm:"column:marks" json:"marks"`
+	CreatedAt  int64  `gorm:"column:created_at;default:0" json:"created_at"`
+}
+
+func (IptvAccount) TableName() string {
+	return "iptv_accounts"
+}
@@ -0,0 +1,40 @@
+package models
+
+// 卡密
+type IptvActCode struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Code      string `gorm:"column:code;not null;unique" json:"code"`
+	Meal      int64  `gorm:"column:meal;not null" json:"meal"`
+	Days      int64  `gorm:"column:days;default:0" json:"days"`         // 授权天数，0为永久
+	MaxUses   int64  `gorm:"column:max_uses;default:1" json:"max_uses"` // 可使用次数
+	UsedCount int64  `gorm:"column:used_count;default:0" json:"used_count"`
+	ExpiresAt int64  `gorm:"column:expires_at;default:0" json:"expires_at"` // 卡密有效期，0为不限
+	Batch     string `gorm:"column:batch;index" json:"batch"`               // 批次标签
+	Status    int64  `gorm:"column:status;default:1" json:"status"`
+	Author    string `gorm:"column:author" json:"author"`
+	CreatedAt int64  `gorm:"column:created_at;default:0" json:"created_at"`
+}
+
+func (IptvActCode) TableName() string {
+	return "iptv_act_codes"
+}
+
+// 卡密使用记录，同一设备不能重复使用同一卡密
+type IptvActCodeLog struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	CodeID    int64  `gorm:"column:code_id;not null;uniqueIndex:idx_act_code_user" json:"code_id"`
+	Code      string `gorm:"column:code;index" json:"code"`
+	UserName  int64  `gorm:"column:user_name;not null;uniqueIndex:idx_act_code_user" json:"user_name"`
+	DeviceID  string `gorm:"column:deviceid" json:"deviceid"`
+	Mac       string `gorm:"column:mac" json:"mac"`
+	IP        string `gorm:"column:ip" json:"ip"`
+	Client    string `gorm:"column:client" json:"client"` // apk、mytv
+	Meal      int64  `gorm:"column:meal" json:"meal"`
+	Days      int64  `gorm:"column:days" json:"days"`
+	Exp       int64  `gorm:"column:exp" json:"exp"` // 兑换后的到期时间，0为永久
+	CreatedAt int64  `gorm:"column:created_at;default:0" json:"created_at"`
+}
+
+func (IptvActCodeLog) TableName() string {
+	return "iptv_act_code_logs"
+}
@@ -1,9 +1,17 @@
 package models
 
 type IptvAdmin struct {
-	ID       int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	UserName string `gorm:"column:username" json:"username"`
-	PassWord string `gorm:"column:password" json:"password"`
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserName  string `gorm:"column:username" json:"username"`
+	PassWord  string `gorm:"column:password" json:"-"`
+	Role      string `gorm:"column:role;default:owner" json:"role"` // 角色: owner/operator/support/readonly
+	Status    int64  `gorm:"column:status;default:1" json:"status"` // 1启用 0禁用
+	CreatedAt int64  `gorm:"column:created_at;default:0" json:"created_at"`
+	LastLogin int64  `gorm:"column:last_login;default:0" json:"last_login"`
+	TotpKey   string `gorm:"column:totp_key" json:"-"`            // 两步验证密钥，未验证前为待启用
+	Totp      int64  `gorm:"column:totp;default:0" json:"totp"`   // 1已开启两步验证
+	Recovery  string `gorm:"column:recovery_codes" json:"-"`      // 恢复码哈希，逗号分隔，使用后移除
+	TotpStep  int64  `gorm:"column:totp_step;default:0" json:"-"` // 最近一次使用的验证码步长，防止重放
 }
 
 func (IptvAdmin) TableName() string {
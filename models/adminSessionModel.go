@@ -0,0 +1,17 @@
+package models
+
+// 管理员登录会话，删除即吊销
+type IptvAdminSession struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Sid       string `gorm:"column:sid;not null;uniqueIndex" json:"-"` // 写入 token 的会话ID
+	AdminID   int64  `gorm:"column:admin_id;not null;index" json:"admin_id"`
+	UA        string `gorm:"column:ua" json:"ua"`
+	IP        string `gorm:"column:ip" json:"ip"`
+	CreatedAt int64  `gorm:"column:created_at" json:"created_at"`
+	LastUsed  int64  `gorm:"column:last_used" json:"last_used"`
+	ExpiresAt int64  `gorm:"column:expires_at;index" json:"expires_at"`
+}
+
+func (IptvAdminSession) TableName() string {
+	return "iptv_admin_sessions"
+}
@@ -0,0 +1,20 @@
+package models
+
+// 开放接口密钥，只保存密钥的哈希
+type IptvApiKey struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name      string `gorm:"column:name;not null" json:"name"`
+	Prefix    string `gorm:"column:prefix" json:"prefix"`                   // 密钥前几位，用于识别
+	KeyHash   string `gorm:"column:key_hash;not null;uniqueIndex" json:"-"` // 密钥 sha256
+	Scopes    string `gorm:"column:scopes" json:"scopes"`                   // 权限范围，逗号分隔
+	CreatedBy string `gorm:"column:created_by" json:"created_by"`
+	CreatedAt int64  `gorm:"column:created_at" json:"created_at"`
+	LastUsed  int64  `gorm:"column:last_used" json:"last_used"`
+	LastIP    string `gorm:"column:last_ip" json:"last_ip"`
+	ExpiresAt int64  `gorm:"column:expires_at" json:"expires_at"`   // 0为永不过期
+	Status    int64  `gorm:"column:status;default:1" json:"status"` // 0已吊销
+}
+
+func (IptvApiKey) TableName() string {
+	return "iptv_api_keys"
+}
@@ -0,0 +1,22 @@
+package models
+
+// 管理员操作审计记录
+type IptvAuditLog struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Admin     string `gorm:"column:admin;index" json:"admin"`
+	Role      string `gorm:"column:role" json:"role"`
+	IP        string `gorm:"column:ip" json:"ip"`
+	Method    string `gorm:"column:method" json:"method"`
+	Path      string `gorm:"column:path;index" json:"path"` // 路由，如 /meals
+	Action    string `gorm:"column:action" json:"action"`   // 表单中的操作名，如 delmeal
+	Target    string `gorm:"column:target" json:"target"`   // 操作对象ID/名称
+	Params    string `gorm:"column:params" json:"params"`   // 提交参数摘要，密码等已隐藏
+	Changes   string `gorm:"column:changes" json:"changes"` // 修改前后的数据差异
+	Code      int64  `gorm:"column:code" json:"code"`       // 返回结果 code
+	Result    string `gorm:"column:result" json:"result"`   // 返回消息
+	CreatedAt int64  `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (IptvAuditLog) TableName() string {
+	return "iptv_audit_logs"
+}
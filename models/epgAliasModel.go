@@ -0,0 +1,13 @@
+package models
+
+type IptvEpgAlias struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Alias     string `gorm:"column:alias;not null;unique" json:"alias"`
+	Name      string `gorm:"column:name;not null" json:"name"`
+	Source    string `gorm:"column:source;default:local" json:"source"` // builtin:内置 local:本地添加 community:社区合并
+	UpdatedAt int64  `gorm:"column:updated_at;default:0" json:"updated_at"`
+}
+
+func (IptvEpgAlias) TableName() string {
+	return "iptv_epg_alias"
+}
@@ -0,0 +1,20 @@
+package models
+
+type IptvEpgCustom struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Channel   string `gorm:"column:channel;not null;index" json:"channel"` // EPG名称
+	Title     string `gorm:"column:title;not null" json:"title"`
+	Desc      string `gorm:"column:description" json:"desc"`
+	Type      string `gorm:"column:type;default:weekly" json:"type"`    // weekly:每周重复 once:单次
+	Weekdays  string `gorm:"column:weekdays" json:"weekdays"`           // 每周重复的星期，0为周日，如 1,2,3,4,5
+	StartTime string `gorm:"column:start_time" json:"start_time"`       // 每周重复开始时间 15:04
+	EndTime   string `gorm:"column:end_time" json:"end_time"`           // 每周重复结束时间 15:04，小于开始时间为跨天
+	StartAt   int64  `gorm:"column:start_at;default:0" json:"start_at"` // 单次节目开始时间戳
+	EndAt     int64  `gorm:"column:end_at;default:0" json:"end_at"`     // 单次节目结束时间戳
+	Status    int64  `gorm:"column:status;default:1" json:"status"`
+	UpdatedAt int64  `gorm:"column:updated_at;default:0" json:"updated_at"`
+}
+
+func (IptvEpgCustom) TableName() string {
+	return "iptv_epg_custom"
+}
@@ -22,6 +22,7 @@ type IptvEpgList struct {
 	Remarks     string `gorm:"column:remarks" json:"remarks"`
 	Url         string `gorm:"column:url" json:"url"`
 	UA          string `gorm:"column:ua" json:"ua"`
+	Type        string `gorm:"column:type;default:xmltv" json:"type"` // xmltv、diyp
 	LastTime    int64  `gorm:"column:lasttime" json:"lasttime"`
 	LastTimeStr string `gorm:"-" json:"lasttimeStr"`
 	Status      int64  `gorm:"column:status" json:"status"`
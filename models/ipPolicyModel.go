@@ -0,0 +1,17 @@
+package models
+
+// IP/地区访问策略
+type IptvIPPolicy struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Scope     string `gorm:"column:scope;not null;index" json:"scope"` // global/meal/token
+	Target    int64  `gorm:"column:target;default:0" json:"target"`    // 套餐ID或tokenID，全局为0
+	Type      string `gorm:"column:type;not null" json:"type"`         // allow_cidr/deny_cidr/allow_region/deny_region
+	Value     string `gorm:"column:value;not null" json:"value"`
+	Status    int64  `gorm:"column:status;default:1" json:"status"`
+	Marks     string `gorm:"column:marks" json:"marks"`
+	CreatedAt int64  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (IptvIPPolicy) TableName() string {
+	return "iptv_ip_policies"
+}
@@ -0,0 +1,18 @@
+package models
+
+// 后台通知，同一问题未读时合并为一条并累计次数
+type IptvNotification struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Level     string `gorm:"column:level" json:"level"`                 // info/warning/error
+	Source    string `gorm:"column:source" json:"source"`               // 来源: list/epg/license/apk/updata/disk/expiry
+	Key       string `gorm:"column:notify_key;index" json:"notify_key"` // 合并依据
+	Message   string `gorm:"column:message" json:"message"`
+	Count     int64  `gorm:"column:count;default:1" json:"count"`
+	Status    int64  `gorm:"column:status;default:0;index" json:"status"` // 0未读 1已读
+	CreatedAt int64  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt int64  `gorm:"column:updated_at;index" json:"updated_at"`
+}
+
+func (IptvNotification) TableName() string {
+	return "iptv_notifications"
+}
@@ -0,0 +1,19 @@
+package models
+
+// 试用记录，删除设备后保留，用于限制重复试用
+type IptvTrial struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserName  int64  `gorm:"column:user_name" json:"user_name"`
+	Mac       string `gorm:"column:mac;index" json:"mac"`
+	DeviceID  string `gorm:"column:deviceid;index" json:"deviceid"`
+	Model     string `gorm:"column:model;index" json:"model"`
+	IP        string `gorm:"column:ip;index" json:"ip"`
+	Client    string `gorm:"column:client" json:"client"` // apk、mytv
+	Meal      int64  `gorm:"column:meal" json:"meal"`
+	Exp       int64  `gorm:"column:exp" json:"exp"`
+	CreatedAt int64  `gorm:"column:created_at;default:0" json:"created_at"`
+}
+
+func (IptvTrial) TableName() string {
+	return "iptv_trials"
+}
@@ -0,0 +1,14 @@
+package models
+
+// 设备近期使用的IP，用于多地登录检测
+type IptvUserIP struct {
+	ID       int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserName int64  `gorm:"column:user_name;not null;uniqueIndex:idx_user_ip" json:"user_name"`
+	IP       string `gorm:"column:ip;not null;uniqueIndex:idx_user_ip" json:"ip"`
+	Region   string `gorm:"column:region" json:"region"`
+	LastSeen int64  `gorm:"column:last_seen;index" json:"last_seen"`
+}
+
+func (IptvUserIP) TableName() string {
+	return "iptv_user_ips"
+}
@@ -11,12 +11,15 @@ type IptvUser struct {
 	Exp         int64  `gorm:"column:exp" json:"exp"`
 	VPN         int    `gorm:"column:vpn" json:"vpn"`
 	IDChange    int    `gorm:"column:idchange" json:"idchange"`
+	MultiIP     int    `gorm:"column:multiip;default:0" json:"multiip"` // 多地登录标记
+	Flag        string `gorm:"column:flag" json:"flag"`                 // 异常检测说明
 	Author      string `gorm:"column:author" json:"author"`
 	AuthorTime  int64  `gorm:"column:authortime" json:"authortime"`
-	Status      int64  `gorm:"default:-1;column:status" json:"status"`
+	Status      int64  `gorm:"default:-1;column:status" json:"status"` // -1未授权 0禁用 1按天授权 2已过期 999永久授权
 	LastTime    int64  `gorm:"column:lasttime" json:"lasttime"`
 	Marks       string `gorm:"column:marks" json:"marks"`
 	Meal        int64  `gorm:"column:meal" json:"meal"`
+	Account     int64  `gorm:"column:account;default:0;index" json:"account"` // 所属客户账号，0为未绑定
 	LastTimeStr string `gorm:"-" json:"lasttime_str"`
 	ExpDesc     string `gorm:"-" json:"expdesc"`    // 剩余xx天
 	ExpDays     string `gorm:"-" json:"expdays"`    // 剩余天数
@@ -35,13 +38,17 @@ type IptvUserShow struct {
 	Exp         int64  `gorm:"column:exp" json:"exp"`
 	VPN         int    `gorm:"column:vpn" json:"vpn"`
 	IDChange    int    `gorm:"column:idchange" json:"idchange"`
+	MultiIP     int    `gorm:"column:multiip;default:0" json:"multiip"` // 多地登录标记
+	Flag        string `gorm:"column:flag" json:"flag"`                 // 异常检测说明
 	Author      string `gorm:"column:author" json:"author"`
 	AuthorTime  int64  `gorm:"column:authortime" json:"authortime"`
-	Status      int64  `gorm:"default:-1;column:status" json:"status"`
+	Status      int64  `gorm:"default:-1;column:status" json:"status"` // -1未授权 0禁用 1按天授权 2已过期 999永久授权
 	LastTime    int64  `gorm:"column:lasttime" json:"lasttime"`
 	Marks       string `gorm:"column:marks" json:"marks"`
 	Meal        int64  `gorm:"column:meal" json:"meal"`
+	Account     int64  `gorm:"column:account" json:"account"`
 	MealName    string `gorm:"->;column:mealname" json:"mealname"`
+	AccountName string `gorm:"->;column:accountname" json:"accountname"`
 	LastTimeStr string `gorm:"-" json:"lasttime_str"`
 	ExpDesc     string `gorm:"-" json:"expdesc"`    // 剩余xx天
 	ExpDays     string `gorm:"-" json:"expdays"`    // 剩余天数
@@ -0,0 +1,37 @@
+package models
+
+// 对外推送的 Webhook 订阅
+type IptvWebhook struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name      string `gorm:"column:name;not null" json:"name"`
+	Url       string `gorm:"column:url;not null" json:"url"`
+	Secret    string `gorm:"column:secret" json:"-"`      // 签名密钥
+	Events    string `gorm:"column:events" json:"events"` // 订阅的事件，逗号分隔
+	Enable    int64  `gorm:"column:enable;default:1" json:"enable"`
+	CreatedAt int64  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (IptvWebhook) TableName() string {
+	return "iptv_webhooks"
+}
+
+// Webhook 投递记录，失败后按退避时间重试
+type IptvWebhookDelivery struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	WebhookID int64  `gorm:"column:webhook_id;index" json:"webhook_id"`
+	Event     string `gorm:"column:event" json:"event"`
+	Uuid      string `gorm:"column:uuid" json:"uuid"`
+	Payload   string `gorm:"column:payload" json:"payload"`
+	Status    int64  `gorm:"column:status;default:0" json:"status"` // 0待发送 1成功 2失败
+	Attempts  int64  `gorm:"column:attempts;default:0" json:"attempts"`
+	NextAt    int64  `gorm:"column:next_at;index" json:"next_at"` // 下次发送时间
+	Code      int64  `gorm:"column:code" json:"code"`             // 响应状态码
+	Response  string `gorm:"column:response" json:"response"`
+	Error     string `gorm:"column:error" json:"error"`
+	CreatedAt int64  `gorm:"column:created_at;index" json:"created_at"`
+	UpdatedAt int64  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (IptvWebhookDelivery) TableName() string {
+	return "iptv_webhook_deliveries"
+}
@@ -2,8 +2,13 @@ package router
 
 import (
 	"go-iptv/api"
+	"go-iptv/dao"
+	"go-iptv/dto"
 	"go-iptv/html"
+	"go-iptv/models"
+	"go-iptv/service"
 	"go-iptv/until"
+	"log"
 	"net/http"
 	"time"
 
@@ -13,77 +18,167 @@ import (
 func AdminRouter(r *gin.Engine, path string) {
 	router := r.Group(path)
 	{
-		router.POST("/login", api.Login)
+		router.POST("/login", OriginMiddleware(), RateLimitMiddleware("admin", adminLoginRateKey), api.Login)
 		router.GET("/login", html.Login)
 		router.GET("/logout", api.Logout)
 
 		router.Use(JWTMiddleware(router))
+		router.Use(AuditMiddleware(router.BasePath()))
+		router.Use(CSRFMiddleware())
 		{
 			router.GET("/", html.Index)
 			router.GET("/index", html.Index)
+			router.GET("/about", html.About)
 
-			router.GET("/users", html.Users)
-			router.POST("/users", api.EditUsers)
+			// 修改自己的信息，所有角色可用
+			router.GET("/admins", html.Admins)
+			router.POST("/admins", api.Admins)
+		}
 
-			router.GET("/authors", html.Authors)
-			router.POST("/authors", api.Authors)
+		// 设备授权与客户管理
+		users := router.Group("", PermMiddleware(until.PermUsers, true))
+		{
+			users.GET("/users", html.Users)
+			users.POST("/users", api.EditUsers)
+			users.POST("/users/import", api.ImportUsers)
 
-			router.GET("/meals", html.Meals)
-			router.POST("/meals", api.Meals)
+			users.GET("/authors", html.Authors)
+			users.POST("/authors", api.Authors)
 
-			router.GET("/channels", html.Channels)
-			router.POST("/channels", api.Channels)
-			router.POST("/channels/uploadPayList", api.UploadPayList)
-			router.POST("/channels/uploadLogo", api.UploadLogo)
+			users.GET("/accessLogs", html.AccessLogs)
+			users.POST("/accessLogs", api.AccessLogs)
 
-			router.GET("/epgsList", html.Epgs)
-			router.POST("/epgsList", api.Epgs)
+			users.GET("/ipPolicies", html.IPPolicies)
+			users.POST("/ipPolicies", api.IPPolicies)
 
-			router.GET("/epgFrom", html.EpgsFrom)
-			router.POST("/epgFrom", api.EpgsFrom)
+			users.GET("/detect", html.Detect)
+			users.POST("/detect", api.Detect)
 
-			router.GET("/notice", html.Notice)
-			router.POST("/notice", api.Notice)
+			users.GET("/accounts", html.Accounts)
+			users.POST("/accounts", api.Accounts)
+			users.GET("/accountDevices", html.AccountDevices)
+			users.POST("/accountDevices", api.Accounts)
 
-			router.GET("/clientMyTV", html.ClientMyTV)
-			router.POST("/clientMyTV", api.ClientMyTV)
-			router.GET("/clientMyTV/buildStatus", api.BuildMyTVStatus)
+			users.GET("/actCodes", html.ActCodes)
+			users.POST("/actCodes", api.ActCodes)
+			users.GET("/actCodeLogs", html.ActCodeLogs)
+		}
 
-			router.GET("/client", html.Client)
-			router.POST("/client", api.Client)
-			router.GET("/client/buildStatus", api.BuildStatus)
-			router.POST("/client/uploadIcon", api.ClientUploadIcon)
-			router.POST("/client/uploadBj", api.ClientUploadBj)
+		// 查询、导出接口返回完整数据或激活码，只读角色不可用
+		usersData := router.Group("", PermMiddleware(until.PermUsers, false))
+		{
+			usersData.GET("/users/query", api.QueryUsers)
+			usersData.GET("/users/export", api.ExportUsers)
+			usersData.GET("/actCodes/export", api.ExportActCodes)
+		}
 
-			router.GET("/admins", html.Admins)
-			router.POST("/admins", api.Admins)
+		// 频道、EPG、套餐
+		channels := router.Group("", PermMiddleware(until.PermChannels, true))
+		{
+			channels.GET("/meals", html.Meals)
+			channels.POST("/meals", api.Meals)
 
-			router.GET("/movie", html.Movie)
-			router.POST("/movie", api.Movie)
+			channels.GET("/channels", html.Channels)
+			channels.POST("/channels", api.Channels)
+			channels.POST("/channels/uploadPayList", api.UploadPayList)
+			channels.POST("/channels/uploadLogo", api.UploadLogo)
 
-			router.GET("/about", html.About)
+			channels.GET("/epgsList", html.Epgs)
+			channels.POST("/epgsList", api.Epgs)
 
-			router.GET("/license", html.License)
-			router.GET("/license/checkProxy", api.CheckProxy)
-			router.POST("/license", api.License)
-			router.GET("/license/log", api.LicenseLog)
+			channels.GET("/epgFrom", html.EpgsFrom)
+			channels.POST("/epgFrom", api.EpgsFrom)
 
-			router.GET("/updata", html.Updata)
-			router.GET("/updata/checkWeb", api.UpdataCheckWeb)
-			router.GET("/updata/checkLic", api.UpdataCheckLic)
-			router.GET("/updata/downWeb", api.UpdataDownWeb)
-			router.GET("/updata/downLic", api.UpdataDownLic)
-			router.GET("/updata/updata", api.Updata)
+			channels.GET("/epgAlias", html.EpgAlias)
+			channels.POST("/epgAlias", api.EpgAlias)
+			channels.POST("/epgAlias/import", api.ImportAlias)
+			channels.GET("/epgCustom", html.EpgCustom)
+			channels.POST("/epgCustom", api.EpgCustom)
+			channels.POST("/epgCustom/import", api.ImportEpgCustom)
 
-			router.POST("/getRssUrl", api.GetRssUrl)
-			router.POST("/getTokenRssUrl", api.GetTokenRssUrl)
+			channels.GET("/movie", html.Movie)
+			channels.POST("/movie", api.Movie)
+
+			// 订阅地址包含token，刷新KEY会使旧地址失效
+			channels.POST("/getRssUrl", api.GetRssUrl)
+			channels.POST("/getTokenRssUrl", api.GetTokenRssUrl)
 
 			// 多token管理API
-			router.GET("/meals/:meal_id/tokens", api.GetMealTokens)
-			router.POST("/meals/:meal_id/tokens", api.CreateMealToken)
-			router.POST("/meals/tokens/:token_id/status", api.UpdateMealToken)
-			router.DELETE("/meals/tokens/:token_id", api.DeleteMealToken)
-			router.POST("/meals/tokens/:token_id/extend", api.ExtendToken)
+			channels.POST("/meals/:meal_id/tokens", api.CreateMealToken)
+			channels.POST("/meals/tokens/:token_id/status", api.UpdateMealToken)
+			channels.DELETE("/meals/tokens/:token_id", api.DeleteMealToken)
+			channels.POST("/meals/tokens/:token_id/extend", api.ExtendToken)
+		}
+
+		// 订阅token可直接用于访问，和导出接口一样只读角色不可用
+		channelsData := router.Group("", PermMiddleware(until.PermChannels, false))
+		{
+			channelsData.GET("/meals/:meal_id/tokens", api.GetMealTokens)
+			channelsData.GET("/epgAlias/export", api.ExportAlias)
+			channelsData.GET("/epgCustom/export", api.ExportEpgCustom)
+		}
+
+		// 客户端与系统设置
+		system := router.Group("", PermMiddleware(until.PermSystem, true))
+		{
+			system.GET("/rateLimits", html.RateLimits)
+			system.POST("/rateLimits", api.RateLimits)
+
+			system.GET("/geoip", html.GeoIP)
+			system.POST("/geoip", api.GeoIP)
+			system.POST("/geoip/upload", api.GeoIPUpload)
+
+			system.GET("/webhooks", html.Webhooks)
+			system.POST("/webhooks", api.Webhooks)
+
+			system.POST("/notifications", api.Notifications)
+
+			system.GET("/notice", html.Notice)
+			system.POST("/notice", api.Notice)
+
+			system.GET("/clientMyTV", html.ClientMyTV)
+			system.POST("/clientMyTV", api.ClientMyTV)
+			system.GET("/clientMyTV/buildStatus", api.BuildMyTVStatus)
+
+			system.GET("/client", html.Client)
+			system.POST("/client", api.Client)
+			system.GET("/client/buildStatus", api.BuildStatus)
+			system.POST("/client/uploadIcon", api.ClientUploadIcon)
+			system.POST("/client/uploadBj", api.ClientUploadBj)
+
+			system.GET("/license", html.License)
+			system.POST("/license", api.License)
+
+			system.GET("/updata", html.Updata)
+		}
+
+		systemData := router.Group("", PermMiddleware(until.PermSystem, false))
+		{
+			systemData.GET("/license/checkProxy", api.CheckProxy)
+			systemData.GET("/license/log", api.LicenseLog)
+		}
+
+		// 升级操作为GET请求，只读角色不可用
+		updata := router.Group("", PermMiddleware(until.PermSystem, false), AuditGET(), RequireCSRF())
+		{
+			updata.GET("/updata/checkWeb", api.UpdataCheckWeb)
+			updata.GET("/updata/checkLic", api.UpdataCheckLic)
+			updata.GET("/updata/downWeb", api.UpdataDownWeb)
+			updata.GET("/updata/downLic", api.UpdataDownLic)
+			updata.GET("/updata/updata", api.Updata)
+		}
+
+		// 管理员管理，仅所有者
+		admins := router.Group("", PermMiddleware(until.PermAdmins, false))
+		{
+			admins.POST("/admins/manage", api.ManageAdmins)
+
+			admins.GET("/auditLogs", html.AuditLogs)
+			admins.POST("/auditLogs", api.AuditLogs)
+			admins.GET("/auditLogs/export", api.ExportAuditLogs)
+
+			admins.GET("/apiKeys", html.ApiKeys)
+			admins.POST("/apiKeys", api.ApiKeys)
 		}
 	}
 }
@@ -107,10 +202,29 @@ func JWTMiddleware(r *gin.RouterGroup) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		// 会话已吊销或过期、管理员已删除或禁用时退出登录
+		sid, _ := claims["sid"].(string)
+		session, ok := service.CheckSession(sid, c.ClientIP())
+		var admin models.IptvAdmin
+		if !ok || dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", session.AdminID).First(&admin).Error != nil || admin.Status != 1 {
+			until.SetAuthCookie(c, "", -1)
+			c.Redirect(http.StatusFound, r.BasePath()+"/login")
+			c.Abort()
+			return
+		}
+		username, _ := claims["username"].(string)
+		if role, _ := claims["role"].(string); role != admin.Role || username != admin.UserName {
+			// 角色或用户名已变更，按原有效期重新签发
+			exp := time.Until(time.Unix(until.GetAuthExp(claims), 0))
+			tokenString, _ := until.GenerateJWT(admin.UserName, admin.Role, sid, exp)
+			until.SetAuthCookie(c, tokenString, int(exp.Seconds()))
+			claims["role"], claims["username"] = admin.Role, admin.UserName
+		}
 		if update {
 			// 更新 token
-			tokenString, _ := until.GenerateJWT(claims["username"].(string), time.Hour)
-			c.SetCookie("token", tokenString, 3600, "/", "", false, true)
+			tokenString, _ := until.GenerateJWT(admin.UserName, admin.Role, sid, time.Hour)
+			until.SetAuthCookie(c, tokenString, 3600)
+			service.ExtendSession(sid, time.Hour)
 		}
 
 		// 保存 claims 到上下文
@@ -119,3 +233,18 @@ func JWTMiddleware(r *gin.RouterGroup) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// 按角色检查路由分组权限，readable 为 true 时无权限的角色仍可查看页面(GET)
+func PermMiddleware(perm string, readable bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := until.GetAuthRole(c)
+		if until.HasPerm(role, perm) || (readable && c.Request.Method == http.MethodGet) {
+			c.Next()
+			return
+		}
+		username, _ := until.GetAuthName(c)
+		log.Printf("管理员 %s(%s) 无权限访问 %s %s\n", username, until.RoleName(role), c.Request.Method, c.Request.URL.Path)
+		c.JSON(http.StatusOK, dto.ReturnJsonDto{Code: 0, Msg: "当前角色(" + until.RoleName(role) + ")无权限执行此操作", Type: "danger"})
+		c.Abort()
+	}
+}
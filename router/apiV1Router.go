@@ -0,0 +1,143 @@
+package router
+
+import (
+	"go-iptv/api"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/service"
+	"go-iptv/until"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 开放接口，使用后台创建的接口密钥认证
+func ApiV1Router(r *gin.Engine, path string) {
+	router := r.Group(path, RateLimitMiddleware("api", apiKeyRateKey), ApiKeyMiddleware(), AuditMiddleware(""))
+	{
+		channelsRead := router.Group("", ApiScopeMiddleware(service.ScopeChannelsRead))
+		{
+			channelsRead.GET("/lists", api.ApiV1Lists)
+			channelsRead.GET("/lists/:id", api.ApiV1List)
+			channelsRead.GET("/categories", api.ApiV1Categories)
+			channelsRead.GET("/categories/:id", api.ApiV1Category)
+			channelsRead.GET("/categories/:id/channels", api.ApiV1CategoryChannels)
+			channelsRead.GET("/channels/:id", api.ApiV1Channel)
+		}
+		channelsWrite := router.Group("", ApiScopeMiddleware(service.ScopeChannelsWrite))
+		{
+			channelsWrite.POST("/lists", api.ApiV1SaveList)
+			channelsWrite.POST("/lists/refresh", api.ApiV1RefreshLists)
+			channelsWrite.PUT("/lists/:id", api.ApiV1SaveList)
+			channelsWrite.PATCH("/lists/:id", api.ApiV1PatchList)
+			channelsWrite.DELETE("/lists/:id", api.ApiV1DelList)
+			channelsWrite.POST("/lists/:id/refresh", api.ApiV1RefreshList)
+			channelsWrite.POST("/categories", api.ApiV1SaveCategory)
+			channelsWrite.PUT("/categories/:id", api.ApiV1SaveCategory)
+			channelsWrite.PATCH("/categories/:id", api.ApiV1PatchCategory)
+			channelsWrite.DELETE("/categories/:id", api.ApiV1DelCategory)
+			channelsWrite.PATCH("/channels/:id", api.ApiV1PatchChannel)
+		}
+
+		mealsRead := router.Group("", ApiScopeMiddleware(service.ScopeMealsRead))
+		{
+			mealsRead.GET("/meals", api.ApiV1Meals)
+			mealsRead.GET("/meals/:id", api.ApiV1Meal)
+			mealsRead.GET("/meals/:id/tokens", api.ApiV1MealTokens)
+		}
+		mealsWrite := router.Group("", ApiScopeMiddleware(service.ScopeMealsWrite))
+		{
+			mealsWrite.POST("/meals", api.ApiV1SaveMeal)
+			mealsWrite.PUT("/meals/:id", api.ApiV1SaveMeal)
+			mealsWrite.PATCH("/meals/:id", api.ApiV1PatchMeal)
+			mealsWrite.DELETE("/meals/:id", api.ApiV1DelMeal)
+			mealsWrite.POST("/meals/:id/tokens", api.ApiV1CreateMealToken)
+			mealsWrite.PATCH("/tokens/:id", api.ApiV1PatchMealToken)
+			mealsWrite.POST("/tokens/:id/extend", api.ApiV1ExtendMealToken)
+			mealsWrite.DELETE("/tokens/:id", api.ApiV1DelMealToken)
+		}
+
+		usersRead := router.Group("", ApiScopeMiddleware(service.ScopeUsersRead))
+		{
+			usersRead.GET("/users", api.ApiV1Users)
+			usersRead.GET("/users/:name", api.ApiV1User)
+		}
+		usersWrite := router.Group("", ApiScopeMiddleware(service.ScopeUsersWrite))
+		{
+			usersWrite.POST("/users/authorize", api.ApiV1AuthorizeUsers)
+			usersWrite.POST("/users/forbid", api.ApiV1ForbidUsers)
+			usersWrite.DELETE("/users/:name", api.ApiV1DelUser)
+		}
+
+		epgRead := router.Group("", ApiScopeMiddleware(service.ScopeEpgRead))
+		{
+			epgRead.GET("/epg-sources", api.ApiV1EpgSources)
+			epgRead.GET("/epg-sources/:id", api.ApiV1EpgSource)
+		}
+		epgWrite := router.Group("", ApiScopeMiddleware(service.ScopeEpgWrite))
+		{
+			epgWrite.POST("/epg-sources", api.ApiV1SaveEpgSource)
+			epgWrite.POST("/epg-sources/refresh", api.ApiV1RefreshEpgSources)
+			epgWrite.PUT("/epg-sources/:id", api.ApiV1SaveEpgSource)
+			epgWrite.PATCH("/epg-sources/:id", api.ApiV1PatchEpgSource)
+			epgWrite.DELETE("/epg-sources/:id", api.ApiV1DelEpgSource)
+			epgWrite.POST("/epg-sources/:id/refresh", api.ApiV1RefreshEpgSource)
+		}
+	}
+}
+
+// 从 Authorization: Bearer 或 X-API-Key 取接口密钥
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	auth := c.GetHeader("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+func apiKeyRateKey(c *gin.Context) string {
+	key := apiKeyFromRequest(c)
+	if len(key) > 11 {
+		key = key[:11]
+	}
+	return key
+}
+
+// 校验接口密钥，调用者以 "api/名称" 记入操作日志
+func ApiKeyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rec, ok := service.CheckApiKey(apiKeyFromRequest(c), c.ClientIP())
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.JSON(http.StatusUnauthorized, dto.ReturnJsonDto{Code: 0, Msg: "接口密钥无效、已过期或已吊销", Type: "danger"})
+			c.Abort()
+			return
+		}
+		c.Set("apikey", rec)
+		c.Set("auth", jwt.MapClaims{
+			"username": "api/" + rec.Name,
+			"role":     until.RoleApiKey,
+			"exp":      float64(time.Now().Add(time.Hour).Unix()),
+		})
+		c.Next()
+	}
+}
+
+// 检查接口密钥的权限范围
+func ApiScopeMiddleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rec, _ := c.MustGet("apikey").(models.IptvApiKey)
+		if service.ApiKeyHasScope(rec, scope) {
+			c.Next()
+			return
+		}
+		c.JSON(http.StatusForbidden, dto.ReturnJsonDto{Code: 0, Msg: "接口密钥没有 " + scope + " 权限", Type: "danger"})
+		c.Abort()
+	}
+}
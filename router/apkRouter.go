@@ -16,8 +16,9 @@ func ApkRouter(r *gin.Engine, path string) {
 		router.GET("/getepg", api.GetEpg)
 		router.GET("/getver", api.Getver)
 		router.GET("/bg", api.GetBg)
-		router.POST("/login", api.ApkLogin)
-		router.POST("/channels", api.GetChannels)
+		router.POST("/login", RateLimitMiddleware("apk", apkRateKey), IPPolicyMiddleware(apkPolicySubject), api.ApkLogin)
+		router.POST("/channels", RateLimitMiddleware("apk", apkRateKey), IPPolicyMiddleware(apkPolicySubject), api.GetChannels)
+		router.POST("/redeem", RateLimitMiddleware("apk", nil), api.ApkRedeem)
 
 	}
 }
@@ -0,0 +1,171 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-iptv/models"
+	"go-iptv/service"
+	"go-iptv/until"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 记录响应内容，用于取出返回的 code 和 msg
+type auditWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	if w.body.Len() < 4096 {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	if w.body.Len() < 4096 {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// 标记 GET 请求也需要记录操作日志，用于在线升级等 GET 操作
+func AuditGET() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("audit", true)
+		c.Next()
+	}
+}
+
+// 表单中不是操作名的字段，按路由区分
+var auditSkipKeys = map[string]map[string]bool{
+	"/users": keySet(service.UserFilterKeys),
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// 记录管理员的修改操作：非 GET 请求以及标记了 AuditGET 的路由
+func AuditMiddleware(basePath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		isGet := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+		path := strings.TrimPrefix(c.FullPath(), basePath)
+
+		// 操作名为表单最后一个字段(点击的按钮)，需要按原始顺序读取，跳过回传的筛选条件
+		var action string
+		var raw []byte
+		if !isGet && strings.HasPrefix(c.ContentType(), "application/x-www-form-urlencoded") {
+			raw, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+			skip := auditSkipKeys[path]
+			for _, kv := range strings.Split(string(raw), "&") {
+				k, _, _ := strings.Cut(kv, "=")
+				k, _ = url.QueryUnescape(k)
+				if k != "" && k != "csrf_token" && !skip[k] {
+					action = k
+				}
+			}
+		}
+		form, _ := url.ParseQuery(string(raw))
+		// 开放接口的 JSON 请求没有操作名
+		if !isGet && strings.HasPrefix(c.ContentType(), "application/json") {
+			raw, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+			if v, err := until.JsonToValues(raw); err == nil {
+				form = v
+			}
+		}
+
+		var targets []string
+		var beforeRows service.AuditRows
+		var beforeCfg map[string]string
+		if !isGet {
+			targets = auditTargets(c, form, action)
+			beforeRows = service.AuditSnapshot(path, targets)
+			beforeCfg = service.AuditConfigSnapshot()
+		}
+
+		w := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		if isGet && !c.GetBool("audit") {
+			return
+		}
+		if isGet {
+			action = path[strings.LastIndex(path, "/")+1:]
+		}
+		if c.Request.MultipartForm != nil {
+			form = url.Values(c.Request.MultipartForm.Value)
+			if action == "" {
+				action = "upload"
+			}
+		}
+
+		rec := models.IptvAuditLog{
+			Role:   until.GetAuthRole(c),
+			IP:     c.ClientIP(),
+			Method: c.Request.Method,
+			Path:   path,
+			Action: action,
+			Target: strings.Join(targets, ","),
+			Params: service.AuditParams(form, action),
+			Code:   -1,
+		}
+		rec.Admin, _ = until.GetAuthName(c)
+
+		var res struct {
+			Code int64  `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if json.Unmarshal(w.body.Bytes(), &res) == nil {
+			rec.Code, rec.Result = res.Code, res.Msg
+		} else {
+			rec.Result = http.StatusText(c.Writer.Status())
+			if c.Writer.Status() == http.StatusNoContent {
+				rec.Code = 1
+			}
+		}
+
+		var changes []string
+		if !isGet {
+			changes = append(changes, service.AuditRowsDiff(beforeRows, service.AuditSnapshot(path, targets))...)
+			changes = append(changes, service.AuditConfigDiff(beforeCfg, service.AuditConfigSnapshot())...)
+		}
+		service.RecordAudit(rec, changes)
+	}
+}
+
+// 操作对象：勾选的 ids[]、接口传入的 names、路由参数、id 字段或按钮的值
+func auditTargets(c *gin.Context, form url.Values, action string) []string {
+	if ids := form["ids[]"]; len(ids) > 0 {
+		return ids
+	}
+	if names := form["names"]; len(names) > 0 {
+		return names
+	}
+	for _, p := range c.Params {
+		if p.Key != "meal_id" {
+			return []string{p.Value}
+		}
+	}
+	for _, k := range []string{"id", action, "meal_id", "username", "name"} {
+		if v := form.Get(k); k != "" && v != "" {
+			return []string{v}
+		}
+	}
+	if len(c.Params) > 0 {
+		return []string{c.Params[0].Value}
+	}
+	return nil
+}
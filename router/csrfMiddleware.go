@@ -0,0 +1,94 @@
+package router
+
+import (
+	"crypto/subtle"
+	"go-iptv/dto"
+	"go-iptv/until"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// 校验 Origin/Referer 与当前访问的域名一致，两者都没有时交给 token 校验
+func sameOrigin(c *gin.Context) bool {
+	src := c.GetHeader("Origin")
+	if src == "" {
+		src = c.GetHeader("Referer")
+	}
+	if src == "" {
+		return true
+	}
+	u, err := url.Parse(src)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, c.Request.Host) {
+		return true
+	}
+	// 反向代理修改了 Host 时使用转发的原始域名
+	fwd, _, _ := strings.Cut(c.GetHeader("X-Forwarded-Host"), ",")
+	return fwd != "" && strings.EqualFold(u.Host, strings.TrimSpace(fwd))
+}
+
+func csrfDeny(c *gin.Context, reason string) {
+	username, _ := until.GetAuthName(c)
+	log.Printf("拒绝跨站请求 %s %s，管理员: %s IP: %s 原因: %s\n", c.Request.Method, c.Request.URL.Path, username, c.ClientIP(), reason)
+	c.JSON(http.StatusForbidden, dto.ReturnJsonDto{Code: 0, Msg: "请求校验失败，请刷新页面后重试", Type: "danger"})
+	c.Abort()
+}
+
+// 登录等未认证的修改请求只校验来源
+func OriginMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isSafeMethod(c.Request.Method) && !sameOrigin(c) {
+			csrfDeny(c, "来源不一致")
+			return
+		}
+		c.Next()
+	}
+}
+
+// 后台修改请求校验来源和 CSRF token，GET 请求时下发 token Cookie
+func CSRFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) {
+			want := until.CsrfToken(until.GetAuthSid(c))
+			if got, _ := c.Cookie("csrf_token"); got != want {
+				until.SetCsrfCookie(c, want)
+			}
+			c.Next()
+			return
+		}
+		checkCSRF(c)
+	}
+}
+
+// GET 方式执行的操作(在线升级等)同样要求 CSRF token
+func RequireCSRF() gin.HandlerFunc {
+	return checkCSRF
+}
+
+// token 从请求头 X-CSRF-Token 或表单 csrf_token 读取
+func checkCSRF(c *gin.Context) {
+	if !sameOrigin(c) {
+		csrfDeny(c, "来源不一致")
+		return
+	}
+	got := c.GetHeader("X-CSRF-Token")
+	if got == "" && !isSafeMethod(c.Request.Method) {
+		got = c.PostForm("csrf_token")
+	}
+	want := until.CsrfToken(until.GetAuthSid(c))
+	if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		csrfDeny(c, "token 错误")
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-iptv/dto"
+	"go-iptv/service"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 从请求中解析策略检查对象（设备/套餐/token）
+type policyResolver func(c *gin.Context) service.PolicySubject
+
+// IP/地区访问策略，只有全局策略时不解析设备和token
+func IPPolicyMiddleware(resolve policyResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !service.HasIPPolicies(false) {
+			c.Next()
+			return
+		}
+		var s service.PolicySubject
+		if service.HasIPPolicies(true) {
+			s = resolve(c)
+		}
+
+		ip := c.ClientIP()
+		reason := service.CheckIPPolicy(ip, s)
+		if reason == "" {
+			c.Next()
+			return
+		}
+
+		log.Printf("IP策略拒绝 %s %s: %s\n", c.FullPath(), ip, reason)
+		if s.User.ID > 0 {
+			service.RecordAccess(service.Entitlement{User: s.User, Reason: service.DenyIPPolicy}, dto.AccessClient{
+				IP:       ip,
+				Endpoint: c.FullPath(),
+			})
+		}
+		c.String(http.StatusForbidden, "访问受限: "+reason)
+		c.Abort()
+	}
+}
+
+// APK请求体中的MAC，读取后还原请求体
+func apkRequestMac(c *gin.Context) string {
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var req dto.DataReqDto
+	json.Unmarshal(body, &req)
+	if strings.Contains(req.Mac, "获取地址失败") {
+		req.Mac = req.DeviceID
+	}
+	return req.Mac
+}
+
+func apkPolicySubject(c *gin.Context) service.PolicySubject {
+	return service.PolicySubjectByMac(apkRequestMac(c))
+}
+
+func mytvPolicySubject(c *gin.Context) service.PolicySubject {
+	deviceId := c.Query("deviceId")
+	if deviceId == "" {
+		deviceId = c.Param("deviceId")
+	}
+	return service.PolicySubjectByDevice(deviceId)
+}
+
+func rssPolicySubject(c *gin.Context) service.PolicySubject {
+	token := c.Param("token")
+	if token == "" && c.Param("key") != "" {
+		token = service.GetRssToken(c.Param("key"))
+	}
+	return service.PolicySubjectByToken(token)
+}
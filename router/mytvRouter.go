@@ -9,8 +9,10 @@ import (
 func MytvRouter(r *gin.Engine, path string) {
 	router := r.Group(path)
 	{
-		router.GET("/m3u8", api.MytvGetUserM3U8)
-		router.GET("/:deviceId/e.xml", api.MytvGetRssEpg)
+		router.GET("/m3u8", RateLimitMiddleware("mytv", mytvRateKey), IPPolicyMiddleware(mytvPolicySubject), api.MytvGetUserM3U8)
+		router.GET("/redeem", RateLimitMiddleware("mytv", nil), api.MytvRedeem)
+		router.POST("/redeem", RateLimitMiddleware("mytv", nil), api.MytvRedeem)
+		router.GET("/:deviceId/e.xml", RateLimitMiddleware("mytv", mytvRateKey), IPPolicyMiddleware(mytvPolicySubject), api.MytvGetRssEpg)
 		router.GET("/releases", api.MytvReleases)
 		router.GET("/baseApk", api.BaseApk)
 		router.GET("/baseVersion", api.BaseVersion)
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 从请求中取频率限制的账号/设备/token，返回空时只按IP限制
+type rateKeyFunc func(c *gin.Context) string
+
+// 按客户端IP和账号/设备/token限制每分钟请求数，超出时返回 429
+func RateLimitMiddleware(scope string, key rateKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := dao.GetConfig().RateLimit
+		if cfg.Disable == 1 {
+			c.Next()
+			return
+		}
+		wait := service.RateCheck(service.RateKindIP, scope, c.ClientIP(), cfg.GetIPRate())
+		if wait == 0 && key != nil {
+			if k := key(c); k != "" {
+				wait = service.RateCheck(service.RateKindKey, scope, k, cfg.GetKeyRate())
+			}
+		}
+		if wait == 0 {
+			c.Next()
+			return
+		}
+
+		msg := "请求过于频繁，请" + service.FormatWait(wait) + "后重试"
+		c.Header("Retry-After", strconv.FormatInt(wait, 10))
+		if scope == "admin" || scope == "api" {
+			c.JSON(http.StatusTooManyRequests, dto.ReturnJsonDto{Code: 0, Msg: msg, Type: "danger"})
+		} else {
+			c.String(http.StatusTooManyRequests, msg)
+		}
+		c.Abort()
+	}
+}
+
+func adminLoginRateKey(c *gin.Context) string {
+	return c.PostForm("username")
+}
+
+func apkRateKey(c *gin.Context) string {
+	return apkRequestMac(c)
+}
+
+func mytvRateKey(c *gin.Context) string {
+	deviceId := c.Query("deviceId")
+	if deviceId == "" {
+		deviceId = c.Param("deviceId")
+	}
+	return deviceId
+}
+
+func rssRateKey(c *gin.Context) string {
+	if token := c.Param("token"); token != "" {
+		return token
+	}
+	return c.Param("key")
+}
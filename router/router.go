@@ -50,6 +50,7 @@ func InitRouter(debug bool) *gin.Engine {
 		"Sub":      func(a, b int64) int64 { return a - b },
 		"DisPay":   func() int64 { return dao.GetConfig().System.DisPay },
 		"IsLic":    func() bool { return (dao.Lic.Type == 1 && dao.Lic.Exp > time.Now().Unix()) || dao.Lic.Type >= 2 },
+		"Unread":   until.UnreadNotifications,
 	})
 
 	r.Static("/app", "/config/app")
@@ -81,6 +82,7 @@ func InitRouter(debug bool) *gin.Engine {
 
 	ApkRouter(r, "/apk")
 	AdminRouter(r, "/admin")
+	ApiV1Router(r, "/api/v1")
 	RssRouter(r, "/")
 	MytvRouter(r, "/mytv")
 
@@ -178,8 +180,11 @@ func InitRouter(debug bool) *gin.Engine {
 			status, msg := bootstrap.Install()
 			if status {
 				dao.DB.Model(&models.IptvAdmin{}).Create(&models.IptvAdmin{
-					UserName: username,
-					PassWord: password,
+					UserName:  username,
+					PassWord:  password,
+					Role:      until.RoleOwner,
+					Status:    1,
+					CreatedAt: time.Now().Unix(),
 				})
 				cfg := dao.GetConfig()
 				cfg.ServerUrl = strings.TrimSuffix(apkApi, "/")
@@ -190,7 +195,11 @@ func InitRouter(debug bool) *gin.Engine {
 				}
 				go crontab.Crontab()
 				go crontab.EpgCron()
+				go crontab.AccessLogCron()
+				go crontab.ExpiryCron()
+				go crontab.DiskCron()
 				go until.InitCacheRebuild()
+				go until.WebhookWorker()
 				bootstrap.Installed = true
 				c.JSON(http.StatusOK, gin.H{
 					"code": 1,
@@ -242,6 +251,7 @@ func loadTemplates(r *gin.Engine) {
 			"Sub":      func(a, b int64) int64 { return a - b },
 			"DisPay":   func() int64 { return dao.GetConfig().System.DisPay }, // 显示付费模块
 			"IsLic":    func() bool { return (dao.Lic.Type == 1 && dao.Lic.Exp > time.Now().Unix()) || dao.Lic.Type >= 2 },
+			"Unread":   until.UnreadNotifications,
 		})
 		tmpl = template.Must(tmpl.ParseFS(assets.EmbeddedFS, "templates/*"))
 		staticFiles, _ := fs.Sub(assets.StaticFS, "static")
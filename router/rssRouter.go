@@ -7,12 +7,14 @@ import (
 )
 
 func RssRouter(r *gin.Engine, path string) {
-	router := r.Group(path)
+	router := r.Group(path, RateLimitMiddleware("rss", rssRateKey), IPPolicyMiddleware(rssPolicySubject))
 	{
 		router.GET("/getRss/:token/paylist.m3u", api.GetRssM3u)
 		router.GET("/getRss/:token/paylist.txt", api.GetRssTxt)
 		router.GET("/ku9/:token/paylist.txt", api.GetRssTxtKu9)
 		router.GET("/epg/:token/e.xml", api.GetRssEpg)
+		router.GET("/epg/:token/now.json", api.GetRssNowNext)
+		router.GET("/diyp/:token", api.GetRssDiyp)
 
 		router.GET("/r/:key/p.m3u", api.GetRssM3uShortURL)
 		router.GET("/r/:key/p.txt", api.GetRssTxtShortURL)
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const AccessOK = "ok"
+
+// 写入访问记录，e 为本次请求的授权检查结果
+func RecordAccess(e Entitlement, client dto.AccessClient) {
+	rec := models.IptvAccessLog{
+		UserName:  e.User.Name,
+		DeviceID:  e.User.DeviceID,
+		Mac:       e.User.Mac,
+		IP:        client.IP,
+		AppName:   client.AppName,
+		AppVer:    client.AppVer,
+		Endpoint:  client.Endpoint,
+		Result:    AccessOK,
+		CreatedAt: time.Now().Unix(),
+	}
+	if e.User.IP == client.IP {
+		rec.Region = e.User.Region
+	}
+	if !e.Allowed() {
+		rec.Result = e.Reason
+	}
+	if err := dao.DB.Create(&rec).Error; err != nil {
+		log.Println("写入访问记录失败:", err)
+	}
+}
+
+func AccessResultText(result string) string {
+	if result == AccessOK {
+		return "允许"
+	}
+	return Entitlement{Reason: result}.ReasonText()
+}
+
+// 访问记录查询，user 为设备账号
+func AccessLogsQuery(user, ip, result string) *gorm.DB {
+	db := dao.DB.Model(&models.IptvAccessLog{})
+	if user != "" {
+		db = db.Where("user_name = ?", user)
+	}
+	if ip != "" {
+		// 前缀匹配，不用 LIKE 以免输入的 % _ 被当作通配符
+		db = db.Where("substr(ip, 1, ?) = ?", len(ip), ip)
+	}
+	switch result {
+	case "ok":
+		db = db.Where("result = ?", AccessOK)
+	case "deny":
+		db = db.Where("result <> ?", AccessOK)
+	}
+	return db
+}
+
+func SetAccessLogDays(params url.Values) dto.ReturnJsonDto {
+	days, err := strconv.ParseInt(params.Get("access_log_days"), 10, 64)
+	if err != nil || days < 1 || days > 3650 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "保留天数必须为 1-3650", Type: "danger"}
+	}
+	cfg := dao.GetConfig()
+	cfg.System.AccessLogDays = days
+	dao.SetConfig(cfg)
+	return dto.ReturnJsonDto{Code: 1, Msg: "保存成功，过期记录将在每日清理任务中删除", Type: "success"}
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const pairCodeLen = 8
+
+var (
+	errAccountFull     = errors.New("客户账号设备数已达上限")
+	errAccountNotFound = errors.New("客户账号不存在")
+	errDeviceNotFound  = errors.New("设备不存在")
+)
+
+// 生成数字配对码，方便遥控器输入
+func genPairCode() (string, error) {
+	max := big.NewInt(10)
+	for i := 0; i < 10; i++ {
+		b := make([]byte, pairCodeLen)
+		for j := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			b[j] = byte('0' + n.Int64())
+		}
+		var count int64
+		dao.DB.Model(&models.IptvAccount{}).Where("pair_code = ?", string(b)).Count(&count)
+		if count == 0 {
+			return string(b), nil
+		}
+	}
+	return "", errors.New("生成配对码失败")
+}
+
+// 账号下设备的显示状态，授权仍以账号为准
+func accountDeviceValues(account models.IptvAccount) map[string]interface{} {
+	status := int64(999)
+	if account.Exp > 0 {
+		status = 1
+	}
+	return map[string]interface{}{
+		"meal":   account.Meal,
+		"exp":    account.Exp,
+		"status": status,
+	}
+}
+
+// 同步账号套餐和到期时间到名下设备，已禁用的设备保持禁用
+func syncAccountDevices(tx *gorm.DB, account models.IptvAccount) error {
+	return tx.Model(&models.IptvUser{}).Where("account = ? and status <> 0", account.ID).Updates(accountDeviceValues(account)).Error
+}
+
+func clearAccountDevicesCache(accountIds ...int64) {
+	var deviceIds []string
+	dao.DB.Model(&models.IptvUser{}).Where("account in ? and deviceid <> ''", accountIds).Pluck("deviceid", &deviceIds)
+	for _, id := range deviceIds {
+		dao.Cache.Delete("mytvMealM3u8_" + id)
+	}
+}
+
+func clearDevicesCache(names []string) {
+	var deviceIds []string
+	dao.DB.Model(&models.IptvUser{}).Where("name in ? and deviceid <> ''", names).Pluck("deviceid", &deviceIds)
+	for _, id := range deviceIds {
+		dao.Cache.Delete("mytvMealM3u8_" + id)
+	}
+}
+
+// 绑定设备到账号，超过设备上限时返回错误
+func bindDevice(tx *gorm.DB, user models.IptvUser, account models.IptvAccount, by string) error {
+	if user.Account == account.ID {
+		return nil
+	}
+	if account.MaxDevices > 0 {
+		var count int64
+		tx.Model(&models.IptvUser{}).Where("account = ? and id <> ?", account.ID, user.ID).Count(&count)
+		if count >= account.MaxDevices {
+			return errAccountFull
+		}
+	}
+	values := accountDeviceValues(account)
+	values["account"] = account.ID
+	values["author"] = by
+	values["authortime"] = time.Now().Unix()
+	values["marks"] = "客户账号:" + account.Name
+	return tx.Model(&models.IptvUser{}).Where("id = ?", user.ID).Updates(values).Error
+}
+
+// 解绑设备，解绑后设备变为未授权
+func unbindDevices(tx *gorm.DB, names []string) error {
+	return tx.Model(&models.IptvUser{}).Where("name in ? and account > 0", names).Updates(map[string]interface{}{
+		"account": 0,
+		"status":  -1,
+		"exp":     0,
+		"marks":   "已解绑客户账号",
+	}).Error
+}
+
+func SaveAccount(params url.Values) dto.ReturnJsonDto {
+	accountId := params.Get("accountId")
+	name := strings.TrimSpace(params.Get("name"))
+	meal := params.Get("meal")
+	if !until.IsSafe(accountId) || !until.IsSafe(meal) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"}
+	}
+	if name == "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: "账号名称不能为空", Type: "danger"}
+	}
+
+	var mealData models.IptvMeals
+	if err := dao.DB.Model(&models.IptvMeals{}).Where("id = ?", meal).First(&mealData).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择套餐", Type: "danger"}
+	}
+
+	maxDevices, err := strconv.ParseInt(params.Get("maxdevices"), 10, 64)
+	if err != nil || maxDevices < 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "设备上限必须为非负整数，0为不限", Type: "danger"}
+	}
+
+	var exp int64
+	if v := strings.TrimSpace(params.Get("expdate")); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "到期时间格式错误", Type: "danger"}
+		}
+		exp = t.AddDate(0, 0, 1).Unix() - 1 // 当天结束前有效
+	}
+
+	account := models.IptvAccount{
+		Name:       name,
+		Meal:       mealData.ID,
+		Exp:        exp,
+		MaxDevices: maxDevices,
+		Marks:      params.Get("marks"),
+		Status:     1,
+	}
+
+	if accountId == "" {
+		if account.PairCode, err = genPairCode(); err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+		}
+		account.CreatedAt = time.Now().Unix()
+		if err := dao.DB.Create(&account).Error; err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "添加失败", Type: "danger"}
+		}
+		return dto.ReturnJsonDto{Code: 1, Msg: "添加成功，配对码: " + account.PairCode, Type: "success"}
+	}
+
+	var old models.IptvAccount
+	if err := dao.DB.Model(&models.IptvAccount{}).Where("id = ?", accountId).First(&old).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: errAccountNotFound.Error(), Type: "danger"}
+	}
+	account.ID = old.ID
+	account.PairCode = old.PairCode
+	account.Status = old.Status
+	account.CreatedAt = old.CreatedAt
+
+	err = dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&account).Error; err != nil {
+			return err
+		}
+		return syncAccountDevices(tx, account)
+	})
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "保存失败", Type: "danger"}
+	}
+	clearAccountDevicesCache(account.ID)
+	return dto.ReturnJsonDto{Code: 1, Msg: "保存成功", Type: "success"}
+}
+
+func DelAccount(params url.Values) dto.ReturnJsonDto {
+	accountId, err := strconv.ParseInt(params.Get("delaccount"), 10, 64)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "账号id不能为空", Type: "danger"}
+	}
+	clearAccountDevicesCache(accountId)
+
+	err = dao.DB.Transaction(func(tx *gorm.DB) error {
+		var names []string
+		tx.Model(&models.IptvUser{}).Where("account = ?", accountId).Pluck("name", &names)
+		if len(names) > 0 {
+			if err := unbindDevices(tx, names); err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", accountId).Delete(&models.IptvAccount{}).Error
+	})
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败", Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "删除成功，名下设备已解绑", Type: "success"}
+}
+
+func ChangeAccountStatus(params url.Values) dto.ReturnJsonDto {
+	accountId := params.Get("accountstatus")
+	if accountId == "" || !until.IsSafe(accountId) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "账号id不能为空", Type: "danger"}
+	}
+	var account models.IptvAccount
+	if err := dao.DB.Model(&models.IptvAccount{}).Where("id = ?", accountId).First(&account).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: errAccountNotFound.Error(), Type: "danger"}
+	}
+	status := int64(1)
+	if account.Status == 1 {
+		status = 0
+	}
+	if err := dao.DB.Model(&models.IptvAccount{}).Where("id = ?", account.ID).Update("status", status).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "更新失败", Type: "danger"}
+	}
+	clearAccountDevicesCache(account.ID)
+	return dto.ReturnJsonDto{Code: 1, Msg: "更新成功", Type: "success"}
+}
+
+func ResetPairCode(params url.Values) dto.ReturnJsonDto {
+	accountId := params.Get("resetpaircode")
+	if accountId == "" || !until.IsSafe(accountId) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "账号id不能为空", Type: "danger"}
+	}
+	code, err := genPairCode()
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+	res := dao.DB.Model(&models.IptvAccount{}).Where("id = ?", accountId).Update("pair_code", code)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "重置失败", Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "新配对码: " + code, Type: "success"}
+}
+
+func getAccountAndDevice(accountId, deviceName string) (models.IptvAccount, models.IptvUser, error) {
+	var account models.IptvAccount
+	var user models.IptvUser
+	if err := dao.DB.Model(&models.IptvAccount{}).Where("id = ?", accountId).First(&account).Error; err != nil {
+		return account, user, errAccountNotFound
+	}
+	if err := dao.DB.Model(&models.IptvUser{}).Where("name = ?", deviceName).First(&user).Error; err != nil {
+		return account, user, errDeviceNotFound
+	}
+	return account, user, nil
+}
+
+// 按设备账号绑定设备
+func BindAccountDevice(params url.Values, username string) dto.ReturnJsonDto {
+	accountId := params.Get("accountId")
+	device := strings.TrimSpace(params.Get("device"))
+	if !until.IsSafe(accountId) || !until.IsSafe(device) || device == "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"}
+	}
+	account, user, err := getAccountAndDevice(accountId, device)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+	if user.Account > 0 && user.Account != account.ID {
+		return dto.ReturnJsonDto{Code: 0, Msg: "设备已绑定其他客户账号，请使用迁移", Type: "danger"}
+	}
+	if err := bindDevice(dao.DB, user, account, username); err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+	clearDevicesCache([]string{device})
+	return dto.ReturnJsonDto{Code: 1, Msg: "绑定成功", Type: "success"}
+}
+
+func UnbindAccountDevices(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择设备", Type: "danger"}
+	}
+	clearDevicesCache(ids)
+	if err := unbindDevices(dao.DB, ids); err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "解绑失败", Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "解绑成功", Type: "success"}
+}
+
+// 迁移设备到其他账号
+func MoveAccountDevices(params url.Values, username string) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	target := params.Get("target")
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择设备", Type: "danger"}
+	}
+	if !until.IsSafe(target) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"}
+	}
+	var account models.IptvAccount
+	if err := dao.DB.Model(&models.IptvAccount{}).Where("id = ?", target).First(&account).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择目标账号", Type: "danger"}
+	}
+
+	var users []models.IptvUser
+	dao.DB.Model(&models.IptvUser{}).Where("name in ?", ids).Find(&users)
+	err := dao.DB.Transaction(func(tx *gorm.DB) error {
+		for _, user := range users {
+			if err := bindDevice(tx, user, account, username); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "迁移失败:" + err.Error(), Type: "danger"}
+	}
+	clearDevicesCache(ids)
+	return dto.ReturnJsonDto{Code: 1, Msg: fmt.Sprintf("已迁移 %d 台设备到 %s", len(users), account.Name), Type: "success"}
+}
+
+// 用新设备替换账号下的旧设备，旧设备解绑
+func ReplaceAccountDevice(params url.Values, username string) dto.ReturnJsonDto {
+	oldName := params.Get("replacedevice")
+	newName := strings.TrimSpace(params.Get("newdevice"))
+	if !until.IsSafe(oldName) || !until.IsSafe(newName) || oldName == "" || newName == "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"}
+	}
+	if oldName == newName {
+		return dto.ReturnJsonDto{Code: 0, Msg: "新旧设备不能相同", Type: "danger"}
+	}
+
+	var oldUser models.IptvUser
+	if err := dao.DB.Model(&models.IptvUser{}).Where("name = ? and account > 0", oldName).First(&oldUser).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "旧设备未绑定客户账号", Type: "danger"}
+	}
+	account, newUser, err := getAccountAndDevice(strconv.FormatInt(oldUser.Account, 10), newName)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+	if newUser.Account > 0 && newUser.Account != account.ID {
+		return dto.ReturnJsonDto{Code: 0, Msg: "新设备已绑定其他客户账号", Type: "danger"}
+	}
+
+	clearDevicesCache([]string{oldName, newName})
+	err = dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := unbindDevices(tx, []string{oldName}); err != nil {
+			return err
+		}
+		return bindDevice(tx, newUser, account, username)
+	})
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "替换失败:" + err.Error(), Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "替换成功", Type: "success"}
+}
+
+// 客户端通过配对码绑定客户账号，失败时返回原设备
+func PairDevice(user models.IptvUser, code, client string) models.IptvUser {
+	code = strings.TrimSpace(code)
+	if code == "" || user.ID == 0 {
+		return user
+	}
+	var account models.IptvAccount
+	if err := dao.DB.Model(&models.IptvAccount{}).Where("pair_code = ? and status = 1", code).First(&account).Error; err != nil {
+		log.Printf("设备配对失败 [%s] 用户: %d 配对码: %s 无效\n", client, user.Name, code)
+		return user
+	}
+	if user.Account == account.ID {
+		return user
+	}
+	if user.Account > 0 {
+		log.Printf("设备配对失败 [%s] 用户: %d 已绑定其他客户账号\n", client, user.Name)
+		return user
+	}
+	if user.Status == 0 {
+		log.Printf("设备配对失败 [%s] 用户: %d 设备已禁用\n", client, user.Name)
+		return user
+	}
+	if err := bindDevice(dao.DB, user, account, "配对码"); err != nil {
+		log.Printf("设备配对失败 [%s] 用户: %d 账号: %s %s\n", client, user.Name, account.Name, err.Error())
+		return user
+	}
+	if user.DeviceID != "" {
+		dao.Cache.Delete("mytvMealM3u8_" + user.DeviceID)
+	}
+	log.Printf("设备配对成功 [%s] 用户: %d 账号: %s\n", client, user.Name, account.Name)
+
+	dao.DB.Model(&models.IptvUser{}).Where("id = ?", user.ID).First(&user)
+	return user
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 卡密字符集，去掉易混淆的 0O1I
+const actCodeChars = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+const (
+	actCodeLen      = 16
+	actCodeMaxCount = 1000 // 单次最多生成数量
+)
+
+var errActCodeUsed = errors.New("卡密已被使用")
+
+func genActCode() (string, error) {
+	b := make([]byte, actCodeLen)
+	max := big.NewInt(int64(len(actCodeChars)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = actCodeChars[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// 统一卡密格式，忽略大小写、空格和分隔符
+func NormalizeActCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// 卡密分组显示 XXXX-XXXX-XXXX-XXXX
+func FormatActCode(code string) string {
+	var parts []string
+	for i := 0; i < len(code); i += 4 {
+		end := i + 4
+		if end > len(code) {
+			end = len(code)
+		}
+		parts = append(parts, code[i:end])
+	}
+	return strings.Join(parts, "-")
+}
+
+func GenActCodes(params url.Values, username string) dto.ReturnJsonDto {
+	meal := params.Get("meal")
+	batch := strings.TrimSpace(params.Get("batch"))
+	if !until.IsSafe(meal) || !until.IsSafe(batch) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"}
+	}
+
+	var mealData models.IptvMeals
+	if err := dao.DB.Model(&models.IptvMeals{}).Where("id = ? and status = 1", meal).First(&mealData).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择上线套餐", Type: "danger"}
+	}
+
+	count, err := strconv.ParseInt(params.Get("count"), 10, 64)
+	if err != nil || count <= 0 || count > actCodeMaxCount {
+		return dto.ReturnJsonDto{Code: 0, Msg: fmt.Sprintf("生成数量必须为 1-%d", actCodeMaxCount), Type: "danger"}
+	}
+	days, err := strconv.ParseInt(params.Get("days"), 10, 64)
+	if err != nil || days < 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "授权天数必须为非负整数，0为永久", Type: "danger"}
+	}
+	maxUses := int64(1)
+	if v := params.Get("maxuses"); v != "" {
+		maxUses, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || maxUses <= 0 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "可使用次数必须为正整数", Type: "danger"}
+		}
+	}
+
+	var expiresAt int64
+	if v := strings.TrimSpace(params.Get("expdate")); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "卡密有效期格式错误", Type: "danger"}
+		}
+		expiresAt = t.AddDate(0, 0, 1).Unix() - 1 // 当天结束前有效
+		if expiresAt < time.Now().Unix() {
+			return dto.ReturnJsonDto{Code: 0, Msg: "卡密有效期不能早于今天", Type: "danger"}
+		}
+	}
+
+	now := time.Now()
+	if batch == "" {
+		batch = now.Format("20060102150405")
+	}
+
+	codes := make([]models.IptvActCode, 0, count)
+	exist := make(map[string]struct{}, count)
+	for int64(len(codes)) < count {
+		code, err := genActCode()
+		if err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "生成卡密失败:" + err.Error(), Type: "danger"}
+		}
+		if _, ok := exist[code]; ok {
+			continue
+		}
+		exist[code] = struct{}{}
+		codes = append(codes, models.IptvActCode{
+			Code:      code,
+			Meal:      mealData.ID,
+			Days:      days,
+			MaxUses:   maxUses,
+			ExpiresAt: expiresAt,
+			Batch:     batch,
+			Status:    1,
+			Author:    username,
+			CreatedAt: now.Unix(),
+		})
+	}
+
+	res := dao.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&codes, 500)
+	if res.Error != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "保存卡密失败:" + res.Error.Error(), Type: "danger"}
+	}
+	log.Printf("管理员 %s 生成卡密 %d 个，批次: %s 套餐: %s 天数: %d\n", username, res.RowsAffected, batch, mealData.Name, days)
+	return dto.ReturnJsonDto{Code: 1, Msg: fmt.Sprintf("生成成功 %d 个，批次: %s", res.RowsAffected, batch), Type: "success"}
+}
+
+func DelActCodes(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择卡密", Type: "danger"}
+	}
+	if err := dao.DB.Where("id in ?", ids).Delete(&models.IptvActCode{}).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败", Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "删除成功", Type: "success"}
+}
+
+func SetActCodesStatus(params url.Values, status int64) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择卡密", Type: "danger"}
+	}
+	if err := dao.DB.Model(&models.IptvActCode{}).Where("id in ?", ids).Update("status", status).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "操作失败", Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "操作成功", Type: "success"}
+}
+
+// 卡密列表筛选，html 列表和导出共用
+func ActCodesQuery(batch, state, keywords string) *gorm.DB {
+	now := time.Now().Unix()
+	dbQuery := dao.DB.Model(&models.IptvActCode{})
+	if batch != "" {
+		dbQuery = dbQuery.Where("batch = ?", batch)
+	}
+	if keywords != "" {
+		dbQuery = dbQuery.Where("code like ?", "%"+NormalizeActCode(keywords)+"%")
+	}
+	switch state {
+	case "unused":
+		dbQuery = dbQuery.Where("status = 1 and used_count < max_uses and (expires_at = 0 or expires_at >= ?)", now)
+	case "used":
+		dbQuery = dbQuery.Where("used_count > 0")
+	case "disabled":
+		dbQuery = dbQuery.Where("status = 0")
+	case "expired":
+		dbQuery = dbQuery.Where("expires_at > 0 and expires_at < ?", now)
+	}
+	return dbQuery
+}
+
+func ActCodeStateDesc(code models.IptvActCode) string {
+	switch {
+	case code.Status != 1:
+		return "已停用"
+	case code.UsedCount >= code.MaxUses:
+		return "已用完"
+	case code.ExpiresAt > 0 && code.ExpiresAt < time.Now().Unix():
+		return "已过期"
+	case code.UsedCount > 0:
+		return "部分使用"
+	}
+	return "未使用"
+}
+
+func ExportActCodes(batch, state, keywords string) ([]byte, error) {
+	var list []models.IptvActCode
+	if err := ActCodesQuery(batch, state, keywords).Order("id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	mealNames := make(map[int64]string)
+	var meals []models.IptvMeals
+	dao.DB.Model(&models.IptvMeals{}).Find(&meals)
+	for _, m := range meals {
+		mealNames[m.ID] = m.Name
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"code", "meal", "days", "max_uses", "used_count", "expires", "batch", "state", "created"})
+	for _, v := range list {
+		expires := ""
+		if v.ExpiresAt > 0 {
+			expires = time.Unix(v.ExpiresAt, 0).Format("2006-01-02")
+		}
+		w.Write([]string{
+			FormatActCode(v.Code),
+			mealNames[v.Meal],
+			strconv.FormatInt(v.Days, 10),
+			strconv.FormatInt(v.MaxUses, 10),
+			strconv.FormatInt(v.UsedCount, 10),
+			expires,
+			v.Batch,
+			ActCodeStateDesc(v),
+			time.Unix(v.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// APK 兑换卡密，按 MAC 查找设备
+func ApkRedeem(req dto.RedeemReqDto, ip string) dto.RedeemResDto {
+	var user models.IptvUser
+	if req.Mac != "" {
+		dao.DB.Model(&models.IptvUser{}).Where("mac = ?", req.Mac).First(&user)
+	}
+	return RedeemActCode(user, req.Code, "apk", ip)
+}
+
+// MyTV 兑换卡密，按设备ID查找设备
+func MytvRedeem(req dto.RedeemReqDto, ip string) dto.RedeemResDto {
+	var user models.IptvUser
+	if req.DeviceID != "" {
+		dao.DB.Model(&models.IptvUser{}).Where("deviceid = ?", req.DeviceID).First(&user)
+	}
+	return RedeemActCode(user, req.Code, "mytv", ip)
+}
+
+// 兑换卡密：绑定到设备并设置套餐、到期时间和状态
+func RedeemActCode(user models.IptvUser, code, client, ip string) dto.RedeemResDto {
+	code = NormalizeActCode(code)
+	if code == "" {
+		return dto.RedeemResDto{Code: 0, Msg: "请输入卡密"}
+	}
+	if user.ID == 0 {
+		return dto.RedeemResDto{Code: 0, Msg: "设备未注册，请先打开客户端"}
+	}
+	if user.Status == 0 {
+		return dto.RedeemResDto{Code: 0, Msg: "设备已禁用"}
+	}
+
+	fail := func(msg string) dto.RedeemResDto {
+		log.Printf("卡密兑换失败 [%s] 用户: %d 卡密: %s IP: %s %s\n", client, user.Name, code, ip, msg)
+		return dto.RedeemResDto{Code: 0, Msg: msg}
+	}
+
+	var actCode models.IptvActCode
+	if err := dao.DB.Model(&models.IptvActCode{}).Where("code = ?", code).First(&actCode).Error; err != nil {
+		return fail("卡密不存在")
+	}
+	now := time.Now().Unix()
+	if actCode.Status != 1 {
+		return fail("卡密已停用")
+	}
+	if actCode.ExpiresAt > 0 && actCode.ExpiresAt < now {
+		return fail("卡密已过期")
+	}
+	if actCode.UsedCount >= actCode.MaxUses {
+		return fail(errActCodeUsed.Error())
+	}
+
+	var meal models.IptvMeals
+	if err := dao.DB.Model(&models.IptvMeals{}).Where("id = ? and status = 1", actCode.Meal).First(&meal).Error; err != nil {
+		return fail("套餐已下线")
+	}
+
+	var used int64
+	dao.DB.Model(&models.IptvActCodeLog{}).Where("code_id = ? and user_name = ?", actCode.ID, user.Name).Count(&used)
+	if used > 0 {
+		return fail("该设备已使用过此卡密")
+	}
+	// 绑定客户账号的设备兑换到账号上
+	var account models.IptvAccount
+	if user.Account > 0 {
+		dao.DB.Model(&models.IptvAccount{}).Where("id = ?", user.Account).First(&account)
+	}
+	curMeal, curExp, permanent := user.Meal, user.Exp, user.Status == 999
+	active := user.Status == 1 && user.Exp > now
+	if account.ID > 0 {
+		if account.Status != 1 {
+			return fail("客户账号已禁用")
+		}
+		curMeal, curExp, permanent = account.Meal, account.Exp, account.Exp == 0
+		active = account.Exp > now
+	}
+	if permanent && curMeal == actCode.Meal {
+		return fail("当前设备已永久授权该套餐")
+	}
+
+	status, exp := int64(999), int64(0)
+	if actCode.Days > 0 {
+		// 同套餐未过期时续期，否则从现在开始计算
+		base := time.Now()
+		if active && curMeal == actCode.Meal {
+			base = time.Unix(curExp, 0)
+		}
+		status, exp = 1, base.AddDate(0, 0, int(actCode.Days)).Unix()
+	}
+
+	err := dao.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.IptvActCode{}).
+			Where("id = ? and used_count < max_uses", actCode.ID).
+			Update("used_count", gorm.Expr("used_count + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errActCodeUsed
+		}
+		if err := tx.Create(&models.IptvActCodeLog{
+			CodeID:    actCode.ID,
+			Code:      actCode.Code,
+			UserName:  user.Name,
+			DeviceID:  user.DeviceID,
+			Mac:       user.Mac,
+			IP:        ip,
+			Client:    client,
+			Meal:      actCode.Meal,
+			Days:      actCode.Days,
+			Exp:       exp,
+			CreatedAt: now,
+		}).Error; err != nil {
+			return errors.New("该设备已使用过此卡密")
+		}
+		marks := "卡密兑换(永久)"
+		if actCode.Days > 0 {
+			marks = "卡密兑换(" + strconv.FormatInt(actCode.Days, 10) + "天)"
+		}
+		if account.ID > 0 {
+			account.Meal, account.Exp = actCode.Meal, exp
+			if err := tx.Model(&models.IptvAccount{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+				"meal": account.Meal,
+				"exp":  account.Exp,
+			}).Error; err != nil {
+				return err
+			}
+			return syncAccountDevices(tx, account)
+		}
+		return tx.Model(&models.IptvUser{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"meal":       actCode.Meal,
+			"status":     status,
+			"exp":        exp,
+			"author":     "卡密",
+			"authortime": now,
+			"marks":      marks,
+		}).Error
+	})
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	if account.ID > 0 {
+		clearAccountDevicesCache(account.ID)
+	} else if user.DeviceID != "" {
+		dao.Cache.Delete("mytvMealM3u8_" + user.DeviceID)
+	}
+	log.Printf("卡密兑换成功 [%s] 用户: %d 卡密: %s IP: %s 套餐: %s 天数: %d\n", client, user.Name, code, ip, meal.Name, actCode.Days)
+
+	res := dto.RedeemResDto{Code: 1, Msg: "兑换成功", MealName: meal.Name, Exp: exp}
+	if exp > 0 {
+		res.Days = until.CalcRemainDays(exp)
+	}
+	return res
+}
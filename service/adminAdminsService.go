@@ -9,9 +9,11 @@ import (
 	"log"
 	"net/url"
 	"runtime"
+	"time"
 )
 
-func Admins(params url.Values) dto.ReturnJsonDto {
+// 修改当前登录管理员的信息，改名时 Data 返回新用户名
+func Admins(params url.Values, loginUser string) dto.ReturnJsonDto {
 	username := params.Get("username")
 	oldPassword := params.Get("oldpassword")
 	newpassword := params.Get("newpassword")
@@ -37,20 +39,121 @@ func Admins(params url.Values) dto.ReturnJsonDto {
 	}
 
 	var adminData models.IptvAdmin
-	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", 1).First(&adminData)
-	if adminData.PassWord != until.HashPassword(oldPassword) {
+	dao.DB.Model(&models.IptvAdmin{}).Where("username = ?", loginUser).First(&adminData)
+	if ok, _ := until.CheckPassword(adminData.PassWord, oldPassword); adminData.ID == 0 || !ok {
 		return dto.ReturnJsonDto{Code: 0, Msg: "旧密码错误", Type: "danger"}
 	}
+	if username != loginUser && adminExists(username) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "用户名已存在", Type: "danger"}
+	}
 
-	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", 1).Updates(map[string]interface{}{
-		"password": until.HashPassword(newpassword),
-		"username": username,
-	})
+	updates := map[string]interface{}{"username": username}
+	if newpassword != "" {
+		updates["password"] = until.HashPassword(newpassword)
+	}
+	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", adminData.ID).Updates(updates)
 
-	// TODO
+	if username != loginUser {
+		log.Printf("管理员 %s 修改用户名为 %s\n", loginUser, username)
+		return dto.ReturnJsonDto{Code: 1, Msg: "修改成功", Type: "success", Data: username}
+	}
 	return dto.ReturnJsonDto{Code: 1, Msg: "修改成功", Type: "success"}
+}
+
+func adminExists(username string) bool {
+	var count int64
+	dao.DB.Model(&models.IptvAdmin{}).Where("username = ?", username).Count(&count)
+	return count > 0
+}
+
+// 新增管理员
+func AddAdmin(params url.Values, loginUser string) dto.ReturnJsonDto {
+	username := params.Get("username")
+	password := params.Get("password")
+	role := params.Get("role")
+
+	if username == "" || password == "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: "用户名和密码不能为空", Type: "danger"}
+	}
+	if !until.IsSafe(username) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "用户名不合法", Type: "danger"}
+	}
+	if !until.IsRole(role) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "角色不存在", Type: "danger"}
+	}
+	if adminExists(username) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "用户名已存在", Type: "danger"}
+	}
+
+	dao.DB.Model(&models.IptvAdmin{}).Create(&models.IptvAdmin{
+		UserName:  username,
+		PassWord:  until.HashPassword(password),
+		Role:      role,
+		Status:    1,
+		CreatedAt: time.Now().Unix(),
+	})
+	log.Printf("管理员 %s 新增管理员 %s，角色: %s\n", loginUser, username, until.RoleName(role))
+	return dto.ReturnJsonDto{Code: 1, Msg: "新增管理员成功", Type: "success"}
+}
+
+// 启用/禁用管理员，不能禁用自己
+func SetAdminsStatus(params url.Values, status int64, loginUser string) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择管理员", Type: "danger"}
+	}
+	var admins []models.IptvAdmin
+	dao.DB.Model(&models.IptvAdmin{}).Where("id in (?)", ids).Find(&admins)
+	for _, a := range admins {
+		if a.UserName == loginUser && status != 1 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "不能禁用当前登录的管理员", Type: "danger"}
+		}
+	}
+	dao.DB.Model(&models.IptvAdmin{}).Where("id in (?)", ids).Update("status", status)
+	// 禁用后立即吊销其登录会话
+	if status != 1 {
+		adminIDs := make([]int64, 0, len(admins))
+		for _, a := range admins {
+			adminIDs = append(adminIDs, a.ID)
+		}
+		RevokeAdminSessions(adminIDs...)
+	}
+	log.Printf("管理员 %s 修改管理员状态为 %d: %v\n", loginUser, status, ids)
+	return dto.ReturnJsonDto{Code: 1, Msg: "操作成功", Type: "success"}
+}
+
+// 重置管理员密码并修改角色
+func ResetAdmin(params url.Values, loginUser string) dto.ReturnJsonDto {
+	id := params.Get("id")
+	password := params.Get("password")
+	role := params.Get("role")
 
-	// TODO
+	var admin models.IptvAdmin
+	if err := dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", id).First(&admin).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "管理员不存在", Type: "danger"}
+	}
+	if !until.IsRole(role) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "角色不存在", Type: "danger"}
+	}
+	if admin.UserName == loginUser && role != admin.Role {
+		return dto.ReturnJsonDto{Code: 0, Msg: "不能修改自己的角色", Type: "danger"}
+	}
+
+	updates := map[string]interface{}{"role": role}
+	if password != "" {
+		updates["password"] = until.HashPassword(password)
+	}
+	resetTotp := params.Get("reset_totp") == "1" || params.Get("reset_totp") == "on"
+	if resetTotp {
+		updates["totp"], updates["totp_key"], updates["recovery_codes"] = 0, "", ""
+	}
+	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", admin.ID).Updates(updates)
+	// 重置密码后已登录的会话全部失效
+	if password != "" {
+		RevokeAdminSessions(admin.ID)
+	}
+	log.Printf("管理员 %s 修改管理员 %s，角色: %s 重置密码: %t 关闭两步验证: %t\n", loginUser, admin.UserName, until.RoleName(role), password != "", resetTotp)
+	return dto.ReturnJsonDto{Code: 1, Msg: "修改成功", Type: "success"}
 }
 
 func UpdataCheckWeb() dto.ReturnJsonDto {
@@ -100,26 +203,37 @@ func UpdataCheckLic() dto.ReturnJsonDto {
 func UpdataDownWeb() dto.ReturnJsonDto {
 	up, newWeb, err := until.DownloadAndVerifyWeb(runtime.GOARCH)
 	if err != nil {
+		until.Notify(until.NotifyError, until.SourceUpdata, "updata:web", "管理系统新版本下载失败: "+err.Error())
 		return dto.ReturnJsonDto{Code: 0, Msg: "下载失败: " + err.Error(), Type: "danger"}
 	}
 	if up {
+		until.Notify(until.NotifyInfo, until.SourceUpdata, "updata:web", "管理系统新版本 "+newWeb+" 已下载")
 		return dto.ReturnJsonDto{Code: 1, Msg: "管理系统新版本: " + newWeb, Type: "success"}
 	}
+	until.Notify(until.NotifyError, until.SourceUpdata, "updata:web", "管理系统新版本下载失败")
 	return dto.ReturnJsonDto{Code: 0, Msg: "下载失败", Type: "danger"}
 }
 
 func UpdataDownLic() dto.ReturnJsonDto {
 	up, newLic, err := until.DownloadAndVerifyLic(runtime.GOARCH)
 	if err != nil {
+		until.Notify(until.NotifyError, until.SourceUpdata, "updata:lic", "引擎新版本下载失败: "+err.Error())
 		return dto.ReturnJsonDto{Code: 0, Msg: "下载失败: " + err.Error(), Type: "danger"}
 	}
 	if up {
+		until.Notify(until.NotifyInfo, until.SourceUpdata, "updata:lic", "引擎新版本 "+newLic+" 已下载")
 		return dto.ReturnJsonDto{Code: 1, Msg: "引擎新版本: " + newLic, Type: "success"}
 	}
+	until.Notify(until.NotifyError, until.SourceUpdata, "updata:lic", "引擎新版本下载失败")
 	return dto.ReturnJsonDto{Code: 0, Msg: "下载失败", Type: "danger"}
 }
 
 func Updata() dto.ReturnJsonDto {
-	go until.UpdateSignal()
+	go func() {
+		if err := until.UpdateSignal(); err != nil {
+			log.Println("触发更新失败:", err)
+			until.Notify(until.NotifyError, until.SourceUpdata, "updata", "触发更新失败: "+err.Error())
+		}
+	}()
 	return dto.ReturnJsonDto{Code: 1, Msg: "已触发更新，请稍后刷新...", Type: "success"}
 }
@@ -84,14 +84,16 @@ func SubmitAuthorWithDays(params url.Values, username string) dto.ReturnJsonDto
 	}
 }
 
-func ForbiddenUser(params url.Values) dto.ReturnJsonDto {
+func ForbiddenUser(params url.Values, username string) dto.ReturnJsonDto {
 	ids := params["ids[]"]
 
 	if len(ids) == 0 {
 		return dto.ReturnJsonDto{Code: 0, Msg: "请选择用户", Type: "danger"}
 	}
 	dao.DB.Model(&models.IptvUser{}).Where("name IN (?)", ids).Updates(map[string]interface{}{
-		"status": 0,
+		"status":     0,
+		"author":     username,
+		"authortime": time.Now().Unix(),
 	})
 	return dto.ReturnJsonDto{
 		Code: 1,
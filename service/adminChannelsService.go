@@ -245,6 +245,7 @@ func UpdateList(params url.Values) dto.ReturnJsonDto {
 	client := &http.Client{}
 	req, err := http.NewRequest("GET", iptvCategoryList.Url, nil)
 	if err != nil {
+		until.ReportListFailed(iptvCategoryList, "创建请求错误: "+err.Error())
 		return dto.ReturnJsonDto{Code: 0, Msg: "获取频道列表失败-创建请求错误:" + err.Error(), Type: "danger"}
 	}
 
@@ -253,16 +254,19 @@ func UpdateList(params url.Values) dto.ReturnJsonDto {
 
 	resp, err := client.Do(req)
 	if err != nil {
+		until.ReportListFailed(iptvCategoryList, "无法访问url: "+err.Error())
 		return dto.ReturnJsonDto{Code: 0, Msg: "获取频道列表失败-无法访问url:" + err.Error(), Type: "danger"}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
+		until.ReportListFailed(iptvCategoryList, "状态码: "+strconv.Itoa(resp.StatusCode))
 		return dto.ReturnJsonDto{Code: 0, Msg: "获取频道列表失败-状态码:" + strconv.Itoa(resp.StatusCode), Type: "danger"}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		until.ReportListFailed(iptvCategoryList, "读取响应失败: "+err.Error())
 		return dto.ReturnJsonDto{Code: 0, Msg: "获取频道列表失败", Type: "danger"}
 	}
 
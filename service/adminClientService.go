@@ -5,6 +5,7 @@ import (
 	"go-iptv/bootstrap"
 	"go-iptv/dao"
 	"go-iptv/dto"
+	"go-iptv/models"
 	"go-iptv/until"
 	"net/http"
 	"net/url"
@@ -142,6 +143,33 @@ func SetNeedAuthor(params url.Values) dto.ReturnJsonDto {
 	}
 }
 
+func SetTrialSet(params url.Values) dto.ReturnJsonDto {
+	values := make([]int64, 4)
+	for i, k := range []string{"default_meal", "trialdays", "trial_max_ip", "trial_max_model"} {
+		v, err := strconv.ParseInt(params.Get(k), 10, 64)
+		if err != nil || v < 0 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "参数错误", Type: "danger"}
+		}
+		values[i] = v
+	}
+	if values[1] > 3650 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "试用天数不能超过3650天", Type: "danger"}
+	}
+	var count int64
+	dao.DB.Model(&models.IptvMeals{}).Where("id = ?", values[0]).Count(&count)
+	if count == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "套餐不存在", Type: "danger"}
+	}
+
+	cfg := dao.GetConfig()
+	cfg.App.DefaultMeal = values[0]
+	cfg.App.TrialDays = values[1]
+	cfg.App.TrialMaxIP = values[2]
+	cfg.App.TrialMaxMod = values[3]
+	dao.SetConfig(cfg)
+	return dto.ReturnJsonDto{Code: 1, Msg: "试用设置成功", Type: "success"}
+}
+
 func SetAppInfo(params url.Values) dto.ReturnJsonDto {
 
 	buildStatus := bootstrap.GetBuildStatus()
@@ -198,6 +226,7 @@ func SetTipSet(params url.Values) dto.ReturnJsonDto {
 	tipuserexpired := params.Get("tipuserexpired")
 	tipuserforbidden := params.Get("tipuserforbidden")
 	tipusernoreg := params.Get("tipusernoreg")
+	tipuserexpiring := params.Get("tipuserexpiring")
 
 	cfg := dao.GetConfig()
 
@@ -205,6 +234,7 @@ func SetTipSet(params url.Values) dto.ReturnJsonDto {
 	cfg.Tips.UserExpired = tipuserexpired
 	cfg.Tips.UserForbidden = tipuserforbidden
 	cfg.Tips.UserNoReg = tipusernoreg
+	cfg.Tips.UserExpiring = tipuserexpiring
 
 	dao.SetConfig(cfg)
 	return dto.ReturnJsonDto{Code: 1, Msg: "设置成功", Type: "success"}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SaveAlias(params url.Values) dto.ReturnJsonDto {
+	aliasId := params.Get("aliasId")
+	alias := strings.TrimSpace(params.Get("alias"))
+	name := strings.TrimSpace(params.Get("aliasname"))
+
+	if alias == "" || name == "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: "别名和EPG名称不能为空", Type: "danger"}
+	}
+	if !until.IsSafe(alias) || !until.IsSafe(name) || !until.IsSafe(aliasId) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"}
+	}
+	if alias == name {
+		return dto.ReturnJsonDto{Code: 0, Msg: "别名不能与EPG名称相同", Type: "danger"}
+	}
+
+	var old models.IptvEpgAlias
+	dao.DB.Model(&models.IptvEpgAlias{}).Where("alias = ?", alias).First(&old)
+	if old.ID != 0 && fmt.Sprintf("%d", old.ID) != aliasId {
+		return dto.ReturnJsonDto{Code: 0, Msg: "该别名已存在", Type: "danger"}
+	}
+
+	data := models.IptvEpgAlias{Alias: alias, Name: name, Source: "local", UpdatedAt: time.Now().Unix()}
+	if aliasId != "" {
+		if err := dao.DB.Model(&models.IptvEpgAlias{}).Where("id = ?", aliasId).Updates(&data).Error; err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "保存失败", Type: "danger"}
+		}
+	} else {
+		if err := dao.DB.Model(&models.IptvEpgAlias{}).Create(&data).Error; err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "添加失败", Type: "danger"}
+		}
+	}
+	until.RebuildAlias()
+	return dto.ReturnJsonDto{Code: 1, Msg: "保存成功，稍后自动重新绑定频道", Type: "success"}
+}
+
+func DelAlias(params url.Values) dto.ReturnJsonDto {
+	aliasId := params.Get("delalias")
+	if aliasId == "" || !until.IsSafe(aliasId) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "别名id不能为空", Type: "danger"}
+	}
+	if err := dao.DB.Where("id = ?", aliasId).Delete(&models.IptvEpgAlias{}).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败", Type: "danger"}
+	}
+	until.RebuildAlias()
+	return dto.ReturnJsonDto{Code: 1, Msg: "删除成功", Type: "success"}
+}
+
+func DelAliases(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择别名", Type: "danger"}
+	}
+	if err := dao.DB.Where("id in ?", ids).Delete(&models.IptvEpgAlias{}).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败", Type: "danger"}
+	}
+	until.RebuildAlias()
+	return dto.ReturnJsonDto{Code: 1, Msg: "删除成功", Type: "success"}
+}
+
+func MergeAlias(params url.Values) dto.ReturnJsonDto {
+	path, ok := until.CheckAliasPath(params.Get("aliaspath"))
+	if !ok {
+		return dto.ReturnJsonDto{Code: 0, Msg: "只允许合并 /config 目录下的 json 文件或内置 alias.json", Type: "danger"}
+	}
+	if !until.Exists(path) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "文件不存在: " + path, Type: "danger"}
+	}
+	aliasMap, err := until.LoadAliasFile(path)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+
+	source := "community"
+	if path == "./alias.json" {
+		source = "builtin"
+	}
+	add, up, err := until.ImportAlias(aliasMap, source, params.Get("overwrite") == "1")
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "合并失败:" + err.Error(), Type: "danger"}
+	}
+	if add > 0 || up > 0 {
+		until.RebuildAlias()
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: fmt.Sprintf("合并完成，新增 %d 条，更新 %d 条", add, up), Type: "success"}
+}
+
+func ImportAliasFile(c *gin.Context) dto.ReturnJsonDto {
+	file, err := c.FormFile("aliasfile")
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "获取文件失败:" + err.Error(), Type: "danger"}
+	}
+	if file.Size > 20*1024*1024 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "文件过大", Type: "danger"}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "打开文件失败:" + err.Error(), Type: "danger"}
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "读取文件失败:" + err.Error(), Type: "danger"}
+	}
+	aliasMap, err := until.ParseAliasJson(data)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+
+	add, up, err := until.ImportAlias(aliasMap, "local", c.PostForm("overwrite") == "1")
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "导入失败:" + err.Error(), Type: "danger"}
+	}
+	if add > 0 || up > 0 {
+		until.RebuildAlias()
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: fmt.Sprintf("导入完成，新增 %d 条，更新 %d 条", add, up), Type: "success"}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SaveEpgCustom(params url.Values) dto.ReturnJsonDto {
+	customId := params.Get("customId")
+	if !until.IsSafe(customId) || !until.IsSafe(params.Get("channel")) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "输入不合法", Type: "danger"}
+	}
+
+	data := models.IptvEpgCustom{
+		Channel:   params.Get("channel"),
+		Title:     params.Get("title"),
+		Desc:      params.Get("desc"),
+		Type:      params.Get("type"),
+		Weekdays:  strings.Join(params["weekdays[]"], ","),
+		StartTime: params.Get("starttime"),
+		EndTime:   params.Get("endtime"),
+		Status:    1,
+	}
+	if data.Type == "once" {
+		var err error
+		// 兼容 datetime-local 格式
+		if data.StartAt, err = until.ParseEpgCustomTime(strings.Replace(params.Get("startat"), "T", " ", 1)); err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "开始" + err.Error(), Type: "danger"}
+		}
+		if data.EndAt, err = until.ParseEpgCustomTime(strings.Replace(params.Get("endat"), "T", " ", 1)); err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "结束" + err.Error(), Type: "danger"}
+		}
+	}
+	if err := until.CheckEpgCustom(&data); err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+	data.UpdatedAt = time.Now().Unix()
+
+	if customId != "" {
+		var old models.IptvEpgCustom
+		if err := dao.DB.Model(&models.IptvEpgCustom{}).Where("id = ?", customId).First(&old).Error; err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "节目不存在", Type: "danger"}
+		}
+		data.ID = old.ID
+		data.Status = old.Status
+		if err := dao.DB.Save(&data).Error; err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "保存失败", Type: "danger"}
+		}
+	} else {
+		if err := dao.DB.Create(&data).Error; err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "添加失败", Type: "danger"}
+		}
+	}
+	go until.SyncEpgCustom()
+	return dto.ReturnJsonDto{Code: 1, Msg: "保存成功", Type: "success"}
+}
+
+func DelEpgCustom(params url.Values) dto.ReturnJsonDto {
+	customId := params.Get("delcustom")
+	if customId == "" || !until.IsSafe(customId) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "节目id不能为空", Type: "danger"}
+	}
+	if err := dao.DB.Where("id = ?", customId).Delete(&models.IptvEpgCustom{}).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败", Type: "danger"}
+	}
+	go until.SyncEpgCustom()
+	return dto.ReturnJsonDto{Code: 1, Msg: "删除成功", Type: "success"}
+}
+
+func DelEpgCustoms(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择节目", Type: "danger"}
+	}
+	if err := dao.DB.Where("id in ?", ids).Delete(&models.IptvEpgCustom{}).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败", Type: "danger"}
+	}
+	go until.SyncEpgCustom()
+	return dto.ReturnJsonDto{Code: 1, Msg: "删除成功", Type: "success"}
+}
+
+func ChangeEpgCustomStatus(params url.Values) dto.ReturnJsonDto {
+	customId := params.Get("customstatus")
+	if customId == "" || !until.IsSafe(customId) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "节目id不能为空", Type: "danger"}
+	}
+	var data models.IptvEpgCustom
+	if err := dao.DB.Model(&models.IptvEpgCustom{}).Where("id = ?", customId).First(&data).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "节目不存在", Type: "danger"}
+	}
+	status := int64(1)
+	if data.Status == 1 {
+		status = 0
+	}
+	if err := dao.DB.Model(&models.IptvEpgCustom{}).Where("id = ?", customId).Update("status", status).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "更新失败", Type: "danger"}
+	}
+	go until.SyncEpgCustom()
+	return dto.ReturnJsonDto{Code: 1, Msg: "更新成功", Type: "success"}
+}
+
+// 导入 CSV 或 XMLTV，按文件后缀区分
+func ImportEpgCustomFile(c *gin.Context) dto.ReturnJsonDto {
+	file, err := c.FormFile("customfile")
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "获取文件失败:" + err.Error(), Type: "danger"}
+	}
+	if file.Size > 20*1024*1024 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "文件过大", Type: "danger"}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "打开文件失败:" + err.Error(), Type: "danger"}
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "读取文件失败:" + err.Error(), Type: "danger"}
+	}
+
+	var list []models.IptvEpgCustom
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".csv":
+		list, err = until.ParseEpgCustomCsv(bytes.NewReader(data))
+	case ".xml":
+		list, err = until.ParseEpgCustomXml(data)
+	default:
+		return dto.ReturnJsonDto{Code: 0, Msg: "只支持 csv 或 xml 文件", Type: "danger"}
+	}
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+	if len(list) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "文件中没有有效节目", Type: "danger"}
+	}
+
+	add, err := until.ImportEpgCustom(list, c.PostForm("replace") == "1")
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "导入失败:" + err.Error(), Type: "danger"}
+	}
+	if add > 0 {
+		go until.SyncEpgCustom()
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: fmt.Sprintf("导入完成，新增 %d 条", add), Type: "success"}
+}
@@ -202,6 +202,10 @@ func EpgImport(params url.Values) dto.ReturnJsonDto {
 	url := strings.TrimSpace(params.Get("epgfromurl"))
 	ua := params.Get("epgfromua")
 	eId := params.Get("eid")
+	listType := params.Get("epgfromtype")
+	if listType != "diyp" {
+		listType = "xmltv"
+	}
 
 	if listName == "" {
 		return dto.ReturnJsonDto{Code: 0, Msg: "请输入频道列表", Type: "danger"}
@@ -221,7 +225,7 @@ func EpgImport(params url.Values) dto.ReturnJsonDto {
 		return dto.ReturnJsonDto{Code: 0, Msg: "该频道列表已存在", Type: "danger"}
 	}
 
-	iptvEpgList := models.IptvEpgList{Name: listName, Url: url, Status: 1, Remarks: remarks, UA: ua}
+	iptvEpgList := models.IptvEpgList{Name: listName, Url: url, Status: 1, Remarks: remarks, UA: ua, Type: listType}
 	if eId != "" {
 		if err := dao.DB.Model(&models.IptvEpgList{}).Where("id = ?", eId).First(&eOld).Error; err != nil {
 			return dto.ReturnJsonDto{Code: 0, Msg: "频道列表不存在", Type: "danger"}
@@ -5,25 +5,62 @@ import (
 	"go-iptv/dto"
 	"go-iptv/models"
 	"go-iptv/until"
+	"log"
 	"time"
 )
 
-func AdminLogin(username, password string, reMe bool) dto.ReturnJsonDto {
+var dummyPasswordHash = until.HashPassword("")
+
+// 开启两步验证的管理员需同时提交验证码(或恢复码)，缺少时返回 Code 6
+// 登录成功后创建服务端会话，ua 和 ip 用于会话列表展示
+func AdminLogin(username, password, code string, reMe bool, ua, ip string) dto.ReturnJsonDto {
 	// 获取记住密码选项
 
 	var userDb models.IptvAdmin
 
-	err := dao.DB.Model(&models.IptvAdmin{}).Where("username = ? and password = ?", username, password).First(&userDb).Error
+	// 密码在程序中校验，账号不存在时同样计算一次哈希，避免通过耗时判断账号是否存在
+	err := dao.DB.Model(&models.IptvAdmin{}).Where("username = ?", username).First(&userDb).Error
 	if err != nil {
+		until.CheckPassword(dummyPasswordHash, password)
+		loginFailed(username, ip)
+		return dto.ReturnJsonDto{Code: 0, Msg: "用户名或密码错误", Type: "danger"}
+	}
+	ok, upgrade := until.CheckPassword(userDb.PassWord, password)
+	if !ok {
+		loginFailed(username, ip)
 		return dto.ReturnJsonDto{Code: 0, Msg: "用户名或密码错误", Type: "danger"}
 	}
+	if userDb.Status != 1 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "账号已禁用", Type: "danger"}
+	}
+	if userDb.Totp == 1 {
+		if code == "" {
+			return dto.ReturnJsonDto{Code: 6, Msg: "请输入两步验证码", Type: "warning"}
+		}
+		if !CheckSecondFactor(userDb, code) {
+			loginFailed(username, ip)
+			return dto.ReturnJsonDto{Code: 6, Msg: "两步验证码错误", Type: "danger"}
+		}
+	}
+	loginSucceeded(username, ip)
+	updates := map[string]interface{}{"last_login": time.Now().Unix()}
+	// 旧版 md5 哈希在登录成功后升级为 argon2id
+	if upgrade {
+		updates["password"] = until.HashPassword(password)
+		log.Printf("管理员 %s 密码哈希已升级\n", username)
+	}
+	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", userDb.ID).Updates(updates)
 
 	// 生成 JWT token
 	tTime := 2 * time.Hour
 	if reMe {
 		tTime = 7 * 24 * time.Hour
 	}
-	tokenString, err := until.GenerateJWT(username, tTime)
+	sid, err := CreateSession(userDb.ID, ua, ip, tTime)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "创建会话失败", Type: "danger"}
+	}
+	tokenString, err := until.GenerateJWT(username, userDb.Role, sid, tTime)
 	if err != nil {
 		return dto.ReturnJsonDto{Code: 0, Msg: "生成Token失败", Type: "danger"}
 	}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 最近使用时间的更新间隔，避免每个请求都写数据库
+const sessionTouchInterval = 60
+
+// 创建登录会话，返回写入 token 的会话ID
+func CreateSession(adminID int64, ua, ip string, duration time.Duration) (string, error) {
+	now := time.Now().Unix()
+	dao.DB.Where("expires_at < ?", now).Delete(&models.IptvAdminSession{})
+
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+	session := models.IptvAdminSession{
+		Sid:       until.RandomHex(16),
+		AdminID:   adminID,
+		UA:        ua,
+		IP:        ip,
+		CreatedAt: now,
+		LastUsed:  now,
+		ExpiresAt: now + int64(duration.Seconds()),
+	}
+	if err := dao.DB.Create(&session).Error; err != nil {
+		return "", err
+	}
+	return session.Sid, nil
+}
+
+// 校验会话是否有效，并记录最近使用时间和IP
+func CheckSession(sid, ip string) (models.IptvAdminSession, bool) {
+	var session models.IptvAdminSession
+	if sid == "" {
+		return session, false
+	}
+	now := time.Now().Unix()
+	if err := dao.DB.Where("sid = ? and expires_at >= ?", sid, now).First(&session).Error; err != nil {
+		return session, false
+	}
+	if now-session.LastUsed >= sessionTouchInterval || session.IP != ip {
+		dao.DB.Model(&models.IptvAdminSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+			"last_used": now,
+			"ip":        ip,
+		})
+	}
+	return session, true
+}
+
+// token 续期时同步延长会话有效期
+func ExtendSession(sid string, duration time.Duration) {
+	dao.DB.Model(&models.IptvAdminSession{}).Where("sid = ?", sid).Update("expires_at", time.Now().Add(duration).Unix())
+}
+
+func RevokeSession(sid string) {
+	if sid == "" {
+		return
+	}
+	dao.DB.Where("sid = ?", sid).Delete(&models.IptvAdminSession{})
+}
+
+// 吊销管理员的全部会话，用于禁用账号或重置密码
+func RevokeAdminSessions(adminIDs ...int64) {
+	if len(adminIDs) == 0 {
+		return
+	}
+	dao.DB.Where("admin_id in (?)", adminIDs).Delete(&models.IptvAdminSession{})
+}
+
+// 会话列表，所有者可查看全部管理员的会话
+func AdminSessions(loginUser, currentSid string, all bool) []dto.AdminSessionItem {
+	admin, ok := getAdmin(loginUser)
+	if !ok {
+		return nil
+	}
+	var admins []models.IptvAdmin
+	dao.DB.Model(&models.IptvAdmin{}).Find(&admins)
+	names := make(map[int64]string, len(admins))
+	for _, a := range admins {
+		names[a.ID] = a.UserName
+	}
+
+	var sessions []models.IptvAdminSession
+	db := dao.DB.Model(&models.IptvAdminSession{}).Where("expires_at >= ?", time.Now().Unix())
+	if !all {
+		db = db.Where("admin_id = ?", admin.ID)
+	}
+	db.Order("last_used desc").Find(&sessions)
+
+	list := make([]dto.AdminSessionItem, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, dto.AdminSessionItem{
+			ID:        s.ID,
+			UserName:  names[s.AdminID],
+			Device:    sessionDevice(s.UA),
+			UA:        s.UA,
+			IP:        s.IP,
+			CreatedAt: time.Unix(s.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+			LastUsed:  time.Unix(s.LastUsed, 0).Format("2006-01-02 15:04:05"),
+			ExpiresAt: time.Unix(s.ExpiresAt, 0).Format("2006-01-02 15:04:05"),
+			Current:   s.Sid == currentSid,
+		})
+	}
+	return list
+}
+
+// 吊销选中的会话，非所有者只能吊销自己的会话；others 为吊销当前会话以外的全部会话
+func RevokeSessions(params url.Values, loginUser, currentSid string, all, others bool) dto.ReturnJsonDto {
+	admin, ok := getAdmin(loginUser)
+	if !ok {
+		return dto.ReturnJsonDto{Code: 0, Msg: "管理员不存在", Type: "danger"}
+	}
+	db := dao.DB.Where("sid <> ?", currentSid)
+	if others {
+		db = db.Where("admin_id = ?", admin.ID)
+	} else {
+		ids := params["ids[]"]
+		if len(ids) == 0 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "请选择会话", Type: "danger"}
+		}
+		db = db.Where("id in (?)", ids)
+		if !all {
+			db = db.Where("admin_id = ?", admin.ID)
+		}
+	}
+	res := db.Delete(&models.IptvAdminSession{})
+	if res.Error != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "吊销失败: " + res.Error.Error(), Type: "danger"}
+	}
+	log.Printf("管理员 %s 吊销登录会话 %d 个\n", loginUser, res.RowsAffected)
+	return dto.ReturnJsonDto{Code: 1, Msg: fmt.Sprintf("已吊销 %d 个会话", res.RowsAffected), Type: "success"}
+}
+
+// 根据 UA 粗略识别浏览器和系统
+func sessionDevice(ua string) string {
+	browser, system := "未知浏览器", "未知系统"
+	for _, b := range []struct{ key, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"MicroMessenger", "微信"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"}, {"curl/", "curl"},
+	} {
+		if strings.Contains(ua, b.key) {
+			browser = b.name
+			break
+		}
+	}
+	for _, o := range []struct{ key, name string }{
+		{"Windows", "Windows"}, {"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.key) {
+			system = o.name
+			break
+		}
+	}
+	return browser + " / " + system
+}
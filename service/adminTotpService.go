@@ -0,0 +1,148 @@
+package service
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"net/url"
+	"strings"
+)
+
+const totpIssuer = "清和IPTV"
+
+func getAdmin(username string) (models.IptvAdmin, bool) {
+	var admin models.IptvAdmin
+	err := dao.DB.Model(&models.IptvAdmin{}).Where("username = ?", username).First(&admin).Error
+	return admin, err == nil
+}
+
+// 开始绑定两步验证，生成待验证的密钥
+func TotpSetup(loginUser string) dto.ReturnJsonDto {
+	admin, ok := getAdmin(loginUser)
+	if !ok {
+		return dto.ReturnJsonDto{Code: 0, Msg: "管理员不存在", Type: "danger"}
+	}
+	if admin.Totp == 1 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "已开启两步验证", Type: "danger"}
+	}
+	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", admin.ID).Update("totp_key", until.NewTotpKey())
+	return dto.ReturnJsonDto{Code: 1, Msg: "请使用验证器扫描二维码并输入验证码", Type: "success"}
+}
+
+// 二维码图片，未绑定时返回空
+func TotpQR(admin models.IptvAdmin) string {
+	if admin.TotpKey == "" || admin.Totp == 1 {
+		return ""
+	}
+	qr, err := until.QrDataURI(until.TotpURI(totpIssuer, admin.UserName, admin.TotpKey))
+	if err != nil {
+		log.Println("生成两步验证二维码失败:", err)
+		return ""
+	}
+	return qr
+}
+
+// 验证后开启两步验证，返回恢复码
+func TotpEnable(params url.Values, loginUser string) dto.ReturnJsonDto {
+	admin, ok := getAdmin(loginUser)
+	if !ok || admin.TotpKey == "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请先生成二维码", Type: "danger"}
+	}
+	if admin.Totp == 1 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "已开启两步验证", Type: "danger"}
+	}
+	if !checkTotp(admin, params.Get("code")) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "验证码错误，请检查手机时间是否准确", Type: "danger"}
+	}
+	codes, hashes := until.NewRecoveryCodes(10)
+	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", admin.ID).Updates(map[string]interface{}{
+		"totp":           1,
+		"recovery_codes": strings.Join(hashes, ","),
+	})
+	log.Printf("管理员 %s 已开启两步验证\n", loginUser)
+	return dto.ReturnJsonDto{Code: 3, Msg: "两步验证已开启，请保存恢复码", Type: "success", Data: map[string]interface{}{"codes": codes}}
+}
+
+// 关闭两步验证，需要验证码或恢复码
+func TotpDisable(params url.Values, loginUser string) dto.ReturnJsonDto {
+	admin, ok := getAdmin(loginUser)
+	if !ok {
+		return dto.ReturnJsonDto{Code: 0, Msg: "管理员不存在", Type: "danger"}
+	}
+	if admin.Totp == 1 && !CheckSecondFactor(admin, params.Get("code")) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "验证码错误", Type: "danger"}
+	}
+	ClearTotp(admin.ID)
+	log.Printf("管理员 %s 已关闭两步验证\n", loginUser)
+	return dto.ReturnJsonDto{Code: 1, Msg: "已关闭两步验证", Type: "success"}
+}
+
+// 重新生成恢复码，旧恢复码失效
+func TotpRecovery(params url.Values, loginUser string) dto.ReturnJsonDto {
+	admin, ok := getAdmin(loginUser)
+	if !ok || admin.Totp != 1 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "未开启两步验证", Type: "danger"}
+	}
+	if !checkTotp(admin, params.Get("code")) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "验证码错误", Type: "danger"}
+	}
+	codes, hashes := until.NewRecoveryCodes(10)
+	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", admin.ID).Update("recovery_codes", strings.Join(hashes, ","))
+	return dto.ReturnJsonDto{Code: 3, Msg: "已重新生成恢复码，请妥善保存", Type: "success", Data: map[string]interface{}{"codes": codes}}
+}
+
+func ClearTotp(id int64) {
+	dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp":           0,
+		"totp_key":       "",
+		"recovery_codes": "",
+	})
+}
+
+// 校验验证码并记录步长，同一步长只能使用一次
+func checkTotp(admin models.IptvAdmin, code string) bool {
+	step, ok := until.VerifyTotp(admin.TotpKey, code, admin.TotpStep)
+	if !ok {
+		return false
+	}
+	// 条件更新，并发提交同一验证码时只有一个成功
+	res := dao.DB.Model(&models.IptvAdmin{}).Where("id = ? and totp_step < ?", admin.ID, step).Update("totp_step", step)
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+// 校验第二因素，恢复码使用后作废
+func CheckSecondFactor(admin models.IptvAdmin, code string) bool {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false
+	}
+	if checkTotp(admin, code) {
+		return true
+	}
+	hash := until.RecoveryHash(code)
+	hashes := strings.Split(admin.Recovery, ",")
+	for i, h := range hashes {
+		if h == "" || h != hash {
+			continue
+		}
+		hashes = append(hashes[:i], hashes[i+1:]...)
+		// 条件更新，恢复码已被并发请求使用时不再通过
+		res := dao.DB.Model(&models.IptvAdmin{}).Where("id = ? and recovery_codes = ?", admin.ID, admin.Recovery).Update("recovery_codes", strings.Join(hashes, ","))
+		if res.Error != nil || res.RowsAffected != 1 {
+			return false
+		}
+		log.Printf("管理员 %s 使用恢复码登录，剩余 %d 个\n", admin.UserName, len(hashes))
+		return true
+	}
+	return false
+}
+
+// 剩余恢复码数量
+func RecoveryCount(admin models.IptvAdmin) int {
+	if admin.Recovery == "" {
+		return 0
+	}
+	return len(strings.Split(admin.Recovery, ","))
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 设备列表允许排序的字段
+var userOrderFields = map[string]bool{
+	"id": true, "name": true, "meal": true, "deviceid": true, "model": true, "ip": true, "region": true,
+	"lasttime": true, "exp": true, "status": true, "author": true, "marks": true, "account": true,
+}
+
+func parseFilterDate(s string) string {
+	if _, err := time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+		return ""
+	}
+	return s
+}
+
+// 从查询参数或表单解析筛选条件，非法值忽略
+func ParseUserFilter(values url.Values) dto.UserFilter {
+	f := dto.UserFilter{
+		Keywords: values.Get("keywords"),
+		Status:   values.Get("status"),
+		ExpFrom:  parseFilterDate(values.Get("expfrom")),
+		ExpTo:    parseFilterDate(values.Get("expto")),
+		Region:   values.Get("region"),
+		LastFrom: parseFilterDate(values.Get("lastfrom")),
+		LastTo:   parseFilterDate(values.Get("lastto")),
+		Model:    values.Get("model"),
+		Mark:     values.Get("mark"),
+		Order:    values.Get("order"),
+		Desc:     values.Get("desc") == "1",
+	}
+	f.Meal, _ = strconv.ParseInt(values.Get("meal"), 10, 64)
+	for _, v := range []*string{&f.Keywords, &f.Region, &f.Model, &f.Mark} {
+		if !until.IsSafe(*v) {
+			*v = ""
+		}
+	}
+	switch f.Status {
+	case "all", "days", "forever", "expired", "forbidden", "unauth":
+	default:
+		f.Status = ""
+	}
+	if !userOrderFields[f.Order] {
+		f.Order = "id"
+	}
+	return f
+}
+
+// 筛选条件字段，批量操作表单中会回传，不是操作名
+var UserFilterKeys = []string{"keywords", "status", "meal", "expfrom", "expto", "region", "lastfrom", "lastto", "model", "mark", "order", "desc"}
+
+// 筛选条件转查询串，只保留非空项
+func UserFilterValues(f dto.UserFilter) url.Values {
+	v := url.Values{}
+	set := func(k, val string) {
+		if val != "" {
+			v.Set(k, val)
+		}
+	}
+	set("keywords", f.Keywords)
+	set("status", f.Status)
+	if f.Meal > 0 {
+		v.Set("meal", strconv.FormatInt(f.Meal, 10))
+	}
+	set("expfrom", f.ExpFrom)
+	set("expto", f.ExpTo)
+	set("region", f.Region)
+	set("lastfrom", f.LastFrom)
+	set("lastto", f.LastTo)
+	set("model", f.Model)
+	set("mark", f.Mark)
+	if f.Order != "id" {
+		v.Set("order", f.Order)
+	}
+	if f.Desc {
+		v.Set("desc", "1")
+	}
+	return v
+}
+
+func dayStart(s string) int64 {
+	t, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+	return t.Unix()
+}
+
+// 设备查询，表别名为 u
+func UsersQuery(f dto.UserFilter) *gorm.DB {
+	db := dao.DB.Table(models.IptvUser{}.TableName() + " u")
+	now := time.Now().Unix()
+
+	switch f.Status {
+	case "":
+		db = db.Where("u.status > ?", 0)
+	case "days":
+		db = db.Where("u.status = 1 and u.exp > ?", now)
+	case "forever":
+		db = db.Where("u.status = 999")
+	case "expired":
+		db = db.Where("u.status = 2 or (u.status = 1 and u.exp <= ?)", now)
+	case "forbidden":
+		db = db.Where("u.status = 0")
+	case "unauth":
+		db = db.Where("u.status = -1")
+	}
+	if f.Meal > 0 {
+		db = db.Where("u.meal = ?", f.Meal)
+	}
+	if f.ExpFrom != "" {
+		db = db.Where("u.status <> 999 and u.exp >= ?", dayStart(f.ExpFrom))
+	}
+	if f.ExpTo != "" {
+		db = db.Where("u.status <> 999 and u.exp > 0 and u.exp < ?", dayStart(f.ExpTo)+86400)
+	}
+	if f.LastFrom != "" {
+		db = db.Where("u.lasttime >= ?", dayStart(f.LastFrom))
+	}
+	if f.LastTo != "" {
+		db = db.Where("u.lasttime < ?", dayStart(f.LastTo)+86400)
+	}
+	if f.Region != "" {
+		db = db.Where("u.region LIKE ?", "%"+f.Region+"%")
+	}
+	if f.Model != "" {
+		db = db.Where("u.model LIKE ?", "%"+f.Model+"%")
+	}
+	if f.Mark != "" {
+		db = db.Where("u.marks LIKE ?", "%"+f.Mark+"%")
+	}
+	if f.Keywords != "" {
+		keywords := "%" + f.Keywords + "%"
+		db = db.Where(
+			"u.name LIKE ? OR u.deviceid LIKE ? OR u.mac LIKE ? OR u.model LIKE ? OR u.ip LIKE ? OR u.region LIKE ? OR u.author LIKE ? OR u.marks LIKE ? OR CAST(u.status AS CHAR) LIKE ?",
+			keywords, keywords, keywords, keywords, keywords, keywords, keywords, keywords, keywords,
+		)
+	}
+	return db
+}
+
+func UserOrder(f dto.UserFilter) string {
+	if f.Desc {
+		return "u." + f.Order + " desc"
+	}
+	return "u." + f.Order
+}
+
+// 分页查询设备列表
+func QueryUsers(f dto.UserFilter, page, recCounts int64) ([]models.IptvUserShow, int64, error) {
+	var total int64
+	var users []models.IptvUserShow
+	if err := UsersQuery(f).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := UsersQuery(f).Select(`u.*, m.name AS mealname, a.name AS accountname`).
+		Joins("LEFT JOIN iptv_meals m ON u.meal = m.id").
+		Joins("LEFT JOIN iptv_accounts a ON u.account = a.id").
+		Order(UserOrder(f)).Offset(int(recCounts * (page - 1))).Limit(int(recCounts)).
+		Find(&users).Error
+	return until.CheckUserDay(users), total, err
+}
+
+// 批量操作的设备账号，applyall=1 时为当前筛选结果的全部设备
+func BulkUserNames(params url.Values) []string {
+	if params.Get("applyall") != "1" {
+		return params["ids[]"]
+	}
+	var names []int64
+	UsersQuery(ParseUserFilter(params)).Pluck("u.name", &names)
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		res = append(res, strconv.FormatInt(n, 10))
+	}
+	return res
+}
+
+var userCsvHeader = []string{"mac", "deviceid", "name", "meal", "exp", "status", "marks", "model"}
+
+// 导出筛选结果，exp 为到期日期，永久授权为空
+func ExportUsers(f dto.UserFilter) ([]byte, error) {
+	var users []models.IptvUser
+	if err := UsersQuery(f).Select("u.*").Order(UserOrder(f)).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	mealNames := make(map[int64]string)
+	var meals []models.IptvMeals
+	dao.DB.Model(&models.IptvMeals{}).Find(&meals)
+	for _, m := range meals {
+		mealNames[m.ID] = m.Name
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(&buf)
+	w.Write(userCsvHeader)
+	for _, u := range users {
+		exp := ""
+		if u.Status != 999 && u.Exp > 0 {
+			exp = time.Unix(u.Exp, 0).Format("2006-01-02")
+		}
+		w.Write([]string{
+			until.CsvCell(u.Mac),
+			until.CsvCell(u.DeviceID),
+			strconv.FormatInt(u.Name, 10),
+			until.CsvCell(mealNames[u.Meal]),
+			exp,
+			strconv.FormatInt(u.Status, 10),
+			until.CsvCell(u.Marks),
+			until.CsvCell(u.Model),
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// 导入设备CSV，按MAC（为空时按设备ID）匹配已有设备，存在则更新套餐、到期时间、状态和备注
+func ImportUsers(c *gin.Context, username string) dto.ReturnJsonDto {
+	file, err := c.FormFile("csvfile")
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "获取文件失败:" + err.Error(), Type: "danger"}
+	}
+	f, err := file.Open()
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "打开文件失败:" + err.Error(), Type: "danger"}
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, 20<<20))
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "读取文件失败:" + err.Error(), Type: "danger"}
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil || len(rows) < 2 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "CSV格式错误或没有数据", Type: "danger"}
+	}
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := col["mac"]; !ok {
+		if _, ok := col["deviceid"]; !ok {
+			return dto.ReturnJsonDto{Code: 0, Msg: "CSV缺少 mac 或 deviceid 列", Type: "danger"}
+		}
+	}
+
+	mealIds := map[string]int64{}
+	var meals []models.IptvMeals
+	dao.DB.Model(&models.IptvMeals{}).Find(&meals)
+	for _, m := range meals {
+		mealIds[m.Name] = m.ID
+		mealIds[strconv.FormatInt(m.ID, 10)] = m.ID
+	}
+
+	var res dto.UserImportRes
+	fail := func(line int, msg string) {
+		res.Skipped++
+		if len(res.Errors) < 20 {
+			res.Errors = append(res.Errors, fmt.Sprintf("第%d行: %s", line, msg))
+		}
+	}
+	var names []string
+	now := time.Now().Unix()
+	for i, row := range rows[1:] {
+		line := i + 2
+		get := func(k string) string {
+			if idx, ok := col[k]; ok && idx < len(row) {
+				return until.CsvUncell(strings.TrimSpace(row[idx]))
+			}
+			return ""
+		}
+		mac, deviceId, marks := get("mac"), get("deviceid"), get("marks")
+		if mac == "" && deviceId == "" {
+			fail(line, "mac和deviceid不能同时为空")
+			continue
+		}
+		if !until.IsSafe(mac) || !until.IsSafe(deviceId) || !until.IsSafe(marks) {
+			fail(line, "包含非法字符")
+			continue
+		}
+
+		meal := newDeviceMeal()
+		if v := get("meal"); v != "" {
+			id, ok := mealIds[v]
+			if !ok {
+				fail(line, "套餐不存在: "+v)
+				continue
+			}
+			meal = id
+		}
+
+		var exp int64
+		status := int64(999)
+		if v := get("exp"); v != "" {
+			t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+			if err != nil {
+				fail(line, "到期日期格式错误，应为 2006-01-02")
+				continue
+			}
+			exp = t.Unix() + 86399
+			status = 1
+		}
+		if v := get("status"); v != "" {
+			s, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || (s != -1 && s != 0 && s != 1 && s != 2 && s != 999) {
+				fail(line, "状态只能为 -1、0、1、2、999")
+				continue
+			}
+			status = s
+		}
+
+		var user models.IptvUser
+		q := dao.DB.Model(&models.IptvUser{})
+		if mac != "" {
+			q = q.Where("mac = ?", mac)
+		} else {
+			q = q.Where("deviceid = ?", deviceId)
+		}
+		if q.First(&user).Error == nil {
+			updates := map[string]interface{}{"meal": meal, "exp": exp, "status": status}
+			if marks != "" {
+				updates["marks"] = marks
+			}
+			if deviceId != "" {
+				updates["deviceid"] = deviceId
+			}
+			dao.DB.Model(&models.IptvUser{}).Where("id = ?", user.ID).Updates(updates)
+			names = append(names, strconv.FormatInt(user.Name, 10))
+			res.Updated++
+			continue
+		}
+
+		user = models.IptvUser{
+			Name:       int64(genName()),
+			Mac:        mac,
+			DeviceID:   deviceId,
+			Model:      get("model"),
+			Meal:       meal,
+			Exp:        exp,
+			Status:     status,
+			Marks:      marks,
+			Author:     username,
+			AuthorTime: now,
+		}
+		if err := dao.DB.Create(&user).Error; err != nil {
+			fail(line, err.Error())
+			continue
+		}
+		res.Created++
+	}
+	if len(names) > 0 {
+		clearDevicesCache(names)
+	}
+
+	msg := fmt.Sprintf("导入完成：新增 %d，更新 %d，跳过 %d", res.Created, res.Updated, res.Skipped)
+	if len(res.Errors) > 0 {
+		msg += "；" + strings.Join(res.Errors, "；")
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: msg, Type: "success", Data: res}
+}
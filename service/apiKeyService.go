@@ -0,0 +1,189 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 接口权限范围，write 包含同类的 read
+const (
+	ScopeChannelsRead  = "channels:read"
+	ScopeChannelsWrite = "channels:write"
+	ScopeMealsRead     = "meals:read"
+	ScopeMealsWrite    = "meals:write"
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeEpgRead       = "epg:read"
+	ScopeEpgWrite      = "epg:write"
+)
+
+var ApiScopes = []dto.ApiScope{
+	{Key: ScopeChannelsRead, Name: "读取直播源/分类/频道"},
+	{Key: ScopeChannelsWrite, Name: "管理直播源/分类/频道"},
+	{Key: ScopeMealsRead, Name: "读取套餐/订阅Token"},
+	{Key: ScopeMealsWrite, Name: "管理套餐/订阅Token"},
+	{Key: ScopeUsersRead, Name: "读取设备"},
+	{Key: ScopeUsersWrite, Name: "设备授权/禁用/删除"},
+	{Key: ScopeEpgRead, Name: "读取EPG源"},
+	{Key: ScopeEpgWrite, Name: "管理EPG源"},
+}
+
+const apiKeyPrefix = "qh_"
+
+func apiScopeName(scope string) string {
+	for _, s := range ApiScopes {
+		if s.Key == scope {
+			return s.Name
+		}
+	}
+	return scope
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// 创建接口密钥，明文密钥只在创建时返回一次
+func CreateApiKey(params url.Values, loginUser string) dto.ReturnJsonDto {
+	name := strings.TrimSpace(params.Get("name"))
+	if name == "" || !until.IsSafe(name) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请输入合法的名称", Type: "danger"}
+	}
+	var scopes []string
+	for _, s := range params["scopes[]"] {
+		if apiScopeName(s) == s {
+			return dto.ReturnJsonDto{Code: 0, Msg: "权限范围错误: " + s, Type: "danger"}
+		}
+		scopes = append(scopes, s)
+	}
+	if len(scopes) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择权限范围", Type: "danger"}
+	}
+	var expiresAt int64
+	if v := params.Get("expire_days"); v != "" && v != "0" {
+		days, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || days < 0 || days > 3650 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "有效天数必须为 0-3650", Type: "danger"}
+		}
+		expiresAt = time.Now().AddDate(0, 0, int(days)).Unix()
+	}
+
+	key := apiKeyPrefix + until.RandomHex(24)
+	rec := models.IptvApiKey{
+		Name:      name,
+		Prefix:    key[:len(apiKeyPrefix)+8],
+		KeyHash:   hashApiKey(key),
+		Scopes:    strings.Join(scopes, ","),
+		CreatedBy: loginUser,
+		CreatedAt: time.Now().Unix(),
+		ExpiresAt: expiresAt,
+		Status:    1,
+	}
+	if err := dao.DB.Create(&rec).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "创建失败: " + err.Error(), Type: "danger"}
+	}
+	log.Printf("管理员 %s 创建接口密钥 %s(%s)\n", loginUser, rec.Name, rec.Prefix)
+	return dto.ReturnJsonDto{Code: 3, Msg: "创建成功，请保存密钥", Type: "success", Data: map[string]interface{}{"key": key}}
+}
+
+// 吊销选中的密钥，吊销后立即失效
+func RevokeApiKeys(params url.Values, loginUser string) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择密钥", Type: "danger"}
+	}
+	res := dao.DB.Model(&models.IptvApiKey{}).Where("id in (?) and status = 1", ids).Update("status", 0)
+	if res.Error != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "吊销失败: " + res.Error.Error(), Type: "danger"}
+	}
+	log.Printf("管理员 %s 吊销接口密钥 %d 个\n", loginUser, res.RowsAffected)
+	return dto.ReturnJsonDto{Code: 1, Msg: "已吊销 " + strconv.FormatInt(res.RowsAffected, 10) + " 个密钥", Type: "success"}
+}
+
+// 删除已吊销或已过期的密钥
+func DelApiKeys(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择密钥", Type: "danger"}
+	}
+	now := time.Now().Unix()
+	res := dao.DB.Where("id in (?) and (status = 0 or (expires_at > 0 and expires_at < ?))", ids, now).Delete(&models.IptvApiKey{})
+	if res.Error != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败: " + res.Error.Error(), Type: "danger"}
+	}
+	if res.RowsAffected == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "只能删除已吊销或已过期的密钥", Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "已删除 " + strconv.FormatInt(res.RowsAffected, 10) + " 个密钥", Type: "success"}
+}
+
+func ApiKeys() []dto.ApiKeyItem {
+	var keys []models.IptvApiKey
+	dao.DB.Model(&models.IptvApiKey{}).Order("status desc, id desc").Find(&keys)
+
+	now := time.Now().Unix()
+	list := make([]dto.ApiKeyItem, 0, len(keys))
+	for _, k := range keys {
+		item := dto.ApiKeyItem{
+			IptvApiKey:  k,
+			CreatedStr:  time.Unix(k.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+			LastUsedStr: "未使用",
+			ExpiresStr:  "永不过期",
+			Expired:     k.ExpiresAt > 0 && k.ExpiresAt < now,
+		}
+		if k.LastUsed > 0 {
+			item.LastUsedStr = time.Unix(k.LastUsed, 0).Format("2006-01-02 15:04:05")
+		}
+		if k.ExpiresAt > 0 {
+			item.ExpiresStr = time.Unix(k.ExpiresAt, 0).Format("2006-01-02 15:04:05")
+		}
+		for _, s := range strings.Split(k.Scopes, ",") {
+			item.ScopeNames = append(item.ScopeNames, apiScopeName(s))
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+// 校验接口密钥，并记录最近使用时间和IP
+func CheckApiKey(key, ip string) (models.IptvApiKey, bool) {
+	var rec models.IptvApiKey
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return rec, false
+	}
+	now := time.Now().Unix()
+	if err := dao.DB.Where("key_hash = ? and status = 1", hashApiKey(key)).First(&rec).Error; err != nil {
+		return rec, false
+	}
+	if rec.ExpiresAt > 0 && rec.ExpiresAt < now {
+		return rec, false
+	}
+	if now-rec.LastUsed >= sessionTouchInterval || rec.LastIP != ip {
+		dao.DB.Model(&models.IptvApiKey{}).Where("id = ?", rec.ID).Updates(map[string]interface{}{
+			"last_used": now,
+			"last_ip":   ip,
+		})
+	}
+	return rec, true
+}
+
+// 检查密钥是否有对应权限，write 权限包含 read
+func ApiKeyHasScope(rec models.IptvApiKey, scope string) bool {
+	write := strings.TrimSuffix(scope, ":read") + ":write"
+	for _, s := range strings.Split(rec.Scopes, ",") {
+		if s == scope || s == write {
+			return true
+		}
+	}
+	return false
+}
@@ -50,7 +50,7 @@ func GetBg() string {
 	return pngs[randomIndex]
 }
 
-func ApkLogin(user models.IptvUser) dto.LoginRes {
+func ApkLogin(user models.IptvUser, client dto.AccessClient) dto.LoginRes {
 
 	var result dto.LoginRes
 
@@ -79,32 +79,29 @@ func ApkLogin(user models.IptvUser) dto.LoginRes {
 	result.AdInfo = "客服QQ：2492277473"
 	result.RandKey = until.Md5(time.Now().Format("20060102150405") + strconv.FormatInt(user.Name, 10))
 
-	return getUserInfo(user, result)
+	return getUserInfo(user, result, client)
 }
 
-func GetChannels(channel dto.DataReqDto) string {
+func GetChannels(channel dto.DataReqDto, client dto.AccessClient) string {
 	resList := []dto.ChannelListDto{{
 		Name: "我的收藏",
 		Data: []dto.ChannelData{},
 		Tmp:  "6L+Z5Y+q5piv5Y2g5L2N77yM5LiN54S25rKh6aKR6YGT5a655piT5Ye6546w6ZSZ6K+v77yM5LirYXBr5Yqg5a+G5pWw5o2u6ZyA6KaB5YWI5YigMTI45a2X6IqC77yM5rKh6aKR6YGT5bCx5LiN5aSfMTI4",
 	}}
 
-	var dbUser models.IptvUser
-	err := dao.DB.Where("mac = ?", channel.Mac).First(&dbUser).Error
-	if err != nil {
-
-		resList = append(resList, dto.ChannelListDto{})
-	}
+	e := CheckMacEntitlement(channel.Mac, "apk")
+	RecordAccess(e, client)
+	if !e.Allowed() {
+		resList = append(resList, dto.ChannelListDto{
+			Name: "当前账号" + e.ReasonText(),
+		})
 
-	now := time.Now()
-	todayZero := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	userExp := int64(until.DiffDays(todayZero.Unix(), dbUser.Exp))
-	if userExp <= 0 {
-		resList = append(resList, dto.ChannelListDto{})
+		jsonData, _ := json.Marshal(resList)
+		jsonStr := until.DecodeUnicode(string(jsonData))
+		return encrypt(jsonStr, channel.Rand)
 	}
 
-	var meal models.IptvMeals
-	dao.DB.Model(&models.IptvMeals{}).Where("status = ? and id = ?", 1, dbUser.Meal).First(&meal)
+	meal := e.Meal
 	cList := strings.Split(meal.Content, ",")
 
 	var channelList []models.IptvChannel
@@ -236,18 +233,32 @@ func CompressString(input string) (string, error) {
 	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
 }
 
-func getUserInfo(user models.IptvUser, result dto.LoginRes) dto.LoginRes {
-	var cfg = dao.GetConfig()
-
-	// 检查是否过期（除了 status = 999 永不到期的情况）
-	if user.Status != 999 && user.Exp > 0 {
-		now := time.Now()
-		if now.Unix() > user.Exp {
-			// 已过期，设置 status 为 -1（未授权）
-			user.Status = -1
-		} else {
+func getUserInfo(user models.IptvUser, result dto.LoginRes, client dto.AccessClient) dto.LoginRes {
+	e := CheckUserEntitlement(user, "apk")
+	RecordAccess(e, client)
+	switch e.Reason {
+	case DenyExpired:
+		result.Status = -1 // 已过期按未授权处理
+	case DenyUnregistered:
+		if user.Status == -1 && user.Exp > 0 {
+			result.TipUserNoReg = "当前账号 " + strconv.FormatInt(user.Name, 10) + " 试用已结束，" + dao.GetConfig().Tips.UserNoReg
+		}
+	case "":
+		if e.Account.ID > 0 {
+			result.Status = 999
+			if e.Account.Exp > 0 {
+				result.Status = 1
+				result.Exp = until.CalcRemainDays(e.Account.Exp)
+			}
+		} else if user.Status != 999 && user.Exp > 0 {
 			result.Exp = until.CalcRemainDays(user.Exp)
 		}
+		if e.Account.ID == 0 && IsTrialUser(user) {
+			result.Status = 1 // 试用期内按授权处理
+			result.TipLoading = "试用剩余" + strconv.FormatInt(result.Exp, 10) + "天，" + result.TipLoading
+		} else if tip := expiringTip(result); tip != "" {
+			result.TipLoading = tip + "，" + result.TipLoading
+		}
 	}
 
 	var movie []models.IptvMovie
@@ -255,58 +266,44 @@ func getUserInfo(user models.IptvUser, result dto.LoginRes) dto.LoginRes {
 
 	result.MovieEngine.Model = movie
 
-	if cfg.App.NeedAuthor == 0 {
-		result = getMealName(user, result)
+	if e.Allowed() {
+		result = getMealName(e.Meal, result)
 		log.Printf("用户: %d 登录成功,IP: %s 设备ID: %s 套餐: %s \n", result.ID, result.IP, user.DeviceID, result.MealName)
-	} else if cfg.App.NeedAuthor == 1 && user.Status != -1 {
-		result = getMealName(user, result)
-		log.Printf("用户: %d 登录成功,IP: %s 设备ID: %s 套餐: %s\n", result.ID, result.IP, user.DeviceID, result.MealName)
 	} else {
-		log.Printf("用户: %d 登录成功,IP: %s 设备ID: %s 未授权 \n", result.ID, result.IP, user.DeviceID)
+		log.Printf("用户: %d 登录成功,IP: %s 设备ID: %s %s \n", result.ID, result.IP, user.DeviceID, e.ReasonText())
 	}
 
 	return result
 }
 
-func getMealName(user models.IptvUser, result dto.LoginRes) dto.LoginRes {
-	var meals []models.IptvMeals
+// 到期前N天的即将到期提示，result.Exp 为剩余天数
+func expiringTip(result dto.LoginRes) string {
+	cfg := dao.GetConfig()
+	if result.Status != 1 || result.Exp <= 0 || cfg.Expiry.RemindDays <= 0 || result.Exp > cfg.Expiry.RemindDays {
+		return ""
+	}
+	tip := cfg.Tips.UserExpiring
+	if tip == "" {
+		tip = "账号将在{days}天后到期，请及时续费"
+	}
+	return strings.ReplaceAll(tip, "{days}", strconv.FormatInt(result.Exp, 10))
+}
+
+func getMealName(meal models.IptvMeals, result dto.LoginRes) dto.LoginRes {
 	var caList []models.IptvCategory
-	dao.DB.Model(&models.IptvMeals{}).Where("status = ?", 1).Find(&meals)
 	dao.DB.Model(&models.IptvCategory{}).Where("enable = ?", 1).Find(&caList)
 
-	for _, v := range meals {
-		if v.ID == 1000 && result.MealName == "" {
-			result.MealName = v.Name
-			for _, v1 := range strings.Split(v.Content, ",") {
-				v1Int64, err := strconv.ParseInt(v1, 10, 64)
-				if err != nil {
-					continue
-				}
-				for _, v2 := range caList {
-					if v2.ID == v1Int64 {
-						result.ProvList = append(result.ProvList, v2.Name)
-					}
-
-				}
-			}
-
+	result.MealName = meal.Name
+	for _, v1 := range strings.Split(meal.Content, ",") {
+		v1Int64, err := strconv.ParseInt(v1, 10, 64)
+		if err != nil {
+			continue
 		}
-		if v.ID == user.Meal {
-			result.MealName = v.Name
-			for _, v1 := range strings.Split(v.Content, ",") {
-				v1Int64, err := strconv.ParseInt(v1, 10, 64)
-				if err != nil {
-					continue
-				}
-				for _, v2 := range caList {
-					if v2.ID == v1Int64 {
-						result.ProvList = append(result.ProvList, v2.Name)
-					}
-
-				}
+		for _, v2 := range caList {
+			if v2.ID == v1Int64 {
+				result.ProvList = append(result.ProvList, v2.Name)
 			}
 		}
-
 	}
 	return result
 }
@@ -315,16 +312,25 @@ func CheckUserDb(user dto.ApkUser, ip string) models.IptvUser {
 	var dbUser models.IptvUser
 	res := dao.DB.Where("mac = ?", user.Mac).Find(&dbUser)
 	if res.RowsAffected == 0 {
-		return AddUser(user, ip)
+		dbUser = AddUser(user, ip)
+		DetectDevice(&dbUser, false, "apk")
+		return dbUser
+	}
+
+	// 同一MAC下设备ID变更
+	idChanged := dbUser.DeviceID != "" && user.DeviceID != "" && dbUser.DeviceID != user.DeviceID
+	if idChanged {
+		log.Printf("用户: %d MAC: %s 设备ID变更 %s -> %s\n", dbUser.Name, dbUser.Mac, dbUser.DeviceID, user.DeviceID)
 	}
 
 	dbUser.LastTime = time.Now().Unix()
 	dbUser.IP = ip
-	dbUser.Region = until.GetIpRegion(user.IP)
+	dbUser.Region = until.GetIpRegion(ip)
 	dbUser.NetType = user.NetType
 	dbUser.DeviceID = user.DeviceID
 
 	dao.DB.Model(&models.IptvUser{}).Where("mac = ?", user.Mac).Updates(dbUser)
+	DetectDevice(&dbUser, idChanged, "apk")
 
 	return dbUser
 }
@@ -332,7 +338,6 @@ func CheckUserDb(user dto.ApkUser, ip string) models.IptvUser {
 func AddUser(user dto.ApkUser, ip string) models.IptvUser {
 	user.IP = ip
 	user.Region = until.GetIpRegion(ip)
-	var cfg = dao.GetConfig()
 
 	dbData := models.IptvUser{
 		Name:     int64(genName()),
@@ -342,21 +347,14 @@ func AddUser(user dto.ApkUser, ip string) models.IptvUser {
 		IP:       user.IP,
 		Region:   user.Region,
 		LastTime: time.Now().Unix(),
-		Meal:     1000,
-	}
-
-	switch cfg.App.NeedAuthor {
-	case 0:
-		dbData.Status = 999
-		dbData.Marks = "自动授权"
-		dbData.Exp = 0
-	case 1:
-		dbData.Status = -1
-		dbData.Marks = "未授权"
-		dbData.Exp = 0
 	}
+	trial := initNewUser(&dbData)
 
 	dao.DB.Model(&models.IptvUser{}).Create(&dbData)
+	if trial {
+		recordTrial(dbData, "apk")
+	}
+	notifyNewDevice(dbData, "apk", trial)
 	return dbData
 }
 
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// 记录修改前后数据的路由，key 为操作对象对应的字段
+var auditTables = map[string]struct {
+	model interface{}
+	key   string
+}{
+	"/users":                         {&models.IptvUser{}, "name"},
+	"/authors":                       {&models.IptvUser{}, "name"},
+	"/accountDevices":                {&models.IptvUser{}, "name"},
+	"/accounts":                      {&models.IptvAccount{}, "id"},
+	"/actCodes":                      {&models.IptvActCode{}, "id"},
+	"/ipPolicies":                    {&models.IptvIPPolicy{}, "id"},
+	"/meals":                         {&models.IptvMeals{}, "id"},
+	"/meals/tokens/:token_id/status": {&models.IptvMealToken{}, "id"},
+	"/meals/tokens/:token_id/extend": {&models.IptvMealToken{}, "id"},
+	"/meals/tokens/:token_id":        {&models.IptvMealToken{}, "id"},
+	"/epgsList":                      {&models.IptvEpg{}, "id"},
+	"/epgAlias":                      {&models.IptvEpgAlias{}, "id"},
+	"/epgCustom":                     {&models.IptvEpgCustom{}, "id"},
+	"/admins/manage":                 {&models.IptvAdmin{}, "id"},
+	"/apiKeys":                       {&models.IptvApiKey{}, "id"},
+	"/webhooks":                      {&models.IptvWebhook{}, "id"},
+	"/api/v1/lists/:id":              {&models.IptvCategoryList{}, "id"},
+	"/api/v1/categories/:id":         {&models.IptvCategory{}, "id"},
+	"/api/v1/channels/:id":           {&models.IptvChannel{}, "id"},
+	"/api/v1/meals/:id":              {&models.IptvMeals{}, "id"},
+	"/api/v1/tokens/:id":             {&models.IptvMealToken{}, "id"},
+	"/api/v1/tokens/:id/extend":      {&models.IptvMealToken{}, "id"},
+	"/api/v1/users/:name":            {&models.IptvUser{}, "name"},
+	"/api/v1/users/authorize":        {&models.IptvUser{}, "name"},
+	"/api/v1/users/forbid":           {&models.IptvUser{}, "name"},
+	"/api/v1/epg-sources/:id":        {&models.IptvEpgList{}, "id"},
+}
+
+const (
+	auditMaxRows    = 50   // 每次最多对比的记录数
+	auditMaxValue   = 80   // 单个值最大长度
+	auditMaxSummary = 2000 // 参数和差异最大长度
+)
+
+// 字段名包含以下内容时不记录具体值
+func auditSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"pass", "secret", "totp", "recovery", "token"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "key")
+}
+
+func auditCut(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
+
+// 提交参数摘要，忽略操作名本身并隐藏密码等字段
+func AuditParams(params url.Values, action string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != action {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		v := strings.Join(params[k], ",")
+		if auditSensitive(k) || k == "code" {
+			v = "***"
+		}
+		parts = append(parts, k+"="+auditCut(v, auditMaxValue))
+	}
+	return auditCut(strings.Join(parts, " "), auditMaxSummary)
+}
+
+// 操作前后的记录快照，key 为操作对象
+type AuditRows map[string]map[string]interface{}
+
+func AuditSnapshot(path string, targets []string) AuditRows {
+	t, ok := auditTables[path]
+	if !ok || len(targets) == 0 {
+		return nil
+	}
+	if len(targets) > auditMaxRows {
+		targets = targets[:auditMaxRows]
+	}
+	var rows []map[string]interface{}
+	if err := dao.DB.Model(t.model).Where(t.key+" in (?)", targets).Find(&rows).Error; err != nil {
+		return nil
+	}
+	snap := make(AuditRows, len(rows))
+	for _, row := range rows {
+		snap[fmt.Sprint(row[t.key])] = row
+	}
+	return snap
+}
+
+// 对比前后快照，返回 "#对象 字段: 旧值 → 新值" 形式的差异
+func AuditRowsDiff(before, after AuditRows) []string {
+	keys := make([]string, 0, len(before)+len(after))
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, k := range keys {
+		b, hasB := before[k]
+		a, hasA := after[k]
+		switch {
+		case !hasA:
+			lines = append(lines, "#"+k+" 已删除")
+		case !hasB:
+			lines = append(lines, "#"+k+" 新增")
+		default:
+			var changes []string
+			for _, f := range sortedKeys(a) {
+				if fmt.Sprint(b[f]) == fmt.Sprint(a[f]) {
+					continue
+				}
+				if auditSensitive(f) {
+					changes = append(changes, f+": 已修改")
+				} else {
+					changes = append(changes, f+": "+auditCut(fmt.Sprint(b[f]), auditMaxValue)+" → "+auditCut(fmt.Sprint(a[f]), auditMaxValue))
+				}
+			}
+			if len(changes) > 0 {
+				lines = append(lines, "#"+k+" "+strings.Join(changes, "，"))
+			}
+		}
+	}
+	return lines
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 配置快照，展开为 "section.key" => 值
+func AuditConfigSnapshot() map[string]string {
+	data, err := yaml.Marshal(dao.GetConfig())
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if yaml.Unmarshal(data, &m) != nil {
+		return nil
+	}
+	flat := make(map[string]string)
+	flattenConfig("", m, flat)
+	return flat
+}
+
+func flattenConfig(prefix string, m map[string]interface{}, flat map[string]string) {
+	for k, v := range m {
+		if sub, ok := v.(map[string]interface{}); ok {
+			flattenConfig(prefix+k+".", sub, flat)
+			continue
+		}
+		flat[prefix+k] = fmt.Sprint(v)
+	}
+}
+
+func AuditConfigDiff(before, after map[string]string) []string {
+	if before == nil || after == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(after))
+	for k := range after {
+		if before[k] != after[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if auditSensitive(k) {
+			lines = append(lines, "配置 "+k+": 已修改")
+			continue
+		}
+		lines = append(lines, "配置 "+k+": "+auditCut(before[k], auditMaxValue)+" → "+auditCut(after[k], auditMaxValue))
+	}
+	return lines
+}
+
+func RecordAudit(rec models.IptvAuditLog, changes []string) {
+	rec.Changes = auditCut(strings.Join(changes, "\n"), auditMaxSummary)
+	rec.Target = auditCut(rec.Target, 200)
+	rec.Result = auditCut(rec.Result, 200)
+	rec.CreatedAt = time.Now().Unix()
+	if err := dao.DB.Create(&rec).Error; err != nil {
+		log.Println("写入操作日志失败:", err)
+	}
+}
+
+// 操作日志查询，keywords 匹配路由、操作、对象、参数和差异
+func AuditLogsQuery(admin, keywords, result string) *gorm.DB {
+	db := dao.DB.Model(&models.IptvAuditLog{})
+	if admin != "" {
+		db = db.Where("admin = ?", admin)
+	}
+	if keywords != "" {
+		like := "%" + keywords + "%"
+		db = db.Where("path LIKE ? OR action LIKE ? OR target LIKE ? OR params LIKE ? OR changes LIKE ? OR result LIKE ?", like, like, like, like, like, like)
+	}
+	switch result {
+	case "ok":
+		db = db.Where("code >= 1")
+	case "fail":
+		db = db.Where("code < 1")
+	}
+	return db
+}
+
+func ExportAuditLogs(admin, keywords, result string) ([]byte, error) {
+	var list []models.IptvAuditLog
+	if err := AuditLogsQuery(admin, keywords, result).Order("id desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"time", "admin", "role", "ip", "method", "path", "action", "target", "params", "changes", "code", "result"})
+	for _, v := range list {
+		w.Write([]string{
+			time.Unix(v.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+			v.Admin,
+			v.Role,
+			v.IP,
+			v.Method,
+			v.Path,
+			until.CsvCell(v.Action),
+			until.CsvCell(v.Target),
+			until.CsvCell(v.Params),
+			until.CsvCell(v.Changes),
+			strconv.FormatInt(v.Code, 10),
+			v.Result,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func SetAuditLogDays(params url.Values) dto.ReturnJsonDto {
+	days, err := strconv.ParseInt(params.Get("audit_log_days"), 10, 64)
+	if err != nil || days < 1 || days > 3650 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "保留天数必须为 1-3650", Type: "danger"}
+	}
+	cfg := dao.GetConfig()
+	cfg.System.AuditLogDays = days
+	dao.SetConfig(cfg)
+	return dto.ReturnJsonDto{Code: 1, Msg: "保存成功，过期记录将在每日清理任务中删除", Type: "success"}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 异常检测动作
+const (
+	DetectOff    = 0 // 关闭
+	DetectFlag   = 1 // 仅标记
+	DetectReauth = 2 // 取消授权，需要重新授权
+	DetectBlock  = 3 // 封禁
+)
+
+var detectActionText = map[int64]string{
+	DetectFlag:   "标记",
+	DetectReauth: "重新授权",
+	DetectBlock:  "封禁",
+}
+
+// 记录设备IP并统计窗口内的IP数和地区数
+func recordUserIP(user models.IptvUser, window time.Duration) (int64, int64) {
+	now := time.Now().Unix()
+	var rec models.IptvUserIP
+	if dao.DB.Model(&models.IptvUserIP{}).Where("user_name = ? and ip = ?", user.Name, user.IP).First(&rec).Error == nil {
+		dao.DB.Model(&models.IptvUserIP{}).Where("id = ?", rec.ID).Updates(map[string]interface{}{"last_seen": now, "region": user.Region})
+	} else {
+		dao.DB.Create(&models.IptvUserIP{UserName: user.Name, IP: user.IP, Region: user.Region, LastSeen: now})
+	}
+
+	since := now - int64(window.Seconds())
+	dao.DB.Where("user_name = ? and last_seen < ?", user.Name, since).Delete(&models.IptvUserIP{})
+
+	var ips, regions int64
+	dao.DB.Model(&models.IptvUserIP{}).Where("user_name = ?", user.Name).Count(&ips)
+	dao.DB.Model(&models.IptvUserIP{}).Where("user_name = ? and region <> '' and region <> '局域网'", user.Name).Distinct("region").Count(&regions)
+	return ips, regions
+}
+
+// 设备异常检测，idChanged 为同一MAC下设备ID是否变更
+// 只在标记从无到有时执行动作，管理员重新授权后不会被反复取消
+func DetectDevice(user *models.IptvUser, idChanged bool, client string) {
+	cfg := dao.GetConfig().Detect
+	if user.ID == 0 {
+		return
+	}
+	updates := map[string]interface{}{}
+	var reasons []string
+	var action int64
+
+	hit := func(rule int64, reason string) {
+		reasons = append(reasons, reason)
+		if rule > action {
+			action = rule
+		}
+	}
+
+	if idChanged {
+		user.IDChange++
+		updates["idchange"] = user.IDChange
+		if cfg.IDChange > DetectOff {
+			hit(cfg.IDChange, "设备ID变更")
+		}
+	}
+
+	if cfg.MultiIP > DetectOff && user.IP != "" && (cfg.IPCount > 0 || cfg.RegionCount > 0) {
+		window := time.Duration(cfg.Window) * time.Hour
+		if window <= 0 {
+			window = 24 * time.Hour
+		}
+		ips, regions := recordUserIP(*user, window)
+		if (cfg.IPCount > 0 && ips >= cfg.IPCount) || (cfg.RegionCount > 0 && regions >= cfg.RegionCount) {
+			if user.MultiIP == 0 {
+				user.MultiIP = 1
+				updates["multiip"] = 1
+				hit(cfg.MultiIP, fmt.Sprintf("%d小时内%d个IP/%d个地区", int64(window.Hours()), ips, regions))
+			}
+		}
+	}
+
+	if cfg.VPN > DetectOff && user.VPN == 0 && until.IsVpnIP(user.IP) {
+		user.VPN = 1
+		updates["vpn"] = 1
+		hit(cfg.VPN, "机房/VPN IP "+user.IP)
+	}
+
+	if len(reasons) > 0 {
+		user.Flag = time.Now().Format("01-02 15:04") + " " + strings.Join(reasons, "，")
+		updates["flag"] = user.Flag
+
+		switch action {
+		case DetectReauth:
+			if user.Status != 0 {
+				user.Status, user.Exp, user.Account = -1, 0, 0
+				updates["status"], updates["exp"], updates["account"] = -1, 0, 0
+			}
+		case DetectBlock:
+			user.Status = 0
+			updates["status"] = 0
+		}
+		log.Printf("异常检测 [%s] 用户: %d IP: %s %s，动作: %s\n", client, user.Name, user.IP, strings.Join(reasons, "，"), detectActionText[action])
+	}
+
+	if len(updates) > 0 {
+		dao.DB.Model(&models.IptvUser{}).Where("id = ?", user.ID).Updates(updates)
+		if action >= DetectReauth && user.DeviceID != "" {
+			dao.Cache.Delete("mytvMealM3u8_" + user.DeviceID)
+		}
+	}
+}
+
+// 清除异常标记
+func ClearUserFlags(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择用户", Type: "danger"}
+	}
+	dao.DB.Model(&models.IptvUser{}).Where("name IN ?", ids).Updates(map[string]interface{}{
+		"vpn":      0,
+		"multiip":  0,
+		"idchange": 0,
+		"flag":     "",
+	})
+	dao.DB.Where("user_name IN ?", ids).Delete(&models.IptvUserIP{})
+	return dto.ReturnJsonDto{Code: 1, Msg: "已清除异常标记", Type: "success"}
+}
+
+func SetDetect(params url.Values) dto.ReturnJsonDto {
+	keys := []string{"id_change", "multi_ip", "ip_count", "region_count", "window", "vpn"}
+	values := make(map[string]int64, len(keys))
+	for _, k := range keys {
+		v, err := strconv.ParseInt(params.Get(k), 10, 64)
+		if err != nil || v < 0 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "参数错误", Type: "danger"}
+		}
+		values[k] = v
+	}
+	for _, k := range []string{"id_change", "multi_ip", "vpn"} {
+		if values[k] > DetectBlock {
+			return dto.ReturnJsonDto{Code: 0, Msg: "动作参数错误", Type: "danger"}
+		}
+	}
+	if values["window"] == 0 || values["window"] > 24*30 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "统计窗口必须为 1-720 小时", Type: "danger"}
+	}
+
+	cfg := dao.GetConfig()
+	cfg.Detect = dto.Detect{
+		IDChange:    values["id_change"],
+		MultiIP:     values["multi_ip"],
+		IPCount:     values["ip_count"],
+		RegionCount: values["region_count"],
+		Window:      values["window"],
+		VPN:         values["vpn"],
+	}
+	dao.SetConfig(cfg)
+	return dto.ReturnJsonDto{Code: 1, Msg: "检测规则已保存", Type: "success"}
+}
+
+func SaveVpnCidr(params url.Values) dto.ReturnJsonDto {
+	text := strings.ReplaceAll(params.Get("cidrs"), "\r\n", "\n")
+	if err := os.WriteFile(until.VpnCidrFile, []byte(text), 0644); err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "保存失败:" + err.Error(), Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: fmt.Sprintf("保存成功，有效IP段 %d 条", len(until.ParseCidrList(text))), Type: "success"}
+}
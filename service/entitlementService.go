@@ -0,0 +1,178 @@
+package service
+
+import (
+	"go-iptv/dao"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"strconv"
+	"time"
+)
+
+// 授权拒绝原因
+const (
+	DenyUnregistered = "unregistered" // 未注册/未授权
+	DenyForbidden    = "forbidden"    // 已禁用
+	DenyExpired      = "expired"      // 已过期
+	DenyMealOffline  = "meal_offline" // 套餐已下线
+	DenyInvalidToken = "invalid"      // token无效
+	DenyIPPolicy     = "ip_policy"    // IP/地区策略限制
+)
+
+var denyText = map[string]string{
+	DenyUnregistered: "未授权",
+	DenyForbidden:    "已禁用",
+	DenyExpired:      "已过期",
+	DenyMealOffline:  "套餐已下线",
+	DenyInvalidToken: "token无效",
+	DenyIPPolicy:     "IP受限",
+}
+
+// 授权结果，Reason 为空表示允许
+type Entitlement struct {
+	Reason  string
+	Meal    models.IptvMeals
+	User    models.IptvUser
+	Account models.IptvAccount // 设备所属客户账号
+	Token   AesData
+}
+
+func (e Entitlement) Allowed() bool {
+	return e.Reason == ""
+}
+
+func (e Entitlement) ReasonText() string {
+	if text, ok := denyText[e.Reason]; ok {
+		return text
+	}
+	return e.Reason
+}
+
+func deny(e Entitlement, reason, source, subject string) Entitlement {
+	e.Reason = reason
+	log.Printf("授权拒绝 [%s] %s: %s\n", source, subject, e.ReasonText())
+	return e
+}
+
+// 检查设备授权，source 为调用来源（apk、mytv），用于日志
+func CheckUserEntitlement(user models.IptvUser, source string) Entitlement {
+	e := Entitlement{User: user}
+	subject := "用户 " + strconv.FormatInt(user.Name, 10)
+	if user.DeviceID != "" {
+		subject += " 设备ID: " + user.DeviceID
+	} else if user.Mac != "" {
+		subject += " MAC: " + user.Mac
+	}
+	if user.ID == 0 {
+		return deny(e, DenyUnregistered, source, subject)
+	}
+
+	if user.Status == 0 {
+		return deny(e, DenyForbidden, source, subject)
+	}
+	// 绑定客户账号的设备以账号的套餐和到期时间为准，账号已删除时按设备自身授权
+	if user.Account > 0 {
+		if err := dao.DB.Model(&models.IptvAccount{}).Where("id = ?", user.Account).First(&e.Account).Error; err == nil {
+			return checkAccountEntitlement(e, source, subject)
+		}
+	}
+
+	switch user.Status {
+	case -1:
+		// 自动授权模式下未授权设备也可使用，试用期内的设备也可使用
+		if dao.GetConfig().App.NeedAuthor != 0 && !IsTrialUser(user) {
+			return deny(e, DenyUnregistered, source, subject)
+		}
+	case 999:
+	default:
+		if user.Exp > 0 && user.Exp < time.Now().Unix() {
+			return deny(e, DenyExpired, source, subject)
+		}
+	}
+
+	if err := dao.DB.Model(&models.IptvMeals{}).Where("id = ? and status = 1", user.Meal).First(&e.Meal).Error; err != nil {
+		return deny(e, DenyMealOffline, source, subject)
+	}
+	return e
+}
+
+func checkAccountEntitlement(e Entitlement, source, subject string) Entitlement {
+	subject += " 客户账号: " + e.Account.Name
+	if e.Account.Status != 1 {
+		return deny(e, DenyForbidden, source, subject)
+	}
+	if e.Account.Exp > 0 && e.Account.Exp < time.Now().Unix() {
+		return deny(e, DenyExpired, source, subject)
+	}
+	if err := dao.DB.Model(&models.IptvMeals{}).Where("id = ? and status = 1", e.Account.Meal).First(&e.Meal).Error; err != nil {
+		return deny(e, DenyMealOffline, source, subject)
+	}
+	return e
+}
+
+// 按 MAC 检查APK设备授权
+func CheckMacEntitlement(mac, source string) Entitlement {
+	var user models.IptvUser
+	if mac != "" {
+		dao.DB.Model(&models.IptvUser{}).Where("mac = ?", mac).First(&user)
+	}
+	if user.ID == 0 {
+		user.Mac = mac
+		return deny(Entitlement{User: user}, DenyUnregistered, source, "MAC: "+mac)
+	}
+	return CheckUserEntitlement(user, source)
+}
+
+// 按设备ID检查MyTV设备授权
+func CheckDeviceEntitlement(deviceId, source string) Entitlement {
+	var user models.IptvUser
+	if deviceId != "" {
+		dao.DB.Model(&models.IptvUser{}).Where("deviceid = ?", deviceId).First(&user)
+	}
+	if user.ID == 0 {
+		user.DeviceID = deviceId
+		return deny(Entitlement{User: user}, DenyUnregistered, source, "设备ID: "+deviceId)
+	}
+	return CheckUserEntitlement(user, source)
+}
+
+// 检查订阅token授权；Token管理中创建的token需存在、启用且在有效期内
+func CheckTokenEntitlement(token, source string) Entitlement {
+	var e Entitlement
+	subject := "token: " + shortToken(token)
+
+	aes := until.NewChaCha20(string(until.RssKey))
+	jsonStr, err := aes.Decrypt(token)
+	if err != nil {
+		return deny(e, DenyInvalidToken, source, subject)
+	}
+	e.Token, err = getAesType(jsonStr)
+	if err != nil {
+		return deny(e, DenyInvalidToken, source, subject)
+	}
+
+	if e.Token.R != "" {
+		var mealToken models.IptvMealToken
+		if err := dao.DB.Model(&models.IptvMealToken{}).Where("token = ?", token).First(&mealToken).Error; err != nil {
+			return deny(e, DenyUnregistered, source, subject)
+		}
+		if mealToken.Status != 1 {
+			return deny(e, DenyForbidden, source, subject)
+		}
+		if mealToken.ExpiresAt > 0 && mealToken.ExpiresAt < time.Now().Unix() {
+			return deny(e, DenyExpired, source, subject)
+		}
+	}
+
+	if err := dao.DB.Model(&models.IptvMeals{}).Where("id = ? and status = 1", e.Token.I).First(&e.Meal).Error; err != nil {
+		return deny(e, DenyMealOffline, source, subject)
+	}
+	return e
+}
+
+func shortToken(token string) string {
+	if len(token) > 12 {
+		return token[:12] + "..."
+	}
+	return token
+}
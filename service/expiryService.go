@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+	"go-iptv/crontab"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"net/url"
+	"strconv"
+)
+
+func SetExpirySet(params url.Values) dto.ReturnJsonDto {
+	values := make([]int64, 2)
+	for i, k := range []string{"remind_days", "idle_days"} {
+		v, err := strconv.ParseInt(params.Get(k), 10, 64)
+		if err != nil || v < 0 || v > 3650 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "天数必须为0-3650的整数", Type: "danger"}
+		}
+		values[i] = v
+	}
+
+	cfg := dao.GetConfig()
+	cfg.Expiry.RemindDays = values[0]
+	cfg.Expiry.IdleDays = values[1]
+	dao.SetConfig(cfg)
+	go crontab.RunExpiry()
+	return dto.ReturnJsonDto{Code: 1, Msg: "到期处理设置成功", Type: "success"}
+}
+
+// 立即执行一次到期处理
+func RunExpiry() dto.ReturnJsonDto {
+	digest := crontab.RunExpiry()
+	msg := fmt.Sprintf("执行完成，标记过期 %d 个，清理闲置设备 %d 个", digest.Expired, digest.Cleaned)
+	if digest.Days > 0 {
+		msg += fmt.Sprintf("，%d 天内到期 %d 个", digest.Days, digest.Total)
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: msg, Type: "success"}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/until"
+	"io"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 上传ip2region xdb文件，校验通过后替换 /config/ip2region.xdb
+func UploadGeoIP(c *gin.Context) dto.ReturnJsonDto {
+	file, err := c.FormFile("xdbfile")
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "获取文件失败:" + err.Error(), Type: "danger"}
+	}
+	if file.Size > 200<<20 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "文件不能超过200MB", Type: "danger"}
+	}
+	f, err := file.Open()
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "打开文件失败:" + err.Error(), Type: "danger"}
+	}
+	defer f.Close()
+
+	buf, err := io.ReadAll(f)
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "读取文件失败:" + err.Error(), Type: "danger"}
+	}
+	if err := until.CheckXdb(buf); err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: err.Error(), Type: "danger"}
+	}
+
+	// 先写临时文件再替换，避免查询时读到半个文件
+	tmp := until.GeoIPFile + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0644); err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "保存文件失败:" + err.Error(), Type: "danger"}
+	}
+	if err := os.Rename(tmp, until.GeoIPFile); err != nil {
+		os.Remove(tmp)
+		return dto.ReturnJsonDto{Code: 0, Msg: "保存文件失败:" + err.Error(), Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "上传成功", Type: "success"}
+}
+
+func SetIPRemote(params url.Values) dto.ReturnJsonDto {
+	cfg := dao.GetConfig()
+	if params.Get("ip_remote") == "1" {
+		cfg.System.IPRemote = 1
+	} else {
+		cfg.System.IPRemote = 0
+	}
+	dao.SetConfig(cfg)
+	return dto.ReturnJsonDto{Code: 1, Msg: "保存成功", Type: "success"}
+}
+
+func TestGeoIP(params url.Values) dto.ReturnJsonDto {
+	ip := params.Get("testip")
+	if net.ParseIP(ip) == nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "IP格式错误", Type: "danger"}
+	}
+	region := until.GetIpRegion(ip)
+	if region == "" {
+		region = "未查到"
+	}
+	return dto.ReturnJsonDto{Code: 0, Msg: ip + " : " + region, Type: "success"}
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 策略范围
+const (
+	PolicyGlobal = "global"
+	PolicyMeal   = "meal"
+	PolicyToken  = "token"
+)
+
+// 策略类型
+const (
+	PolicyAllowCidr   = "allow_cidr"
+	PolicyDenyCidr    = "deny_cidr"
+	PolicyAllowRegion = "allow_region"
+	PolicyDenyRegion  = "deny_region"
+)
+
+var policyScopeText = map[string]string{
+	PolicyGlobal: "全局",
+	PolicyMeal:   "套餐",
+	PolicyToken:  "Token",
+}
+
+var policyTypeText = map[string]string{
+	PolicyAllowCidr:   "允许IP段",
+	PolicyDenyCidr:    "禁止IP段",
+	PolicyAllowRegion: "允许地区",
+	PolicyDenyRegion:  "禁止地区",
+}
+
+func PolicyScopeText(scope string) string { return policyScopeText[scope] }
+func PolicyTypeText(t string) string      { return policyTypeText[t] }
+
+// 策略检查对象，Meal/Token 为0时只检查全局策略
+type PolicySubject struct {
+	User  models.IptvUser
+	Meal  int64
+	Token int64
+}
+
+type ipRule struct {
+	models.IptvIPPolicy
+	ipNet *net.IPNet
+}
+
+// 启用的策略缓存，修改策略后清空；version 每次清空时递增，
+// 避免清空前开始的加载把旧策略写回缓存
+var ipPolicies struct {
+	sync.RWMutex
+	loaded  bool
+	version uint64
+	rules   []ipRule
+}
+
+func loadIPPolicies() []ipRule {
+	ipPolicies.RLock()
+	if ipPolicies.loaded {
+		rules := ipPolicies.rules
+		ipPolicies.RUnlock()
+		return rules
+	}
+	version := ipPolicies.version
+	ipPolicies.RUnlock()
+
+	var list []models.IptvIPPolicy
+	dao.DB.Model(&models.IptvIPPolicy{}).Where("status = 1").Find(&list)
+	rules := buildIPRules(list)
+
+	ipPolicies.Lock()
+	if ipPolicies.version == version {
+		ipPolicies.rules, ipPolicies.loaded = rules, true
+	}
+	ipPolicies.Unlock()
+	return rules
+}
+
+func buildIPRules(list []models.IptvIPPolicy) []ipRule {
+	rules := make([]ipRule, 0, len(list))
+	for _, p := range list {
+		r := ipRule{IptvIPPolicy: p}
+		if p.Type == PolicyAllowCidr || p.Type == PolicyDenyCidr {
+			nets := until.ParseCidrList(p.Value)
+			if len(nets) == 0 {
+				continue
+			}
+			r.ipNet = nets[0]
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func clearIPPolicies() {
+	ipPolicies.Lock()
+	ipPolicies.rules, ipPolicies.loaded = nil, false
+	ipPolicies.version++
+	ipPolicies.Unlock()
+}
+
+// 是否存在启用的IP策略，scoped 为 true 时只看套餐/Token策略
+func HasIPPolicies(scoped bool) bool {
+	for _, r := range loadIPPolicies() {
+		if !scoped || r.Scope != PolicyGlobal {
+			return true
+		}
+	}
+	return false
+}
+
+// 检查IP是否被策略允许，返回拒绝原因，空为允许
+func CheckIPPolicy(ip string, s PolicySubject) string {
+	return evalIPPolicy(loadIPPolicies(), net.ParseIP(ip), until.GetIpRegion(ip), s)
+}
+
+func evalIPPolicy(rules []ipRule, ip net.IP, region string, s PolicySubject) string {
+	if ip == nil {
+		return ""
+	}
+	type scopeKey struct {
+		scope  string
+		target int64
+	}
+	applies := map[scopeKey]bool{{PolicyGlobal, 0}: true}
+	if s.Meal > 0 {
+		applies[scopeKey{PolicyMeal, s.Meal}] = true
+	}
+	if s.Token > 0 {
+		applies[scopeKey{PolicyToken, s.Token}] = true
+	}
+	// 局域网地址不受地区策略限制
+	lan := region == "局域网"
+
+	hasAllow := map[scopeKey]bool{}
+	allowed := map[scopeKey]bool{}
+	for _, r := range rules {
+		k := scopeKey{r.Scope, r.Target}
+		if !applies[k] {
+			continue
+		}
+		switch r.Type {
+		case PolicyDenyCidr:
+			if r.ipNet.Contains(ip) {
+				return policyScopeText[r.Scope] + "禁止IP段 " + r.Value
+			}
+		case PolicyDenyRegion:
+			if !lan && region != "" && strings.Contains(region, r.Value) {
+				return policyScopeText[r.Scope] + "禁止地区 " + r.Value
+			}
+		case PolicyAllowCidr:
+			hasAllow[k] = true
+			if r.ipNet.Contains(ip) {
+				allowed[k] = true
+			}
+		case PolicyAllowRegion:
+			// 地区未知时跳过，避免IP库缺失时拒绝所有外网访问
+			if !lan && region == "" {
+				warnUnknownRegion(ip)
+				continue
+			}
+			hasAllow[k] = true
+			if lan || strings.Contains(region, r.Value) {
+				allowed[k] = true
+			}
+		}
+	}
+	for k := range hasAllow {
+		if !allowed[k] {
+			return policyScopeText[k.scope] + "策略不允许该IP/地区"
+		}
+	}
+	return ""
+}
+
+var unknownRegionWarnAt atomic.Int64
+
+// 地区未知时提示，每10分钟最多一条
+func warnUnknownRegion(ip net.IP) {
+	now := time.Now().Unix()
+	last := unknownRegionWarnAt.Load()
+	if now-last < 600 || !unknownRegionWarnAt.CompareAndSwap(last, now) {
+		return
+	}
+	log.Printf("IP %s 无法识别地区，已跳过允许地区策略，请检查IP库是否已加载\n", ip)
+}
+
+// 设备实际使用的套餐，绑定客户账号时以账号套餐为准
+func deviceMeal(user models.IptvUser) int64 {
+	if user.Account > 0 {
+		var account models.IptvAccount
+		if dao.DB.Model(&models.IptvAccount{}).Where("id = ?", user.Account).First(&account).Error == nil {
+			return account.Meal
+		}
+	}
+	return user.Meal
+}
+
+func PolicySubjectByMac(mac string) PolicySubject {
+	var s PolicySubject
+	if mac != "" {
+		dao.DB.Model(&models.IptvUser{}).Where("mac = ?", mac).First(&s.User)
+	}
+	if s.User.ID > 0 {
+		s.Meal = deviceMeal(s.User)
+	}
+	return s
+}
+
+func PolicySubjectByDevice(deviceId string) PolicySubject {
+	var s PolicySubject
+	if deviceId != "" {
+		dao.DB.Model(&models.IptvUser{}).Where("deviceid = ?", deviceId).First(&s.User)
+	}
+	if s.User.ID > 0 {
+		s.Meal = deviceMeal(s.User)
+	}
+	return s
+}
+
+func PolicySubjectByToken(token string) PolicySubject {
+	var s PolicySubject
+	aes := until.NewChaCha20(string(until.RssKey))
+	jsonStr, err := aes.Decrypt(token)
+	if err != nil {
+		return s
+	}
+	data, err := getAesType(jsonStr)
+	if err != nil {
+		return s
+	}
+	s.Meal = data.I
+	if data.R != "" {
+		var mealToken models.IptvMealToken
+		if dao.DB.Model(&models.IptvMealToken{}).Where("token = ?", token).First(&mealToken).Error == nil {
+			s.Token = mealToken.ID
+		}
+	}
+	return s
+}
+
+func parseIPPolicy(params url.Values) (models.IptvIPPolicy, string) {
+	p := models.IptvIPPolicy{
+		Scope: params.Get("scope"),
+		Type:  params.Get("type"),
+		Value: strings.TrimSpace(params.Get("value")),
+		Marks: strings.TrimSpace(params.Get("marks")),
+	}
+	if _, ok := policyScopeText[p.Scope]; !ok {
+		return p, "范围参数错误"
+	}
+	if _, ok := policyTypeText[p.Type]; !ok {
+		return p, "类型参数错误"
+	}
+	if p.Scope != PolicyGlobal {
+		p.Target, _ = strconv.ParseInt(params.Get("target_"+p.Scope), 10, 64)
+		if p.Target <= 0 {
+			return p, "请选择套餐或Token"
+		}
+	}
+	if p.Value == "" || !until.IsSafe(p.Value) || !until.IsSafe(p.Marks) {
+		return p, "输入不合法"
+	}
+	if p.Type == PolicyAllowCidr || p.Type == PolicyDenyCidr {
+		nets := until.ParseCidrList(p.Value)
+		if len(nets) != 1 {
+			return p, "IP段格式错误，例如 1.2.3.0/24"
+		}
+		p.Value = nets[0].String()
+	}
+	if p.Type == PolicyAllowRegion {
+		if size, _ := until.GeoIPInfo(); size == 0 && dao.GetConfig().System.IPRemote != 1 {
+			return p, "未加载IP库且未开启在线查询，无法识别地区，请先上传IP库"
+		}
+	}
+	return p, ""
+}
+
+func SaveIPPolicy(params url.Values) dto.ReturnJsonDto {
+	p, errMsg := parseIPPolicy(params)
+	if errMsg != "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: errMsg, Type: "danger"}
+	}
+	p.Status = 1
+	p.CreatedAt = time.Now().Unix()
+	if err := dao.DB.Create(&p).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "保存失败:" + err.Error(), Type: "danger"}
+	}
+	clearIPPolicies()
+	return dto.ReturnJsonDto{Code: 1, Msg: "添加成功", Type: "success"}
+}
+
+func DelIPPolicies(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择策略", Type: "danger"}
+	}
+	dao.DB.Where("id IN ?", ids).Delete(&models.IptvIPPolicy{})
+	clearIPPolicies()
+	return dto.ReturnJsonDto{Code: 1, Msg: "删除成功", Type: "success"}
+}
+
+func SetIPPoliciesStatus(params url.Values, status int64) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择策略", Type: "danger"}
+	}
+	dao.DB.Model(&models.IptvIPPolicy{}).Where("id IN ?", ids).Update("status", status)
+	clearIPPolicies()
+	return dto.ReturnJsonDto{Code: 1, Msg: "操作成功", Type: "success"}
+}
+
+// 用设备的IP历史试运行策略，候选策略不保存
+func DryRunIPPolicy(params url.Values) dto.ReturnJsonDto {
+	p, errMsg := parseIPPolicy(params)
+	if errMsg != "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: errMsg, Type: "danger"}
+	}
+	if p.Scope == PolicyToken {
+		return dto.ReturnJsonDto{Code: 0, Msg: "Token订阅没有设备IP历史，无法试运行", Type: "warning"}
+	}
+	current := loadIPPolicies()
+	candidate := append(append([]ipRule{}, current...), buildIPRules([]models.IptvIPPolicy{p})...)
+
+	var users []models.IptvUser
+	dao.DB.Model(&models.IptvUser{}).Select("name,ip,region,meal,account").Find(&users)
+
+	// 设备历史IP：当前IP、访问记录和多地检测记录
+	type ipRec struct {
+		UserName int64
+		IP       string
+		Region   string
+	}
+	var history []ipRec
+	dao.DB.Model(&models.IptvAccessLog{}).Select("user_name, ip, max(region) as region").Where("user_name > 0").Group("user_name, ip").Scan(&history)
+	var recent []ipRec
+	dao.DB.Model(&models.IptvUserIP{}).Select("user_name, ip, region").Scan(&recent)
+	history = append(history, recent...)
+	byUser := map[int64][]ipRec{}
+	for _, h := range history {
+		byUser[h.UserName] = append(byUser[h.UserName], h)
+	}
+
+	var devices, ips, denied, newDenied int64
+	var samples []string
+	for _, u := range users {
+		s := PolicySubject{User: u, Meal: deviceMeal(u)}
+		if p.Scope == PolicyMeal && s.Meal != p.Target {
+			continue
+		}
+		devices++
+		recs := append([]ipRec{{u.Name, u.IP, u.Region}}, byUser[u.Name]...)
+		seen := map[string]bool{}
+		hit := false
+		for _, r := range recs {
+			if r.IP == "" || seen[r.IP] {
+				continue
+			}
+			seen[r.IP] = true
+			ips++
+			ip := net.ParseIP(r.IP)
+			if evalIPPolicy(candidate, ip, r.Region, s) == "" {
+				continue
+			}
+			hit = true
+			if evalIPPolicy(current, ip, r.Region, s) == "" {
+				newDenied++
+				if len(samples) < 20 {
+					samples = append(samples, fmt.Sprintf("%d %s %s", u.Name, r.IP, r.Region))
+				}
+			}
+		}
+		if hit {
+			denied++
+		}
+	}
+
+	msg := fmt.Sprintf("试运行：共检查 %d 台设备 %d 个IP，加入该策略后 %d 台设备有IP会被拒绝，新增拒绝 %d 个IP", devices, ips, denied, newDenied)
+	return dto.ReturnJsonDto{Code: 3, Msg: msg, Type: "info", Data: map[string]interface{}{"samples": samples}}
+}
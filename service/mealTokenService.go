@@ -83,6 +83,7 @@ func CreateMealToken(mealId int64, remark string, expireDays int64) (dto.MealTok
 	if err := dao.DB.Create(&mealToken).Error; err != nil {
 		return dto.MealTokenDto{}, err
 	}
+	notifyMealToken(until.EventTokenCreated, mealToken, "created")
 
 	return dto.MealTokenDto{
 		ID:         mealToken.ID,
@@ -126,9 +127,15 @@ func UpdateMealToken(params url.Values) dto.ReturnJsonDto {
 	}
 
 	if len(updates) > 0 {
+		var old models.IptvMealToken
+		dao.DB.Where("id = ?", tokenIdInt64).First(&old)
 		if err := dao.DB.Model(&models.IptvMealToken{}).Where("id = ?", tokenIdInt64).Updates(updates).Error; err != nil {
 			return dto.ReturnJsonDto{Code: 0, Msg: "更新失败: " + err.Error(), Type: "danger"}
 		}
+		if s, ok := updates["status"]; ok && s == int64(0) && old.Status != 0 && old.ID > 0 {
+			old.Status = 0
+			notifyMealToken(until.EventTokenRevoked, old, "disabled")
+		}
 	}
 
 	return dto.ReturnJsonDto{Code: 1, Msg: "更新成功", Type: "success"}
@@ -136,7 +143,27 @@ func UpdateMealToken(params url.Values) dto.ReturnJsonDto {
 
 // DeleteMealToken 删除token
 func DeleteMealToken(tokenId int64) error {
-	return dao.DB.Where("id = ?", tokenId).Delete(&models.IptvMealToken{}).Error
+	var old models.IptvMealToken
+	dao.DB.Where("id = ?", tokenId).First(&old)
+	if err := dao.DB.Where("id = ?", tokenId).Delete(&models.IptvMealToken{}).Error; err != nil {
+		return err
+	}
+	if old.ID > 0 {
+		notifyMealToken(until.EventTokenRevoked, old, "deleted")
+	}
+	return nil
+}
+
+// 订阅token创建/吊销推送 Webhook，不包含token本身
+func notifyMealToken(event string, token models.IptvMealToken, action string) {
+	until.FireWebhook(event, map[string]interface{}{
+		"id":         token.ID,
+		"meal_id":    token.MealID,
+		"remark":     token.Remark,
+		"status":     token.Status,
+		"expires_at": token.ExpiresAt,
+		"action":     action,
+	})
 }
 
 // DeleteMealTokenAPI 删除token API接口
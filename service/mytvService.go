@@ -10,9 +10,10 @@ import (
 	"time"
 )
 
-func MytvGetUserM3U8(ts, deviceId, clientIP, host string) string {
+// pairCode 为客户账号配对码，可选
+func MytvGetUserM3U8(ts, deviceId, pairCode, host string, client dto.AccessClient) string {
 	var user models.IptvUser
-	user.IP = clientIP
+	user.IP = client.IP
 	user.DeviceID = deviceId
 	user.Region = until.GetIpRegion(user.IP)
 
@@ -22,25 +23,34 @@ func MytvGetUserM3U8(ts, deviceId, clientIP, host string) string {
 		user.LastTime = time.Now().Unix()
 	}
 	user = SaveUser(user)
+	if pairCode != "" {
+		user = PairDevice(user, pairCode, "mytv")
+	}
 	keySeed := ts + deviceId
 
-	data, err := until.AESEncrypt(until.MytvM3u8(int64(user.Meal), deviceId, host), keySeed)
+	m3u8 := "#EXTM3U\n"
+	e := CheckUserEntitlement(user, "mytv")
+	RecordAccess(e, client)
+	if e.Allowed() {
+		m3u8 = until.MytvM3u8(e.Meal.ID, deviceId, host)
+	}
+	data, err := until.AESEncrypt(m3u8, keySeed)
 	if err != nil {
 		log.Println("mytv订阅加密失败: ", err)
 	}
 	return data
 }
 
-func MytvGetRssEpg(deviceid string) dto.XmlTV {
-	var dbUser models.IptvUser
-	res := dao.DB.Where("deviceid = ?", deviceid).First(&dbUser)
-	if res.RowsAffected == 0 {
+func MytvGetRssEpg(deviceid string, client dto.AccessClient) dto.XmlTV {
+	e := CheckDeviceEntitlement(deviceid, "mytv")
+	RecordAccess(e, client)
+	if !e.Allowed() {
 		return dto.XmlTV{
 			GeneratorName: "清和IPTV管理系统",
 			GeneratorURL:  "https://www.qingh.xyz",
 		}
 	}
-	return until.GetEpg(dbUser.Meal)
+	return until.GetEpg(e.Meal.ID)
 }
 
 func SaveUser(user models.IptvUser) models.IptvUser {
@@ -48,17 +58,13 @@ func SaveUser(user models.IptvUser) models.IptvUser {
 	res := dao.DB.Where("deviceid = ?", user.DeviceID).First(&dbUser)
 	if res.RowsAffected == 0 {
 		user.Name = int64(genName())
-		var cfg = dao.GetConfig()
-		switch cfg.App.NeedAuthor {
-		case 0:
-			user.Status = 999
-			user.Marks = "自动授权"
-		case 1:
-			user.Status = -1
-			user.Marks = "未授权"
-		}
-		user.Meal = 1000
+		trial := initNewUser(&user)
 		dao.DB.Model(&models.IptvUser{}).Create(&user)
+		if trial {
+			recordTrial(user, "mytv")
+		}
+		notifyNewDevice(user, "mytv", trial)
+		DetectDevice(&user, false, "mytv")
 		return user
 	}
 
@@ -67,5 +73,6 @@ func SaveUser(user models.IptvUser) models.IptvUser {
 	dbUser.LastTime = user.LastTime
 
 	dao.DB.Model(&models.IptvUser{}).Where("deviceid = ?", user.DeviceID).Updates(dbUser)
+	DetectDevice(&dbUser, false, "mytv")
 	return dbUser
 }
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const notificationLimit = 50
+
+var notifyLevels = map[string][2]string{
+	until.NotifyInfo:    {"提示", "label-info"},
+	until.NotifyWarning: {"警告", "label-warning"},
+	until.NotifyError:   {"错误", "label-danger"},
+}
+
+// 最近的通知，未读在前
+func Notifications() []dto.NotificationItem {
+	var list []models.IptvNotification
+	dao.DB.Model(&models.IptvNotification{}).Order("status asc, updated_at desc").Limit(notificationLimit).Find(&list)
+
+	res := make([]dto.NotificationItem, 0, len(list))
+	for _, n := range list {
+		level := notifyLevels[n.Level]
+		if level[0] == "" {
+			level = notifyLevels[until.NotifyInfo]
+		}
+		res = append(res, dto.NotificationItem{
+			IptvNotification: n,
+			SourceName:       until.NotifySourceName(n.Source),
+			LevelName:        level[0],
+			LevelClass:       level[1],
+			TimeStr:          time.Unix(n.UpdatedAt, 0).Format("2006-01-02 15:04:05"),
+		})
+	}
+	return res
+}
+
+// 标记选中的通知为已读，readall=1 时标记全部
+func ReadNotifications(params url.Values) dto.ReturnJsonDto {
+	db := dao.DB.Model(&models.IptvNotification{}).Where("status = 0")
+	if params.Get("readall") != "1" {
+		ids := params["ids[]"]
+		if len(ids) == 0 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "请选择通知", Type: "danger"}
+		}
+		db = db.Where("id in (?)", ids)
+	}
+	res := db.Update("status", 1)
+	if res.Error != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "操作失败: " + res.Error.Error(), Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "已标记 " + strconv.FormatInt(res.RowsAffected, 10) + " 条为已读", Type: "success"}
+}
+
+// 删除选中的已读通知
+func DelNotifications(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择通知", Type: "danger"}
+	}
+	res := dao.DB.Where("id in (?) and status = 1", ids).Delete(&models.IptvNotification{})
+	if res.Error != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败: " + res.Error.Error(), Type: "danger"}
+	}
+	if res.RowsAffected == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "只能删除已读的通知", Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "已删除 " + strconv.FormatInt(res.RowsAffected, 10) + " 条通知", Type: "success"}
+}
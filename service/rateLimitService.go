@@ -0,0 +1,287 @@
+package service
+
+import (
+	"fmt"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 频率限制类型
+const (
+	RateKindIP    = "ip"    // 按客户端IP
+	RateKindKey   = "key"   // 按账号/设备/token
+	RateKindLogin = "login" // 管理员登录失败锁定
+)
+
+var rateKindText = map[string]string{
+	RateKindIP:    "IP",
+	RateKindKey:   "账号/设备/Token",
+	RateKindLogin: "管理员登录",
+}
+
+var rateScopeText = map[string]string{
+	"admin": "后台登录",
+	"apk":   "APK客户端",
+	"mytv":  "MyTV客户端",
+	"rss":   "订阅链接",
+	"api":   "开放接口",
+}
+
+const rateWindow = 60 // 统计窗口，秒
+
+// 频率限制状态保存在内存中，重启后清空
+type rateEntry struct {
+	Kind   string
+	Scope  string
+	Key    string
+	Count  int64 // 当前窗口请求数
+	Window int64 // 当前窗口开始时间
+	Until  int64 // 封禁截止时间
+	Fails  int64 // 连续登录失败次数
+	Locks  int64 // 已锁定次数，用于递增锁定时长
+	Reason string
+}
+
+var (
+	rateLock      sync.Mutex
+	rateEntries   = map[string]*rateEntry{}
+	ratePruneAt   int64
+	loginKnownIPs = map[string]int64{} // 账号成功登录过的IP，值为最后登录时间
+)
+
+const loginKnownTTL = 30 * 86400 // 已知IP保留时长，秒
+
+func rateID(kind, scope, key string) string {
+	return kind + "|" + scope + "|" + key
+}
+
+func getRateEntry(kind, scope, key string, now int64) *rateEntry {
+	id := rateID(kind, scope, key)
+	e := rateEntries[id]
+	if e == nil {
+		// 每个统计窗口最多清理一次，避免条目较多时每次新建都遍历整个表
+		if now-ratePruneAt >= rateWindow {
+			ratePruneAt = now
+			pruneRateEntries(now)
+		}
+		e = &rateEntry{Kind: kind, Scope: scope, Key: key, Window: now}
+		rateEntries[id] = e
+	}
+	return e
+}
+
+// 清理已过期且无锁定记录的条目，登录锁定记录保留一天用于递增锁定时长
+func pruneRateEntries(now int64) {
+	for id, e := range rateEntries {
+		if e.Until > now || now-e.Window < rateWindow {
+			continue
+		}
+		if e.Kind == RateKindLogin && now-e.Until < 86400 {
+			continue
+		}
+		delete(rateEntries, id)
+	}
+	for id, t := range loginKnownIPs {
+		if now-t > loginKnownTTL {
+			delete(loginKnownIPs, id)
+		}
+	}
+}
+
+// 计数并检查是否超出每分钟限制，返回需要等待的秒数，0为允许
+func RateCheck(kind, scope, key string, limit int64) int64 {
+	now := time.Now().Unix()
+	rateLock.Lock()
+	defer rateLock.Unlock()
+
+	e := getRateEntry(kind, scope, key, now)
+	if e.Until > now {
+		return e.Until - now
+	}
+	if now-e.Window >= rateWindow {
+		e.Window, e.Count = now, 0
+	}
+	e.Count++
+	if e.Count <= limit {
+		return 0
+	}
+	e.Until = e.Window + rateWindow
+	e.Reason = fmt.Sprintf("每分钟请求超过 %d 次", limit)
+	log.Printf("频率限制 [%s] %s %s: %s\n", rateScopeText[scope], rateKindText[kind], key, e.Reason)
+	if e.Until <= now {
+		e.Until = now + 1
+	}
+	return e.Until - now
+}
+
+// 登录失败分两级记录：
+// 账号+IP 连续失败后锁定，每次锁定时长翻倍，最长一天；
+// 账号在所有IP上的失败只做固定时长锁定，且不限制该账号成功登录过的IP，
+// 避免他人故意输错密码把管理员长时间锁在外面
+func loginPairKey(username, ip string) string {
+	return username + "@" + ip
+}
+
+// 管理员登录剩余锁定秒数，0为未锁定
+func LoginLocked(username, ip string) int64 {
+	if dao.GetConfig().RateLimit.Disable == 1 {
+		return 0
+	}
+	now := time.Now().Unix()
+	rateLock.Lock()
+	defer rateLock.Unlock()
+	if e := rateEntries[rateID(RateKindLogin, "admin", loginPairKey(username, ip))]; e != nil && e.Until > now {
+		return e.Until - now
+	}
+	if _, ok := loginKnownIPs[loginPairKey(username, ip)]; ok {
+		return 0
+	}
+	if e := rateEntries[rateID(RateKindLogin, "admin", username)]; e != nil && e.Until > now {
+		return e.Until - now
+	}
+	return 0
+}
+
+// 记录登录失败
+func loginFailed(username, ip string) {
+	cfg := dao.GetConfig().RateLimit
+	if cfg.Disable == 1 {
+		return
+	}
+	now := time.Now().Unix()
+	rateLock.Lock()
+	defer rateLock.Unlock()
+
+	fails := cfg.GetLoginFails()
+	lock := cfg.GetLockMinutes() * 60
+
+	e := getRateEntry(RateKindLogin, "admin", loginPairKey(username, ip), now)
+	e.Window = now
+	e.Fails++
+	if e.Fails >= fails {
+		e.Locks++
+		e.Fails = 0
+		pairLock := lock
+		for i := int64(1); i < e.Locks && pairLock < 86400; i++ {
+			pairLock *= 2
+		}
+		if pairLock > 86400 {
+			pairLock = 86400
+		}
+		e.Until = now + pairLock
+		e.Reason = fmt.Sprintf("连续登录失败 %d 次，第 %d 次锁定", fails, e.Locks)
+		log.Printf("管理员 %s 在 %s 登录失败次数过多，锁定 %d 分钟\n", username, ip, pairLock/60)
+	}
+
+	// 账号级别只按固定时长锁定，不递增
+	a := getRateEntry(RateKindLogin, "admin", username, now)
+	if a.Until > now {
+		return
+	}
+	if now-a.Window >= lock {
+		a.Window, a.Fails = now, 0
+	}
+	a.Fails++
+	if a.Fails < fails*2 {
+		return
+	}
+	a.Fails = 0
+	a.Until = now + lock
+	a.Reason = fmt.Sprintf("多个IP连续登录失败 %d 次，已知IP不受限制", fails*2)
+	log.Printf("管理员 %s 登录失败次数过多，未登录过的IP锁定 %d 分钟\n", username, lock/60)
+}
+
+// 登录成功后清除该IP的失败记录，并记为已知IP；账号级锁定也一并解除
+func loginSucceeded(username, ip string) {
+	rateLock.Lock()
+	defer rateLock.Unlock()
+	delete(rateEntries, rateID(RateKindLogin, "admin", loginPairKey(username, ip)))
+	delete(rateEntries, rateID(RateKindLogin, "admin", username))
+	loginKnownIPs[loginPairKey(username, ip)] = time.Now().Unix()
+}
+
+func FormatWait(seconds int64) string {
+	if seconds < 60 {
+		return strconv.FormatInt(seconds, 10) + "秒"
+	}
+	return strconv.FormatInt((seconds+59)/60, 10) + "分钟"
+}
+
+// 当前封禁列表
+func RateBlocks() []dto.RateBlock {
+	now := time.Now().Unix()
+	rateLock.Lock()
+	defer rateLock.Unlock()
+
+	var list []dto.RateBlock
+	for id, e := range rateEntries {
+		if e.Until <= now {
+			continue
+		}
+		list = append(list, dto.RateBlock{
+			ID:     id,
+			Kind:   rateKindText[e.Kind],
+			Scope:  rateScopeText[e.Scope],
+			Key:    e.Key,
+			Reason: e.Reason,
+			Until:  time.Unix(e.Until, 0).Format("2006-01-02 15:04:05"),
+			Remain: FormatWait(e.Until - now),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Until > list[j].Until })
+	return list
+}
+
+func Unblock(params url.Values, all bool) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if !all && len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择要解除的记录", Type: "danger"}
+	}
+	rateLock.Lock()
+	defer rateLock.Unlock()
+
+	now := time.Now().Unix()
+	if all {
+		for id, e := range rateEntries {
+			if e.Until > now {
+				ids = append(ids, id)
+			}
+		}
+	}
+	for _, id := range ids {
+		delete(rateEntries, id)
+	}
+	log.Printf("解除频率限制 %d 条\n", len(ids))
+	return dto.ReturnJsonDto{Code: 1, Msg: fmt.Sprintf("已解除 %d 条", len(ids)), Type: "success"}
+}
+
+func SetRateLimit(params url.Values) dto.ReturnJsonDto {
+	keys := []string{"ip_rate", "key_rate", "login_fails", "lock_minutes"}
+	values := make([]int64, len(keys))
+	for i, k := range keys {
+		v, err := strconv.ParseInt(params.Get(k), 10, 64)
+		if err != nil || v < 0 || v > 100000 {
+			return dto.ReturnJsonDto{Code: 0, Msg: "参数错误", Type: "danger"}
+		}
+		values[i] = v
+	}
+
+	cfg := dao.GetConfig()
+	if params.Get("enable") == "1" || params.Get("enable") == "on" {
+		cfg.RateLimit.Disable = 0
+	} else {
+		cfg.RateLimit.Disable = 1
+	}
+	cfg.RateLimit.IPRate = values[0]
+	cfg.RateLimit.KeyRate = values[1]
+	cfg.RateLimit.LoginFails = values[2]
+	cfg.RateLimit.LockMinutes = values[3]
+	dao.SetConfig(cfg)
+	return dto.ReturnJsonDto{Code: 1, Msg: "设置成功", Type: "success"}
+}
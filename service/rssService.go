@@ -7,6 +7,8 @@ import (
 	"go-iptv/models"
 	"go-iptv/until"
 	"log"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -87,6 +89,8 @@ func GetRssUrl(id, host string, getnewkey bool) dto.ReturnJsonDto {
 	res = append(res, RssUrl{Type: "txt", Url: host + "/getRss/" + token + "/paylist.txt"})
 	res = append(res, RssUrl{Type: "ku9", Url: host + "/ku9/" + token + "/paylist.txt"})
 	res = append(res, RssUrl{Type: "epg", Url: host + "/epg/" + token + "/e.xml"})
+	res = append(res, RssUrl{Type: "diyp", Url: host + "/diyp/" + token + "?ch={ch}&date={date}"})
+	res = append(res, RssUrl{Type: "nownext", Url: host + "/epg/" + token + "/now.json"})
 
 	return dto.ReturnJsonDto{Code: 1, Msg: "订阅生成成功", Type: "success", Data: res}
 }
@@ -98,6 +102,8 @@ func GenTokenRssUrl(token, host string) dto.ReturnJsonDto {
 	res = append(res, RssUrl{Type: "txt", Url: host + "/getRss/" + token + "/paylist.txt"})
 	res = append(res, RssUrl{Type: "ku9", Url: host + "/ku9/" + token + "/paylist.txt"})
 	res = append(res, RssUrl{Type: "epg", Url: host + "/epg/" + token + "/e.xml"})
+	res = append(res, RssUrl{Type: "diyp", Url: host + "/diyp/" + token + "?ch={ch}&date={date}"})
+	res = append(res, RssUrl{Type: "nownext", Url: host + "/epg/" + token + "/now.json"})
 
 	return dto.ReturnJsonDto{Code: 1, Msg: "订阅生成成功", Type: "success", Data: res}
 }
@@ -120,56 +126,163 @@ func GetRssToken(key string) string {
 }
 
 func GetRss(token, host, t string) string {
-	log.Printf("[DEBUG]GetRss - RssKey=%s, token=%s", string(until.RssKey), token)
-
-	aes := until.NewChaCha20(string(until.RssKey))
-	jsonStr, err := aes.Decrypt(token)
-	if err != nil {
-		log.Printf("[DEBUG]GetRss - 解密失败: %v", err)
-		return "订阅失败,token解密错误"
+	e := CheckTokenEntitlement(token, "rss")
+	if !e.Allowed() {
+		return "订阅失败," + e.ReasonText()
 	}
-	aesData, err := getAesType(jsonStr)
-	if err != nil {
-		log.Printf("[DEBUG]GetRss - 反序列化失败: %v", err)
-		return "订阅失败，token读取错误"
-	}
-
-	log.Printf("[DEBUG]GetRss - 解密成功: aesData=%+v", aesData)
 
 	if t == "t" {
-		return until.GetTxt(aesData.I)
+		return until.GetTxt(e.Meal.ID)
 	} else {
-		return until.GetM3u8(aesData.I, host, token)
+		return until.GetM3u8(e.Meal.ID, host, token)
 	}
 }
 
 func GetTxtKu9(token, host string) string {
-	aes := until.NewChaCha20(string(until.RssKey))
-	jsonStr, err := aes.Decrypt(token)
-	if err != nil {
-		return "订阅失败,token解密错误"
-	}
-	aesData, err := getAesType(jsonStr)
-	if err != nil {
-		return "订阅失败，token读取错误"
+	e := CheckTokenEntitlement(token, "ku9")
+	if !e.Allowed() {
+		return "订阅失败," + e.ReasonText()
 	}
-	return until.GetTxtKu9(aesData.I)
+	return until.GetTxtKu9(e.Meal.ID)
 }
 
 func GetRssEpg(token, host string) dto.XmlTV {
+	e := CheckTokenEntitlement(token, "epg")
+	if !e.Allowed() {
+		return dto.XmlTV{
+			GeneratorName: "清和IPTV管理系统",
+			GeneratorURL:  "https://www.qingh.xyz",
+		}
+	}
+	return until.GetEpg(e.Meal.ID)
+}
 
-	res := dto.XmlTV{
-		GeneratorName: "清和IPTV管理系统",
-		GeneratorURL:  "https://www.qingh.xyz",
+// 按 DIYP 格式返回单个频道指定日期的节目单
+func GetRssDiyp(token, ch string, day time.Time) (dto.DiypData, string) {
+	res := dto.DiypData{ChannelName: ch, Date: day.Format("2006-01-02"), EpgData: []dto.DiypProgram{}}
+	e := CheckTokenEntitlement(token, "diyp")
+	if !e.Allowed() {
+		return res, e.ReasonText()
 	}
-	aes := until.NewChaCha20(string(until.RssKey))
-	jsonStr, err := aes.Decrypt(token)
-	if err != nil {
-		return res
+
+	loc := until.EpgLocation
+	dayEnd := day.AddDate(0, 0, 1)
+
+	tv := until.GetEpg(e.Meal.ID)
+	chId := findRssEpgChannel(tv, ch)
+	if chId == "" {
+		return res, ""
 	}
-	aesData, err := getAesType(jsonStr)
-	if err != nil {
-		return res
+	for _, p := range tv.Programmes {
+		if p.Channel != chId {
+			continue
+		}
+		tS, err := until.ParseXmlTime(p.Start)
+		if err != nil {
+			continue
+		}
+		tS = tS.In(loc)
+		if tS.Before(day) || !tS.Before(dayEnd) {
+			continue
+		}
+		end := ""
+		if tE, err := until.ParseXmlTime(p.Stop); err == nil {
+			end = tE.In(loc).Format("15:04")
+		}
+		desc := p.Desc.Value
+		if desc == p.Title.Value {
+			desc = ""
+		}
+		res.EpgData = append(res.EpgData, dto.DiypProgram{
+			Start: tS.Format("15:04"),
+			End:   end,
+			Title: p.Title.Value,
+			Desc:  desc,
+		})
 	}
-	return until.GetEpg(aesData.I)
+	return res, ""
+}
+
+// 套餐内所有频道的当前和下一个节目，maxAge 为最近一次节目切换的剩余秒数
+func GetRssNowNext(token string) ([]dto.EpgNowNext, int64, string) {
+	res := []dto.EpgNowNext{}
+	e := CheckTokenEntitlement(token, "nownext")
+	if !e.Allowed() {
+		return res, 0, e.ReasonText()
+	}
+
+	loc := until.EpgLocation
+	now := time.Now()
+	var maxAge int64 = 300
+
+	tv := until.GetEpg(e.Meal.ID)
+	type progTime struct {
+		p      dto.Programme
+		tS, tE time.Time
+	}
+	progMap := make(map[string][]progTime, len(tv.Channels))
+	for _, p := range tv.Programmes {
+		tS, err := until.ParseXmlTime(p.Start)
+		if err != nil {
+			continue
+		}
+		tE, err := until.ParseXmlTime(p.Stop)
+		if err != nil || !tE.After(now) {
+			continue
+		}
+		progMap[p.Channel] = append(progMap[p.Channel], progTime{p: p, tS: tS, tE: tE})
+	}
+
+	toItem := func(pt progTime) *dto.EpgNowNextItem {
+		return &dto.EpgNowNextItem{
+			Title:   pt.p.Title.Value,
+			Start:   pt.tS.In(loc).Format("15:04"),
+			End:     pt.tE.In(loc).Format("15:04"),
+			StartTs: pt.tS.Unix(),
+			EndTs:   pt.tE.Unix(),
+		}
+	}
+
+	for _, c := range tv.Channels {
+		list := progMap[c.ID]
+		sort.Slice(list, func(i, j int) bool { return list[i].tS.Before(list[j].tS) })
+		for _, d := range c.DisplayName {
+			item := dto.EpgNowNext{Channel: d.Value}
+			for i, pt := range list {
+				if pt.tS.After(now) {
+					item.Next = toItem(pt)
+					break
+				}
+				item.Now = toItem(pt)
+				if i+1 < len(list) {
+					item.Next = toItem(list[i+1])
+				}
+				if left := int64(pt.tE.Sub(now).Seconds()); left > 0 && left < maxAge {
+					maxAge = left
+				}
+				break
+			}
+			res = append(res, item)
+		}
+	}
+	return res, maxAge, ""
+}
+
+// 按频道名称查找EPG中的频道ID，找不到时按别名再查一次
+func findRssEpgChannel(tv dto.XmlTV, ch string) string {
+	names := []string{ch}
+	var alias models.IptvEpgAlias
+	if err := dao.DB.Model(&models.IptvEpgAlias{}).Where("alias = ?", ch).First(&alias).Error; err == nil {
+		names = append(names, alias.Name)
+	}
+	for _, name := range names {
+		for _, c := range tv.Channels {
+			for _, d := range c.DisplayName {
+				if strings.EqualFold(d.Value, name) {
+					return c.ID
+				}
+			}
+		}
+	}
+	return ""
 }
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"go-iptv/dao"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"strconv"
+	"time"
+)
+
+const defaultMealID = 1000
+
+// 新设备使用的套餐，未配置或套餐不存在时使用默认套餐
+func newDeviceMeal() int64 {
+	mealId := dao.GetConfig().App.DefaultMeal
+	if mealId <= 0 || mealId == defaultMealID {
+		return defaultMealID
+	}
+	var count int64
+	dao.DB.Model(&models.IptvMeals{}).Where("id = ?", mealId).Count(&count)
+	if count == 0 {
+		return defaultMealID
+	}
+	return mealId
+}
+
+// 按授权设置初始化新设备的套餐和状态，返回是否发放试用
+func initNewUser(user *models.IptvUser) bool {
+	cfg := dao.GetConfig()
+	user.Meal = newDeviceMeal()
+	user.Exp = 0
+
+	if cfg.App.NeedAuthor == 0 {
+		user.Status = 999
+		user.Marks = "自动授权"
+		return false
+	}
+
+	user.Status = -1
+	user.Marks = "未授权"
+	if cfg.App.TrialDays <= 0 {
+		return false
+	}
+	if reason := trialDenied(*user); reason != "" {
+		user.Marks = "未授权(" + reason + ")"
+		log.Printf("设备不发放试用 MAC: %s 设备ID: %s IP: %s %s\n", user.Mac, user.DeviceID, user.IP, reason)
+		return false
+	}
+	// 试用设备仍为未授权状态，到期时间内可用
+	user.Exp = time.Now().AddDate(0, 0, int(cfg.App.TrialDays)).Unix()
+	user.Marks = "试用(" + strconv.FormatInt(cfg.App.TrialDays, 10) + "天)"
+	return true
+}
+
+// 检查是否可以试用，返回不可试用的原因
+func trialDenied(user models.IptvUser) string {
+	cfg := dao.GetConfig()
+	var count int64
+
+	// 同一MAC或设备ID只能试用一次
+	if user.Mac != "" || user.DeviceID != "" {
+		dao.DB.Model(&models.IptvTrial{}).
+			Where("(mac <> '' and mac = ?) or (deviceid <> '' and deviceid = ?)", user.Mac, user.DeviceID).
+			Count(&count)
+	}
+	if count > 0 {
+		return "已试用过"
+	}
+
+	if cfg.App.TrialMaxIP > 0 && user.IP != "" {
+		dao.DB.Model(&models.IptvTrial{}).Where("ip = ?", user.IP).Count(&count)
+		if count >= cfg.App.TrialMaxIP {
+			return "同IP试用超限"
+		}
+	}
+	if cfg.App.TrialMaxMod > 0 && user.Model != "" {
+		dao.DB.Model(&models.IptvTrial{}).Where("model = ?", user.Model).Count(&count)
+		if count >= cfg.App.TrialMaxMod {
+			return "同型号试用超限"
+		}
+	}
+	return ""
+}
+
+func recordTrial(user models.IptvUser, client string) {
+	err := dao.DB.Create(&models.IptvTrial{
+		UserName:  user.Name,
+		Mac:       user.Mac,
+		DeviceID:  user.DeviceID,
+		Model:     user.Model,
+		IP:        user.IP,
+		Client:    client,
+		Meal:      user.Meal,
+		Exp:       user.Exp,
+		CreatedAt: time.Now().Unix(),
+	}).Error
+	if err != nil {
+		log.Println("保存试用记录失败:", err)
+		return
+	}
+	log.Printf("新设备试用 [%s] 用户: %d IP: %s 到期: %s\n", client, user.Name, user.IP, time.Unix(user.Exp, 0).Format("2006-01-02 15:04:05"))
+}
+
+// 是否在试用期内
+func IsTrialUser(user models.IptvUser) bool {
+	return user.Status == -1 && user.Exp > time.Now().Unix()
+}
+
+// 试用剩余天数，非试用设备返回0
+func TrialRemainDays(user models.IptvUser) int64 {
+	if !IsTrialUser(user) {
+		return 0
+	}
+	return until.CalcRemainDays(user.Exp)
+}
+
+// 新的未授权设备(包括试用中的设备)推送 Webhook
+func notifyNewDevice(user models.IptvUser, client string, trial bool) {
+	if user.Status != -1 {
+		return
+	}
+	until.FireWebhook(until.EventDeviceNew, map[string]interface{}{
+		"name":     user.Name,
+		"mac":      user.Mac,
+		"deviceid": user.DeviceID,
+		"model":    user.Model,
+		"ip":       user.IP,
+		"region":   user.Region,
+		"client":   client,
+		"trial":    trial,
+		"exp":      user.Exp,
+	})
+}
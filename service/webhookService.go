@@ -0,0 +1,178 @@
+package service
+
+import (
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"go-iptv/until"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 新增或修改 Webhook，新增时未填写密钥则自动生成并只显示一次
+func SaveWebhook(params url.Values, loginUser string) dto.ReturnJsonDto {
+	name := strings.TrimSpace(params.Get("name"))
+	if name == "" || !until.IsSafe(name) {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请输入合法的名称", Type: "danger"}
+	}
+	hookUrl := strings.TrimSpace(params.Get("url"))
+	u, err := url.Parse(hookUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return dto.ReturnJsonDto{Code: 0, Msg: "推送地址必须是 http 或 https 地址", Type: "danger"}
+	}
+	var events []string
+	for _, e := range params["events[]"] {
+		if until.WebhookEventName(e) == e {
+			return dto.ReturnJsonDto{Code: 0, Msg: "事件错误: " + e, Type: "danger"}
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择订阅的事件", Type: "danger"}
+	}
+	secret := strings.TrimSpace(params.Get("secret"))
+	if len(secret) > 128 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "签名密钥不能超过128个字符", Type: "danger"}
+	}
+	var enable int64 = 0
+	if params.Get("enable") == "1" {
+		enable = 1
+	}
+
+	if id := params.Get("id"); id != "" && id != "0" {
+		var hook models.IptvWebhook
+		if err := dao.DB.Where("id = ?", id).First(&hook).Error; err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "Webhook不存在", Type: "danger"}
+		}
+		updates := map[string]interface{}{
+			"name":   name,
+			"url":    hookUrl,
+			"events": strings.Join(events, ","),
+			"enable": enable,
+		}
+		if secret != "" {
+			updates["secret"] = secret
+		}
+		if err := dao.DB.Model(&models.IptvWebhook{}).Where("id = ?", hook.ID).Updates(updates).Error; err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "保存失败: " + err.Error(), Type: "danger"}
+		}
+		return dto.ReturnJsonDto{Code: 1, Msg: "保存成功", Type: "success"}
+	}
+
+	show := secret == ""
+	if show {
+		secret = "whsec_" + until.RandomHex(24)
+	}
+	hook := models.IptvWebhook{
+		Name:      name,
+		Url:       hookUrl,
+		Secret:    secret,
+		Events:    strings.Join(events, ","),
+		Enable:    enable,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := dao.DB.Create(&hook).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "添加失败: " + err.Error(), Type: "danger"}
+	}
+	log.Printf("管理员 %s 添加Webhook %s\n", loginUser, hook.Name)
+	if show {
+		return dto.ReturnJsonDto{Code: 3, Msg: "添加成功，请保存签名密钥", Type: "success", Data: map[string]interface{}{"key": secret}}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "添加成功", Type: "success"}
+}
+
+// 删除 Webhook 及其投递记录
+func DelWebhooks(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择Webhook", Type: "danger"}
+	}
+	err := dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id in (?)", ids).Delete(&models.IptvWebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id in (?)", ids).Delete(&models.IptvWebhook{}).Error
+	})
+	if err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "删除失败: " + err.Error(), Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "删除成功", Type: "success"}
+}
+
+func WebhookChangeStatus(params url.Values, enable int64) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择Webhook", Type: "danger"}
+	}
+	if err := dao.DB.Model(&models.IptvWebhook{}).Where("id in (?)", ids).Update("enable", enable).Error; err != nil {
+		return dto.ReturnJsonDto{Code: 0, Msg: "修改失败: " + err.Error(), Type: "danger"}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "修改成功", Type: "success"}
+}
+
+// 向选中的 Webhook 发送测试事件
+func TestWebhooks(params url.Values, loginUser string) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择Webhook", Type: "danger"}
+	}
+	var hooks []models.IptvWebhook
+	dao.DB.Where("id in (?)", ids).Find(&hooks)
+	for _, hook := range hooks {
+		if _, err := until.QueueWebhook(hook, until.EventPing, map[string]interface{}{
+			"message": "测试推送",
+			"admin":   loginUser,
+		}); err != nil {
+			return dto.ReturnJsonDto{Code: 0, Msg: "发送失败: " + err.Error(), Type: "danger"}
+		}
+	}
+	return dto.ReturnJsonDto{Code: 1, Msg: "已加入发送队列，请在投递记录中查看结果", Type: "success"}
+}
+
+// 重新发送选中的投递记录
+func ResendWebhookDeliveries(params url.Values) dto.ReturnJsonDto {
+	ids := params["ids[]"]
+	if len(ids) == 0 {
+		return dto.ReturnJsonDto{Code: 0, Msg: "请选择投递记录", Type: "danger"}
+	}
+	n := until.RetryWebhookDeliveries(ids)
+	return dto.ReturnJsonDto{Code: 1, Msg: "已重新发送 " + strconv.FormatInt(n, 10) + " 条", Type: "success"}
+}
+
+func Webhooks() []dto.WebhookItem {
+	var hooks []models.IptvWebhook
+	dao.DB.Model(&models.IptvWebhook{}).Order("id").Find(&hooks)
+
+	since := time.Now().Add(-24 * time.Hour).Unix()
+	list := make([]dto.WebhookItem, 0, len(hooks))
+	for _, h := range hooks {
+		item := dto.WebhookItem{
+			IptvWebhook: h,
+			CreatedStr:  time.Unix(h.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+		}
+		for _, e := range strings.Split(h.Events, ",") {
+			item.EventNames = append(item.EventNames, until.WebhookEventName(e))
+		}
+		dao.DB.Model(&models.IptvWebhookDelivery{}).Where("webhook_id = ? and status = 1 and updated_at >= ?", h.ID, since).Count(&item.Success)
+		dao.DB.Model(&models.IptvWebhookDelivery{}).Where("webhook_id = ? and status = 2 and updated_at >= ?", h.ID, since).Count(&item.Failed)
+		list = append(list, item)
+	}
+	return list
+}
+
+func WebhookDeliveriesQuery(webhookID int64, status string) *gorm.DB {
+	db := dao.DB.Model(&models.IptvWebhookDelivery{})
+	if webhookID > 0 {
+		db = db.Where("webhook_id = ?", webhookID)
+	}
+	switch status {
+	case "0", "1", "2":
+		db = db.Where("status = ?", status)
+	}
+	return db
+}
@@ -0,0 +1,194 @@
+package until
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-iptv/dao"
+	"go-iptv/models"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const AliasFile = "/config/alias.json"
+
+var AliasBind *SignalExecutor
+
+func initAliasBind() {
+	AliasBind = NewNamedSignalExecutor("EPG别名绑定任务", 10*time.Second, doAliasBind)
+	AliasBind.Start()
+}
+
+// 别名变更后发出信号，延迟执行绑定与缓存重建
+func RebuildAlias() {
+	if AliasBind == nil {
+		go doAliasBind(context.Background())
+		return
+	}
+	AliasBind.Rebuild()
+}
+
+func doAliasBind(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		log.Println("⚠️ 别名绑定任务被中断")
+		return
+	default:
+		if err := WriteAliasFile(); err != nil {
+			log.Println("写入别名文件失败:", err)
+		}
+		BindChannel()
+		CleanMealsEpgCacheAll()
+		log.Println("✅ EPG别名绑定任务执行完成")
+	}
+}
+
+// 读取 alias.json 格式文件 {"别名":"EPG名称"}
+func LoadAliasFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAliasJson(data)
+}
+
+func ParseAliasJson(data []byte) (map[string]string, error) {
+	var aliasMap map[string]string
+	if err := json.Unmarshal(data, &aliasMap); err != nil {
+		return nil, errors.New("别名文件格式错误,应为 {\"别名\":\"EPG名称\"}")
+	}
+	return aliasMap, nil
+}
+
+// 检查社区别名文件路径，只允许 /config 下的 json 文件或内置 alias.json
+func CheckAliasPath(path string) (string, bool) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", false
+	}
+	if path == "./alias.json" || path == "alias.json" {
+		return "./alias.json", true
+	}
+	clean := filepath.Clean(path)
+	if !strings.HasPrefix(clean, "/config/") || !strings.HasSuffix(clean, ".json") {
+		return "", false
+	}
+	return clean, true
+}
+
+// 导入别名，返回新增和更新数量
+// overwrite 为 true 时覆盖已有别名，社区和内置来源不会覆盖本地添加的别名
+func ImportAlias(aliasMap map[string]string, source string, overwrite bool) (int, int, error) {
+	var exists []models.IptvEpgAlias
+	if err := dao.DB.Model(&models.IptvEpgAlias{}).Find(&exists).Error; err != nil {
+		return 0, 0, err
+	}
+	existMap := make(map[string]models.IptvEpgAlias, len(exists))
+	for _, v := range exists {
+		existMap[v.Alias] = v
+	}
+
+	now := time.Now().Unix()
+	var addList []models.IptvEpgAlias
+	var upList []models.IptvEpgAlias
+	for alias, name := range aliasMap {
+		alias = strings.TrimSpace(alias)
+		name = strings.TrimSpace(name)
+		if alias == "" || name == "" {
+			continue
+		}
+		old, ok := existMap[alias]
+		if !ok {
+			item := models.IptvEpgAlias{Alias: alias, Name: name, Source: source, UpdatedAt: now}
+			addList = append(addList, item)
+			existMap[alias] = item
+			continue
+		}
+		if !overwrite || old.Name == name {
+			continue
+		}
+		if old.Source == "local" && source != "local" {
+			continue
+		}
+		old.Name = name
+		old.Source = source
+		old.UpdatedAt = now
+		upList = append(upList, old)
+	}
+
+	err := dao.DB.Transaction(func(tx *gorm.DB) error {
+		if len(addList) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&addList, 500).Error; err != nil {
+				return err
+			}
+		}
+		for _, v := range upList {
+			if err := tx.Model(&models.IptvEpgAlias{}).Where("id = ?", v.ID).Updates(map[string]interface{}{
+				"name":       v.Name,
+				"source":     v.Source,
+				"updated_at": v.UpdatedAt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(addList), len(upList), nil
+}
+
+// 导出全部别名为 alias.json 格式
+func ExportAlias() map[string]string {
+	var list []models.IptvEpgAlias
+	dao.DB.Model(&models.IptvEpgAlias{}).Find(&list)
+	res := make(map[string]string, len(list))
+	for _, v := range list {
+		res[v.Alias] = v.Name
+	}
+	return res
+}
+
+// map 按 key 排序输出，便于比对
+func ExportAliasJson() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(ExportAlias()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 将数据库中的别名同步写入 /config/alias.json，供引擎模糊匹配使用
+func WriteAliasFile() error {
+	data, err := ExportAliasJson()
+	if err != nil {
+		return err
+	}
+	tmp := AliasFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, AliasFile)
+}
+
+// 别名映射，key 为小写别名
+func getAliasMap() map[string]string {
+	var list []models.IptvEpgAlias
+	dao.DB.Model(&models.IptvEpgAlias{}).Select("alias, name").Find(&list)
+	res := make(map[string]string, len(list))
+	for _, v := range list {
+		res[strings.ToLower(v.Alias)] = v.Name
+	}
+	return res
+}
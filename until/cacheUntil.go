@@ -13,6 +13,7 @@ import (
 var Cache *SignalExecutor
 
 type SignalExecutor struct {
+	name      string
 	delay     time.Duration
 	execFunc  func(ctx context.Context)
 	signalCh  chan struct{}
@@ -24,7 +25,13 @@ type SignalExecutor struct {
 
 // 创建 SignalExecutor 实例
 func NewSignalExecutor(delay time.Duration, execFunc func(ctx context.Context)) *SignalExecutor {
+	return NewNamedSignalExecutor("EPG缓存重建任务", delay, execFunc)
+}
+
+// 创建带任务名称的 SignalExecutor 实例
+func NewNamedSignalExecutor(name string, delay time.Duration, execFunc func(ctx context.Context)) *SignalExecutor {
 	return &SignalExecutor{
+		name:     name,
 		delay:    delay,
 		execFunc: execFunc,
 		signalCh: make(chan struct{}, 1),
@@ -38,7 +45,7 @@ func (s *SignalExecutor) Start() {
 		for {
 			select {
 			case <-s.stopCh:
-				log.Println("🛑 EPG缓存重建定时任务 已停止")
+				log.Println("🛑", s.name, "已停止")
 				return
 			case <-s.signalCh:
 				s.handleSignal()
@@ -76,7 +83,7 @@ func (s *SignalExecutor) handleSignal() {
 
 	// 如果任务正在执行 → 先中断
 	if s.cancel != nil {
-		log.Println("⛔ 中断当前执行EPG缓存重建任务")
+		log.Println("⛔ 中断当前执行", s.name)
 		s.cancel()
 		s.cancel = nil
 	}
@@ -85,12 +92,12 @@ func (s *SignalExecutor) handleSignal() {
 	if s.waitTimer != nil {
 		s.waitTimer.Stop()
 		s.waitTimer.Reset(s.delay)
-		log.Println("🔁 重置EPG缓存重建任务等待 10 秒")
+		log.Printf("🔁 重置%s等待 %v", s.name, s.delay)
 		return
 	}
 
 	// 新建计时器
-	log.Println("⏳ 收到EPG缓存重建任务，10 秒后执行")
+	log.Printf("⏳ 收到%s，%v 后执行", s.name, s.delay)
 	s.waitTimer = time.AfterFunc(s.delay, func() {
 		s.timerMu.Lock()
 		ctx, cancel := context.WithCancel(context.Background())
@@ -98,7 +105,7 @@ func (s *SignalExecutor) handleSignal() {
 		s.waitTimer = nil
 		s.timerMu.Unlock()
 
-		log.Println("🚀 开始执行EPG缓存重建任务")
+		log.Println("🚀 开始执行", s.name)
 		s.execFunc(ctx)
 	})
 }
@@ -145,6 +152,7 @@ func InitCacheRebuild() {
 
 	// 启动执行器
 	Cache.Start()
+	initAliasBind()
 	go initEpgCache()
 	select {}
 }
@@ -0,0 +1,91 @@
+package until
+
+import (
+	"bufio"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 机房/VPN IP段列表，每行一个CIDR或IP，#开头为注释
+const VpnCidrFile = "/config/vpn_cidr.txt"
+
+var vpnCidr struct {
+	sync.RWMutex
+	modTime time.Time
+	nets    []*net.IPNet
+}
+
+// 文件变更后重新加载
+func loadVpnCidr() []*net.IPNet {
+	info, err := os.Stat(VpnCidrFile)
+	if err != nil {
+		vpnCidr.Lock()
+		vpnCidr.nets, vpnCidr.modTime = nil, time.Time{}
+		vpnCidr.Unlock()
+		return nil
+	}
+
+	vpnCidr.RLock()
+	if info.ModTime().Equal(vpnCidr.modTime) {
+		nets := vpnCidr.nets
+		vpnCidr.RUnlock()
+		return nets
+	}
+	vpnCidr.RUnlock()
+
+	nets := ParseCidrList(ReadFile(VpnCidrFile))
+	vpnCidr.Lock()
+	vpnCidr.nets, vpnCidr.modTime = nets, info.ModTime()
+	vpnCidr.Unlock()
+	log.Printf("加载机房/VPN IP段 %d 条\n", len(nets))
+	return nets
+}
+
+// 解析CIDR列表，单个IP按/32或/128处理，无效行忽略
+func ParseCidrList(text string) []*net.IPNet {
+	var nets []*net.IPNet
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "/") {
+			if ip := net.ParseIP(line); ip != nil {
+				if ip.To4() != nil {
+					line += "/32"
+				} else {
+					line += "/128"
+				}
+			}
+		}
+		if _, ipNet, err := net.ParseCIDR(line); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+func IsVpnIP(ipStr string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range loadVpnCidr() {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func VpnCidrCount() int {
+	return len(loadVpnCidr())
+}
@@ -0,0 +1,458 @@
+package until
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 自定义节目单来源ID
+const EpgProviderCustom = "custom"
+
+const (
+	customDaysBefore = 1 // 展开前几天的每周节目
+	customDaysAfter  = 7 // 展开后几天的每周节目
+	customMaxImport  = 50000
+	customTimeLayout = "2006-01-02 15:04"
+)
+
+var customCsvHeader = []string{"channel", "type", "weekdays", "start", "end", "title", "desc"}
+
+// ===== 自定义节目单 =====
+
+type customProvider struct{}
+
+func (p *customProvider) ID() string   { return EpgProviderCustom }
+func (p *customProvider) Name() string { return "自定义节目单" }
+
+func (p *customProvider) ListChannels() ([]string, error) {
+	var res []string
+	err := dao.DB.Model(&models.IptvEpgCustom{}).Where("status = 1").Distinct("channel").Order("channel").Pluck("channel", &res).Error
+	return res, err
+}
+
+func (p *customProvider) Programmes(channel string, start, end time.Time) ([]dto.Programme, error) {
+	if channel == "" {
+		return nil, errEpgNoChannel
+	}
+	var list []models.IptvEpgCustom
+	dao.DB.Model(&models.IptvEpgCustom{}).Where("status = 1 and lower(channel) = lower(?)", channel).Find(&list)
+
+	res := ExpandEpgCustom(list, channel, start, end)
+	if len(res) == 0 {
+		return nil, errEpgNotFound
+	}
+	return res, nil
+}
+
+// 展开自定义节目为具体节目，单次节目优先，与其重叠的每周节目不输出
+func ExpandEpgCustom(list []models.IptvEpgCustom, channel string, start, end time.Time) []dto.Programme {
+	type span struct{ s, e time.Time }
+	var onces []span
+	var res []dto.Programme
+
+	add := func(v models.IptvEpgCustom, tS, tE time.Time) {
+		desc := v.Desc
+		if desc == "" {
+			desc = v.Title
+		}
+		prog := dto.Programme{
+			Start:   tS.In(EpgLocation).Format(xmlTimeLayout),
+			Stop:    tE.In(EpgLocation).Format(xmlTimeLayout),
+			Channel: channel,
+			Title:   dto.Title{Lang: "zh", Value: v.Title},
+			Desc:    dto.Desc{Lang: "zh", Value: desc},
+		}
+		if inEpgRange(prog, start, end) {
+			res = append(res, prog)
+		}
+	}
+
+	for _, v := range list {
+		if v.Type != "once" || v.EndAt <= v.StartAt {
+			continue
+		}
+		tS, tE := time.Unix(v.StartAt, 0), time.Unix(v.EndAt, 0)
+		onces = append(onces, span{tS, tE})
+		add(v, tS, tE)
+	}
+
+	days := epgDays(start, end, customDaysBefore, customDaysAfter)
+	for _, v := range list {
+		if v.Type == "once" {
+			continue
+		}
+		sMin, ok1 := parseCustomClock(v.StartTime)
+		eMin, ok2 := parseCustomClock(v.EndTime)
+		if !ok1 || !ok2 {
+			continue
+		}
+		weekdays, err := parseCustomWeekdays(v.Weekdays)
+		if err != nil {
+			continue
+		}
+		for _, day := range days {
+			if len(weekdays) > 0 && !weekdays[int(day.Weekday())] {
+				continue
+			}
+			tS := day.Add(time.Duration(sMin) * time.Minute)
+			tE := day.Add(time.Duration(eMin) * time.Minute)
+			if !tE.After(tS) {
+				tE = tE.AddDate(0, 0, 1) // 跨天节目
+			}
+			overlap := false
+			for _, o := range onces {
+				if tS.Before(o.e) && tE.After(o.s) {
+					overlap = true
+					break
+				}
+			}
+			if !overlap {
+				add(v, tS, tE)
+			}
+		}
+	}
+	sortProgrammes(res)
+	return res
+}
+
+// 解析 15:04 为当天分钟数
+func parseCustomClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// 解析星期列表，0或7为周日，空表示每天；包含无效值时返回错误
+func parseCustomWeekdays(s string) (map[int]bool, error) {
+	res := make(map[int]bool)
+	s = strings.NewReplacer("，", ",", " ", ",", "、", ",").Replace(s)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 7 {
+			return nil, errors.New("星期格式错误: " + v + "，应为 0-7 的数字，0或7为周日")
+		}
+		res[n%7] = true
+	}
+	return res, nil
+}
+
+// 校验并规范化自定义节目
+func CheckEpgCustom(v *models.IptvEpgCustom) error {
+	v.Channel = strings.TrimSpace(v.Channel)
+	v.Title = strings.TrimSpace(v.Title)
+	v.Desc = strings.TrimSpace(v.Desc)
+	if v.Channel == "" || v.Title == "" {
+		return errors.New("频道和节目名称不能为空")
+	}
+
+	switch v.Type {
+	case "once":
+		if v.StartAt <= 0 || v.EndAt <= v.StartAt {
+			return errors.New("结束时间必须晚于开始时间")
+		}
+		v.Weekdays, v.StartTime, v.EndTime = "", "", ""
+	case "weekly", "":
+		v.Type = "weekly"
+		if _, ok := parseCustomClock(v.StartTime); !ok {
+			return errors.New("开始时间格式错误，应为 HH:MM")
+		}
+		if _, ok := parseCustomClock(v.EndTime); !ok {
+			return errors.New("结束时间格式错误，应为 HH:MM")
+		}
+		if v.StartTime == v.EndTime {
+			return errors.New("开始时间与结束时间不能相同")
+		}
+		days, err := parseCustomWeekdays(v.Weekdays)
+		if err != nil {
+			return err
+		}
+		var list []int
+		for d := range days {
+			list = append(list, d)
+		}
+		sort.Ints(list)
+		var strList []string
+		for _, d := range list {
+			strList = append(strList, strconv.Itoa(d))
+		}
+		v.Weekdays = strings.Join(strList, ",")
+		v.StartAt, v.EndAt = 0, 0
+	default:
+		return errors.New("节目类型错误")
+	}
+	return nil
+}
+
+func ParseEpgCustomTime(s string) (int64, error) {
+	t, err := time.ParseInLocation(customTimeLayout, strings.TrimSpace(s), EpgLocation)
+	if err != nil {
+		return 0, errors.New("时间格式错误，应为 " + customTimeLayout)
+	}
+	return t.Unix(), nil
+}
+
+func FormatEpgCustomTime(ts int64) string {
+	if ts <= 0 {
+		return ""
+	}
+	return time.Unix(ts, 0).In(EpgLocation).Format(customTimeLayout)
+}
+
+// 自定义节目变更后同步EPG记录并重新绑定频道
+func SyncEpgCustom() {
+	var names []string
+	dao.DB.Model(&models.IptvEpgCustom{}).Where("status = 1").Distinct("channel").Pluck("channel", &names)
+
+	epgs := make([]models.IptvEpg, 0, len(names))
+	for _, name := range names {
+		epgs = append(epgs, models.IptvEpg{
+			Name:    name,
+			Status:  1,
+			Remarks: genEpgRemarks(name),
+		})
+	}
+
+	reload, _ := syncEpgsFrom(EpgProviderCustom, epgs, true)
+	if reload {
+		BindChannel()
+	}
+	CleanMealsEpgCacheAll()
+	log.Printf("自定义节目单同步完成，共 %d 个频道\n", len(epgs))
+}
+
+// ===== 导入导出 =====
+
+// 导出为CSV，带BOM便于Excel打开
+func ExportEpgCustomCsv() ([]byte, error) {
+	var list []models.IptvEpgCustom
+	if err := dao.DB.Model(&models.IptvEpgCustom{}).Order("channel, type desc, start_at, start_time").Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(&buf)
+	w.Write(customCsvHeader)
+	for _, v := range list {
+		start, end := v.StartTime, v.EndTime
+		if v.Type == "once" {
+			start, end = FormatEpgCustomTime(v.StartAt), FormatEpgCustomTime(v.EndAt)
+		}
+		w.Write([]string{v.Channel, v.Type, v.Weekdays, start, end, v.Title, v.Desc})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// 解析CSV，列顺序同导出：channel,type,weekdays,start,end,title,desc
+func ParseEpgCustomCsv(r io.Reader) ([]models.IptvEpgCustom, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var res []models.IptvEpgCustom
+	line := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, errors.New("CSV格式错误: " + err.Error())
+		}
+		if line == 1 && len(record) > 0 {
+			record[0] = strings.TrimPrefix(record[0], "\xEF\xBB\xBF")
+			if strings.EqualFold(strings.TrimSpace(record[0]), "channel") {
+				continue
+			}
+		}
+		if len(record) < 6 {
+			return nil, errors.New("第 " + strconv.Itoa(line) + " 行列数不足")
+		}
+
+		v := models.IptvEpgCustom{
+			Channel:  record[0],
+			Type:     strings.ToLower(strings.TrimSpace(record[1])),
+			Weekdays: record[2],
+			Title:    record[5],
+			Status:   1,
+		}
+		if len(record) > 6 {
+			v.Desc = record[6]
+		}
+		if v.Type == "once" {
+			if v.StartAt, err = ParseEpgCustomTime(record[3]); err != nil {
+				return nil, errors.New("第 " + strconv.Itoa(line) + " 行" + err.Error())
+			}
+			if v.EndAt, err = ParseEpgCustomTime(record[4]); err != nil {
+				return nil, errors.New("第 " + strconv.Itoa(line) + " 行" + err.Error())
+			}
+		} else {
+			v.StartTime = strings.TrimSpace(record[3])
+			v.EndTime = strings.TrimSpace(record[4])
+		}
+		if err := CheckEpgCustom(&v); err != nil {
+			return nil, errors.New("第 " + strconv.Itoa(line) + " 行" + err.Error())
+		}
+		res = append(res, v)
+		if len(res) > customMaxImport {
+			return nil, errors.New("导入条数过多")
+		}
+	}
+	return res, nil
+}
+
+// 导出为XMLTV，每周节目按默认范围展开
+func ExportEpgCustomXml() dto.XmlTV {
+	res := dto.XmlTV{
+		GeneratorName: "清和IPTV管理系统",
+		GeneratorURL:  "https://www.qingh.xyz",
+	}
+	var list []models.IptvEpgCustom
+	dao.DB.Model(&models.IptvEpgCustom{}).Where("status = 1").Order("channel").Find(&list)
+
+	group := make(map[string][]models.IptvEpgCustom)
+	var names []string
+	for _, v := range list {
+		if _, ok := group[v.Channel]; !ok {
+			names = append(names, v.Channel)
+		}
+		group[v.Channel] = append(group[v.Channel], v)
+	}
+	for _, name := range names {
+		res.Channels = append(res.Channels, dto.XmlChannel{
+			ID:          name,
+			DisplayName: []dto.DisplayName{{Lang: "zh", Value: name}},
+		})
+		res.Programmes = append(res.Programmes, ExpandEpgCustom(group[name], name, time.Time{}, time.Time{})...)
+	}
+	return res
+}
+
+// 解析XMLTV，节目全部作为单次节目导入，频道使用第一个 display-name
+func ParseEpgCustomXml(data []byte) ([]models.IptvEpgCustom, error) {
+	var tv dto.XmlTV
+	if err := xml.Unmarshal(data, &tv); err != nil {
+		return nil, errors.New("XMLTV格式错误: " + err.Error())
+	}
+
+	names := make(map[string]string, len(tv.Channels))
+	for _, c := range tv.Channels {
+		if len(c.DisplayName) > 0 && strings.TrimSpace(c.DisplayName[0].Value) != "" {
+			names[c.ID] = strings.TrimSpace(c.DisplayName[0].Value)
+		}
+	}
+
+	var res []models.IptvEpgCustom
+	for _, p := range tv.Programmes {
+		tS, err := ParseXmlTime(p.Start)
+		if err != nil {
+			continue
+		}
+		tE, err := ParseXmlTime(p.Stop)
+		if err != nil {
+			continue
+		}
+		name, ok := names[p.Channel]
+		if !ok {
+			name = p.Channel
+		}
+		v := models.IptvEpgCustom{
+			Channel: name,
+			Type:    "once",
+			Title:   p.Title.Value,
+			Desc:    p.Desc.Value,
+			StartAt: tS.Unix(),
+			EndAt:   tE.Unix(),
+			Status:  1,
+		}
+		if v.Desc == v.Title {
+			v.Desc = ""
+		}
+		if CheckEpgCustom(&v) != nil {
+			continue
+		}
+		res = append(res, v)
+		if len(res) > customMaxImport {
+			return nil, errors.New("导入条数过多")
+		}
+	}
+	return res, nil
+}
+
+// 导入自定义节目，replace 为 true 时先清空文件中涉及频道的已有节目，返回新增数量
+func ImportEpgCustom(list []models.IptvEpgCustom, replace bool) (int, error) {
+	channels := make(map[string]struct{})
+	for _, v := range list {
+		channels[v.Channel] = struct{}{}
+	}
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+
+	key := func(v models.IptvEpgCustom) string {
+		return strings.Join([]string{v.Channel, v.Type, v.Weekdays, v.StartTime, v.EndTime,
+			strconv.FormatInt(v.StartAt, 10), strconv.FormatInt(v.EndAt, 10), v.Title}, "|")
+	}
+
+	addList := make([]models.IptvEpgCustom, 0, len(list))
+	err := dao.DB.Transaction(func(tx *gorm.DB) error {
+		exist := make(map[string]struct{})
+		if replace {
+			if len(names) > 0 {
+				if err := tx.Where("channel in ?", names).Delete(&models.IptvEpgCustom{}).Error; err != nil {
+					return err
+				}
+			}
+		} else {
+			var old []models.IptvEpgCustom
+			if len(names) > 0 {
+				tx.Model(&models.IptvEpgCustom{}).Where("channel in ?", names).Find(&old)
+			}
+			for _, v := range old {
+				exist[key(v)] = struct{}{}
+			}
+		}
+
+		now := time.Now().Unix()
+		for _, v := range list {
+			k := key(v)
+			if _, ok := exist[k]; ok {
+				continue
+			}
+			exist[k] = struct{}{}
+			v.ID = 0
+			v.UpdatedAt = now
+			addList = append(addList, v)
+		}
+		if len(addList) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&addList, 500).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(addList), nil
+}
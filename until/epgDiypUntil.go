@@ -0,0 +1,252 @@
+package until
+
+import (
+	"encoding/json"
+	"errors"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	diypDaysBefore = 1 // 拉取前几天的节目单
+	diypDaysAfter  = 2 // 拉取后几天的节目单
+	diypWorkers    = 5 // 并发请求数
+)
+
+// DIYP 接口在无数据时常返回的占位节目
+var diypPlaceholder = []string{"精彩节目", "暂无节目", "节目以实际播出为准"}
+
+// 拼接 DIYP 请求地址，支持 {ch}、{date} 占位符，否则追加 ch、date 参数
+func GetDiypUrl(base, ch, date string) string {
+	base = strings.TrimSpace(base)
+	if strings.Contains(base, "{ch}") || strings.Contains(base, "{date}") {
+		base = strings.ReplaceAll(base, "{ch}", url.QueryEscape(ch))
+		return strings.ReplaceAll(base, "{date}", date)
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+		if strings.HasSuffix(base, "?") || strings.HasSuffix(base, "&") {
+			sep = ""
+		}
+	}
+	return base + sep + "ch=" + url.QueryEscape(ch) + "&date=" + date
+}
+
+func GetEpgDiyp(list models.IptvEpgList, ch string, date time.Time) (dto.DiypData, error) {
+	var data dto.DiypData
+	if ch == "" {
+		return data, errors.New("ch is empty")
+	}
+	dateStr := date.Format("2006-01-02")
+	cacheKey := "diyp_" + strconv.FormatInt(list.ID, 10) + "_" + Md5Hex(strings.ToUpper(ch)) + "_" + dateStr
+
+	if dao.Cache.Exists(cacheKey) {
+		if err := dao.Cache.GetJSON(cacheKey, &data); err == nil {
+			return data, nil
+		}
+	}
+
+	jsonStr := GetUrlData(GetDiypUrl(list.Url, ch, dateStr), list.UA)
+	if jsonStr == "" {
+		return data, errors.New("请求失败")
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
+		return data, err
+	}
+	if data.Date == "" {
+		data.Date = dateStr
+	}
+	if dao.Cache.SetJSON(cacheKey, data) != nil {
+		dao.Cache.Delete(cacheKey)
+	}
+	return data, nil
+}
+
+// 判断是否为有效节目单（排除占位数据）
+func isDiypValid(data dto.DiypData) bool {
+	for _, p := range data.EpgData {
+		if p.Title != "" && !isDiypPlaceholder(p.Title) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDiypPlaceholder(title string) bool {
+	for _, v := range diypPlaceholder {
+		if strings.Contains(title, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// DIYP 节目单转换为 XMLTV 节目
+func ConvertDiypToProgrammes(data dto.DiypData, eName string) []dto.Programme {
+	day, err := time.ParseInLocation("2006-01-02", data.Date, EpgLocation)
+	if err != nil {
+		return nil
+	}
+
+	parse := func(hm string) (time.Time, bool) {
+		t, err := time.ParseInLocation("15:04", strings.TrimSpace(hm), EpgLocation)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), true
+	}
+
+	var res []dto.Programme
+	for i, p := range data.EpgData {
+		if p.Title == "" {
+			continue
+		}
+		start, ok := parse(p.Start)
+		if !ok {
+			continue
+		}
+		end, ok := parse(p.End)
+		if !ok && i+1 < len(data.EpgData) {
+			end, ok = parse(data.EpgData[i+1].Start)
+		}
+		if !ok {
+			end = day.AddDate(0, 0, 1)
+		}
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1) // 跨天节目
+		}
+		desc := p.Desc
+		if desc == "" {
+			desc = p.Title
+		}
+		res = append(res, dto.Programme{
+			Start:   start.Format(xmlTimeLayout),
+			Stop:    end.Format(xmlTimeLayout),
+			Channel: eName,
+			Title:   dto.Title{Lang: "zh", Value: p.Title},
+			Desc:    dto.Desc{Lang: "zh", Value: desc},
+		})
+	}
+	return res
+}
+
+// 获取来源下已绑定频道的EPG
+func getDiypBoundEpgs(listId int64) []models.IptvEpg {
+	var epgs []models.IptvEpg
+	dao.DB.Model(&models.IptvEpg{}).
+		Where("status = 1 and id in (?)", dao.DB.Model(&models.IptvChannel{}).Select("distinct e_id").Where("status = 1 and e_id > 0")).
+		Find(&epgs)
+
+	id := strconv.FormatInt(listId, 10)
+	res := make([]models.IptvEpg, 0, len(epgs))
+	for _, epg := range epgs {
+		for _, from := range strings.Split(epg.FromListStr, ",") {
+			if from == id {
+				res = append(res, epg)
+				break
+			}
+		}
+	}
+	return res
+}
+
+type diypJob struct {
+	name string
+	date time.Time
+}
+
+// 并发拉取DIYP节目单，返回 名称 -> 节目单列表
+func fetchDiypAll(list models.IptvEpgList, jobs []diypJob) map[string][]dto.DiypData {
+	res := make(map[string][]dto.DiypData)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, diypWorkers)
+
+	for _, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(job diypJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			data, err := GetEpgDiyp(list, job.name, job.date)
+			if err != nil || !isDiypValid(data) {
+				return
+			}
+			mu.Lock()
+			res[job.name] = append(res[job.name], data)
+			mu.Unlock()
+		}(job)
+	}
+	wg.Wait()
+	return res
+}
+
+// DIYP 来源没有频道列表，使用已有EPG名称和频道名称探测当天节目单
+func updataDiypList(list models.IptvEpgList, newAdd bool) (bool, error) {
+	log.Println("更新DIYP EPG源: ", list.Name)
+	if strings.TrimSpace(list.Url) == "" {
+		return false, errors.New("URL错误:" + list.Url)
+	}
+	dao.Cache.Delete("diyp_" + strconv.FormatInt(list.ID, 10) + "_*")
+
+	nameSet := make(map[string]struct{})
+	var epgNames []string
+	dao.DB.Model(&models.IptvEpg{}).Where("status = 1").Pluck("name", &epgNames)
+	for _, n := range epgNames {
+		nameSet[n] = struct{}{}
+	}
+
+	aliasMap := getAliasMap()
+	var chNames []string
+	dao.DB.Model(&models.IptvChannel{}).Where("status = 1").Distinct("name").Pluck("name", &chNames)
+	for _, n := range chNames {
+		if a, ok := aliasMap[strings.ToLower(n)]; ok {
+			n = a
+		}
+		nameSet[n] = struct{}{}
+	}
+
+	today := time.Now()
+	jobs := make([]diypJob, 0, len(nameSet))
+	for n := range nameSet {
+		if n == "" {
+			continue
+		}
+		jobs = append(jobs, diypJob{name: n, date: today})
+	}
+	dataMap := fetchDiypAll(list, jobs)
+
+	var epgs []models.IptvEpg
+	for name := range dataMap {
+		epgs = append(epgs, models.IptvEpg{
+			Name:    name,
+			Status:  1,
+			Remarks: genEpgRemarks(name),
+		})
+	}
+	if len(epgs) == 0 {
+		return false, errors.New("未找到epg数据")
+	}
+	sort.Slice(epgs, func(i, j int) bool { return epgs[i].Name < epgs[j].Name })
+
+	dao.DB.Model(&models.IptvEpgList{}).Where("id = ?", list.ID).Updates(&models.IptvEpgList{Status: 1, LastTime: time.Now().Unix()})
+
+	log.Println("开始同步EPG")
+	reload, _ := SyncEpgs(list.ID, epgs, newAdd) // 同步
+	if reload {
+		go BindChannel() // 绑定频道
+	} else {
+		go CleanMealsEpgCacheAll()
+	}
+	log.Printf("DIYP EPG更新完成，共 %d 个频道\n", len(epgs))
+	return true, nil
+}
@@ -0,0 +1,422 @@
+package until
+
+import (
+	"encoding/json"
+	"errors"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EPG来源接口，内置来源（如CNTV）按固定ID注册，数据库中的来源按类型创建
+type EpgProvider interface {
+	ID() string   // 来源ID，对应 IptvEpg.FromListStr 中的值
+	Name() string // 来源名称
+	ListChannels() ([]string, error)
+	Programmes(channel string, start, end time.Time) ([]dto.Programme, error)
+}
+
+// 需要批量预取的来源实现此接口，带来源缓存批量获取时（GetEpgXml）会在首次使用前调用
+type epgPreloader interface {
+	Preload()
+}
+
+const xmlTimeLayout = "20060102150405 -0700"
+
+// 节目单时区
+var EpgLocation = loadEpgLocation()
+
+// CNTV 来源ID，沿用历史数据中的 "0"
+const EpgProviderCntv = "0"
+
+var (
+	epgProviderMu   sync.RWMutex
+	epgProviders    = make(map[string]EpgProvider)
+	epgProviderIDs  []string
+	epgSourceTypes  = make(map[string]func(list models.IptvEpgList) EpgProvider)
+	errEpgNotFound  = errors.New("未找到节目单")
+	errEpgNoChannel = errors.New("频道名称为空")
+)
+
+func init() {
+	RegisterEpgProvider(&customProvider{}) // 自定义节目单优先
+	RegisterEpgProvider(&cntvProvider{})
+	RegisterEpgSourceType("xmltv", func(list models.IptvEpgList) EpgProvider { return &xmltvProvider{list: list} })
+	RegisterEpgSourceType("diyp", func(list models.IptvEpgList) EpgProvider { return &diypProvider{list: list} })
+}
+
+func loadEpgLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return time.FixedZone("CST", 8*3600)
+	}
+	return loc
+}
+
+// 注册内置来源
+func RegisterEpgProvider(p EpgProvider) {
+	epgProviderMu.Lock()
+	defer epgProviderMu.Unlock()
+	if _, ok := epgProviders[p.ID()]; !ok {
+		epgProviderIDs = append(epgProviderIDs, p.ID())
+	}
+	epgProviders[p.ID()] = p
+}
+
+// 注册数据库来源类型（IptvEpgList.Type）
+func RegisterEpgSourceType(t string, f func(list models.IptvEpgList) EpgProvider) {
+	epgProviderMu.Lock()
+	defer epgProviderMu.Unlock()
+	epgSourceTypes[t] = f
+}
+
+// 内置来源列表，按注册顺序
+func GetFixedEpgProviders() []EpgProvider {
+	epgProviderMu.RLock()
+	defer epgProviderMu.RUnlock()
+	res := make([]EpgProvider, 0, len(epgProviderIDs))
+	for _, id := range epgProviderIDs {
+		res = append(res, epgProviders[id])
+	}
+	return res
+}
+
+func isFixedEpgProvider(id string) bool {
+	epgProviderMu.RLock()
+	defer epgProviderMu.RUnlock()
+	_, ok := epgProviders[id]
+	return ok
+}
+
+func NewEpgListProvider(list models.IptvEpgList) EpgProvider {
+	epgProviderMu.RLock()
+	f, ok := epgSourceTypes[list.Type]
+	if !ok {
+		f = epgSourceTypes["xmltv"]
+	}
+	epgProviderMu.RUnlock()
+	return f(list)
+}
+
+// 按ID获取来源，数据库来源只返回上线的
+func GetEpgProvider(id string) (EpgProvider, bool) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, false
+	}
+	epgProviderMu.RLock()
+	p, ok := epgProviders[id]
+	epgProviderMu.RUnlock()
+	if ok {
+		return p, true
+	}
+
+	var list models.IptvEpgList
+	if err := dao.DB.Model(&models.IptvEpgList{}).Where("id = ? and status = 1", id).First(&list).Error; err != nil {
+		return nil, false
+	}
+	if list.Url == "" || list.Name == "" {
+		return nil, false
+	}
+	return NewEpgListProvider(list), true
+}
+
+// 解析 FromListStr，内置来源优先，其余按原顺序
+func GetEpgProviders(fromListStr string, cache map[string]EpgProvider) []EpgProvider {
+	var fixed, others []EpgProvider
+	for _, id := range strings.Split(fromListStr, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		p, ok := cache[id]
+		if !ok {
+			p, ok = GetEpgProvider(id)
+			if !ok {
+				continue
+			}
+			if cache != nil {
+				if pl, ok := p.(epgPreloader); ok {
+					pl.Preload()
+				}
+				cache[id] = p
+			}
+		}
+		if isFixedEpgProvider(id) {
+			fixed = append(fixed, p)
+		} else {
+			others = append(others, p)
+		}
+	}
+	return append(fixed, others...)
+}
+
+// 依次从来源获取节目，返回第一个有数据的来源结果
+func GetEpgProgrammes(epg models.IptvEpg, start, end time.Time, cache map[string]EpgProvider) []dto.Programme {
+	for _, p := range GetEpgProviders(epg.FromListStr, cache) {
+		progs, err := p.Programmes(epg.Name, start, end)
+		if err == nil && len(progs) > 0 {
+			return progs
+		}
+	}
+	return nil
+}
+
+func ParseXmlTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(xmlTimeLayout, s); err == nil {
+		return t, nil
+	}
+	if len(s) >= 14 {
+		return time.ParseInLocation("20060102150405", s[:14], EpgLocation)
+	}
+	return time.Time{}, errors.New("时间格式错误: " + s)
+}
+
+// 节目是否与时间段有交集，start 为零值时不过滤
+func inEpgRange(p dto.Programme, start, end time.Time) bool {
+	if start.IsZero() && end.IsZero() {
+		return true
+	}
+	tS, err := ParseXmlTime(p.Start)
+	if err != nil {
+		return false
+	}
+	tE, err := ParseXmlTime(p.Stop)
+	if err != nil {
+		tE = tS
+	}
+	return tE.After(start) && tS.Before(end)
+}
+
+// 时间段内的自然日（上海时区），并限制在 [今天-before, 今天+after]
+func epgDays(start, end time.Time, before, after int) []time.Time {
+	now := time.Now().In(EpgLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, EpgLocation)
+	minDay := today.AddDate(0, 0, -before)
+	maxDay := today.AddDate(0, 0, after)
+
+	if start.IsZero() {
+		start = minDay
+	}
+	if end.IsZero() {
+		end = maxDay.AddDate(0, 0, 1)
+	}
+	s := start.In(EpgLocation)
+	day := time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, EpgLocation)
+
+	var res []time.Time
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if day.Before(minDay) || day.After(maxDay) {
+			continue
+		}
+		res = append(res, day)
+	}
+	return res
+}
+
+// ===== CNTV =====
+
+type cntvProvider struct{}
+
+var cntvChannels = []string{
+	"CCTV1", "CCTV2", "CCTV3", "CCTV4", "CCTV5", "CCTV5+", "CCTV6", "CCTV7", "CCTV8",
+	"CCTV9", "CCTV10", "CCTV11", "CCTV12", "CCTV13", "CCTV14", "CCTV15", "CCTV16", "CCTV17",
+}
+
+func (p *cntvProvider) ID() string   { return EpgProviderCntv }
+func (p *cntvProvider) Name() string { return "CCTV官网" }
+
+func (p *cntvProvider) ListChannels() ([]string, error) {
+	return cntvChannels, nil
+}
+
+func (p *cntvProvider) Programmes(channel string, start, end time.Time) ([]dto.Programme, error) {
+	if channel == "" {
+		return nil, errEpgNoChannel
+	}
+	name := channel
+	if strings.EqualFold(name, "cctv5+") || strings.EqualFold(name, "cctv-5+") {
+		name = "cctv5plus"
+	}
+
+	var res []dto.Programme
+	for _, day := range epgDays(start, end, 0, 1) {
+		data, err := GetEpgCntv(name, day.Format("20060102"))
+		if err != nil {
+			continue
+		}
+		for _, prog := range ConvertCntvToXml(data, channel).Programmes {
+			if inEpgRange(prog, start, end) {
+				res = append(res, prog)
+			}
+		}
+	}
+	if len(res) == 0 {
+		return nil, errEpgNotFound
+	}
+	return res, nil
+}
+
+// ===== XMLTV =====
+
+type xmltvProvider struct {
+	list models.IptvEpgList
+	once sync.Once
+	tv   dto.XmlTV
+}
+
+func (p *xmltvProvider) ID() string   { return strconv.FormatInt(p.list.ID, 10) }
+func (p *xmltvProvider) Name() string { return p.list.Name }
+
+func (p *xmltvProvider) load() *dto.XmlTV {
+	p.once.Do(func() {
+		p.tv = GetEpgListXml(p.list.Name, p.list.Url)
+	})
+	return &p.tv
+}
+
+func (p *xmltvProvider) ListChannels() ([]string, error) {
+	tv := p.load()
+	res := make([]string, 0, len(tv.Channels))
+	for _, c := range tv.Channels {
+		if len(c.DisplayName) > 0 && c.DisplayName[0].Value != "" {
+			res = append(res, c.DisplayName[0].Value)
+		}
+	}
+	return res, nil
+}
+
+func (p *xmltvProvider) Programmes(channel string, start, end time.Time) ([]dto.Programme, error) {
+	tv := p.load()
+	var srcID string
+	for _, c := range tv.Channels {
+		if len(c.DisplayName) > 0 && strings.EqualFold(c.DisplayName[0].Value, channel) {
+			srcID = c.ID
+			break
+		}
+	}
+	if srcID == "" {
+		return nil, errEpgNotFound
+	}
+
+	var res []dto.Programme
+	for _, prog := range tv.Programmes {
+		if prog.Channel == srcID && inEpgRange(prog, start, end) {
+			prog.Channel = channel
+			res = append(res, prog)
+		}
+	}
+	if len(res) == 0 {
+		return nil, errEpgNotFound
+	}
+	return res, nil
+}
+
+// ===== DIYP =====
+
+type diypProvider struct {
+	list    models.IptvEpgList
+	mu      sync.Mutex
+	preload map[string][]dto.DiypData
+}
+
+func (p *diypProvider) ID() string   { return strconv.FormatInt(p.list.ID, 10) }
+func (p *diypProvider) Name() string { return p.list.Name }
+
+// 批量并发拉取已绑定频道，避免逐个频道串行请求
+func (p *diypProvider) Preload() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.preload != nil {
+		return
+	}
+	var jobs []diypJob
+	for _, epg := range getDiypBoundEpgs(p.list.ID) {
+		for _, day := range epgDays(time.Time{}, time.Time{}, diypDaysBefore, diypDaysAfter) {
+			jobs = append(jobs, diypJob{name: epg.Name, date: day})
+		}
+	}
+	p.preload = fetchDiypAll(p.list, jobs)
+}
+
+func (p *diypProvider) ListChannels() ([]string, error) {
+	epgs := getDiypBoundEpgs(p.list.ID)
+	res := make([]string, 0, len(epgs))
+	for _, epg := range epgs {
+		res = append(res, epg.Name)
+	}
+	return res, nil
+}
+
+func (p *diypProvider) Programmes(channel string, start, end time.Time) ([]dto.Programme, error) {
+	if channel == "" {
+		return nil, errEpgNoChannel
+	}
+
+	p.mu.Lock()
+	dataList, ok := p.preload[channel]
+	p.mu.Unlock()
+	if !ok {
+		var jobs []diypJob
+		for _, day := range epgDays(start, end, diypDaysBefore, diypDaysAfter) {
+			jobs = append(jobs, diypJob{name: channel, date: day})
+		}
+		dataList = fetchDiypAll(p.list, jobs)[channel]
+	}
+
+	var res []dto.Programme
+	for _, data := range dataList {
+		for _, prog := range ConvertDiypToProgrammes(data, channel) {
+			if inEpgRange(prog, start, end) {
+				res = append(res, prog)
+			}
+		}
+	}
+	if len(res) == 0 {
+		return nil, errEpgNotFound
+	}
+	sortProgrammes(res)
+	return res, nil
+}
+
+func sortProgrammes(list []dto.Programme) {
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Start < list[j].Start })
+}
+
+// 获取指定日期的CNTV节目单，date 为 20060102，空为当天
+func GetEpgCntv(name, date string) (dto.CntvJsonChannel, error) {
+	if name == "" {
+		return dto.CntvJsonChannel{}, errors.New("id is empty")
+	}
+	name = strings.ToLower(name)
+	cacheKey := "cntv_" + strings.ToUpper(name) + "_" + date
+
+	var cntvJson dto.CntvData
+	if dao.Cache.Exists(cacheKey) {
+		if err := dao.Cache.GetJSON(cacheKey, &cntvJson); err == nil {
+			if ch, ok := cntvJson[name]; ok {
+				return ch, nil
+			}
+		}
+	}
+
+	epgUrl := "https://api.cntv.cn/epg/epginfo?c=" + name + "&serviceId=channel&d=" + date
+	jsonStr := GetUrlData(epgUrl)
+	if err := json.Unmarshal([]byte(jsonStr), &cntvJson); err != nil {
+		return dto.CntvJsonChannel{}, err
+	}
+	ch, ok := cntvJson[name]
+	if !ok || len(ch.Program) == 0 {
+		return dto.CntvJsonChannel{}, errEpgNotFound
+	}
+	if dao.Cache.SetJSON(cacheKey, cntvJson) != nil {
+		dao.Cache.Delete(cacheKey)
+	}
+	return ch, nil
+}
@@ -1,7 +1,6 @@
 package until
 
 import (
-	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -10,7 +9,6 @@ import (
 	"go-iptv/models"
 	"log"
 	"regexp"
-	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -80,44 +78,14 @@ func GetEpgListXml(name, url string) dto.XmlTV {
 	return xmlTV
 }
 
-func GetEpgCntv(name string) (dto.CntvJsonChannel, error) {
-
-	var cacheKey = "cntv_" + strings.ToUpper(name)
-
-	var cntvJson dto.CntvData
-
-	if name == "" {
-		return dto.CntvJsonChannel{}, errors.New("id is empty")
-	}
-	name = strings.ToLower(name)
-
-	epgUrl := "https://api.cntv.cn/epg/epginfo?c=" + name + "&serviceId=channel&d="
-
-	readCacheOk := false
-	if dao.Cache.Exists(cacheKey) {
-		err := dao.Cache.GetJSON(cacheKey, cntvJson)
-		if err == nil {
-			readCacheOk = true
-		}
-	}
-
-	if !readCacheOk {
-		jsonStr := GetUrlData(epgUrl)
-		err := json.Unmarshal([]byte(jsonStr), &cntvJson)
-		if err != nil {
-			return dto.CntvJsonChannel{}, err
-		}
-		if dao.Cache.SetJSON(cacheKey, cntvJson) != nil {
-			dao.Cache.Delete(cacheKey)
-		}
-	}
-	return cntvJson[name], nil
-}
-
 func UpdataEpgList() bool {
 	var epgLists []models.IptvEpgList
 	dao.DB.Model(&models.IptvEpgList{}).Find(&epgLists)
 	for _, list := range epgLists {
+		if list.Type == "diyp" {
+			UpdataEpgListOne(list, false)
+			continue
+		}
 		log.Println("更新EPG源: ", list.Name)
 		cacheKey := "epgXmlFrom_" + list.Name
 		dao.Cache.Delete(cacheKey)
@@ -129,36 +97,15 @@ func UpdataEpgList() bool {
 			}
 			var xmlTV dto.XmlTV
 			if xml.Unmarshal(xmlByte, &xmlTV) != nil {
+				ReportEpgFailed(list, "xml解析失败")
 				continue
 			}
 			var epgs []models.IptvEpg
-			// 1️⃣ 匹配数字台，如 CCTV1、CCTV-5+、CCTV13 等
-			reNum := regexp.MustCompile(`(?i)CCTV-?(\d+\+?)$`)
-
-			// 2️⃣ 匹配字母台，如 CCTV4EUO、CCTV4AME、CCTVF、CCTVE 等
-			reAlpha := regexp.MustCompile(`(?i)CCTV(\d*[A-Z]+)`)
 			for _, channel := range xmlTV.Channels {
-				remarks := channel.DisplayName[0].Value
-				upper := strings.ToUpper(remarks)
-				if strings.Contains(upper, "CCTV") {
-					switch {
-					case reNum.MatchString(upper):
-						match := reNum.FindStringSubmatch(upper)
-						num := match[1]
-						remarks = fmt.Sprintf("CCTV%s|CCTV-%s|CCTV%s 4K|CCTV-%s 4K|CCTV%s HD|CCTV-%s HD", num, num, num, num, num, num)
-
-					case reAlpha.MatchString(upper):
-						match := reAlpha.FindStringSubmatch(upper)
-						suffix := match[1]
-						remarks = fmt.Sprintf("CCTV%s|CCTV-%s", suffix, suffix)
-					}
-				} else {
-					remarks = fmt.Sprintf("%s|%s 4K|%s HD", remarks, remarks, remarks)
-				}
 				epgs = append(epgs, models.IptvEpg{
 					Name:    channel.DisplayName[0].Value,
 					Status:  1,
-					Remarks: remarks,
+					Remarks: genEpgRemarks(channel.DisplayName[0].Value),
 				})
 			}
 			if len(epgs) > 0 {
@@ -172,14 +119,27 @@ func UpdataEpgList() bool {
 				} else {
 					go CleanMealsEpgCacheAll()
 				}
+			} else {
+				ReportEpgFailed(list, "未找到epg数据")
 			}
+		} else {
+			ReportEpgFailed(list, "获取EPG数据失败")
 		}
 	}
 	log.Println("EPG列表更新完成")
 	return true
 }
 
-func UpdataEpgListOne(list models.IptvEpgList, newAdd bool) (bool, error) {
+func UpdataEpgListOne(list models.IptvEpgList, newAdd bool) (ok bool, err error) {
+	defer func() {
+		// 新添加的EPG源由添加接口直接返回错误
+		if err != nil && !newAdd {
+			ReportEpgFailed(list, err.Error())
+		}
+	}()
+	if list.Type == "diyp" {
+		return updataDiypList(list, newAdd)
+	}
 	log.Println("更新EPG源: ", list.Name)
 	cacheKey := "epgXmlFrom_" + list.Name
 	dao.Cache.Delete(cacheKey)
@@ -194,37 +154,14 @@ func UpdataEpgListOne(list models.IptvEpgList, newAdd bool) (bool, error) {
 			return false, errors.New("xml解析失败")
 		}
 		var epgs []models.IptvEpg
-		// 1️⃣ 匹配数字台，如 CCTV1、CCTV-5+、CCTV13 等
-		reNum := regexp.MustCompile(`(?i)CCTV-?(\d+\+?)$`)
-
-		// 2️⃣ 匹配字母台，如 CCTV4EUO、CCTV4AME、CCTVF、CCTVE 等
-		reAlpha := regexp.MustCompile(`(?i)CCTV(\d*[A-Z]+)`)
 		for _, channel := range xmlTV.Channels {
-			remarks := channel.DisplayName[0].Value
-			if remarks == "" {
+			if channel.DisplayName[0].Value == "" {
 				continue
 			}
-			upper := strings.ToUpper(remarks)
-			if strings.Contains(upper, "CCTV") {
-				switch {
-				case reNum.MatchString(upper):
-					match := reNum.FindStringSubmatch(upper)
-					num := match[1]
-					remarks = fmt.Sprintf("CCTV%s|CCTV-%s|CCTV%s 4K|CCTV-%s 4K|CCTV%s HD|CCTV-%s HD", num, num, num, num, num, num)
-
-				case reAlpha.MatchString(upper):
-					match := reAlpha.FindStringSubmatch(upper)
-					suffix := match[1]
-					remarks = fmt.Sprintf("CCTV%s|CCTV-%s", suffix, suffix)
-				}
-			} else {
-				remarks = fmt.Sprintf("%s|%s 4K|%s HD", remarks, remarks, remarks)
-			}
-
 			epgs = append(epgs, models.IptvEpg{
 				Name:    channel.DisplayName[0].Value,
 				Status:  1,
-				Remarks: remarks,
+				Remarks: genEpgRemarks(channel.DisplayName[0].Value),
 			})
 		}
 		if len(epgs) > 0 {
@@ -248,6 +185,29 @@ func UpdataEpgListOne(list models.IptvEpgList, newAdd bool) (bool, error) {
 	return false, errors.New("URL错误:" + list.Url)
 }
 
+// 1️⃣ 匹配数字台，如 CCTV1、CCTV-5+、CCTV13 等
+var reCctvNum = regexp.MustCompile(`(?i)CCTV-?(\d+\+?)$`)
+
+// 2️⃣ 匹配字母台，如 CCTV4EUO、CCTV4AME、CCTVF、CCTVE 等
+var reCctvAlpha = regexp.MustCompile(`(?i)CCTV(\d*[A-Z]+)`)
+
+// 根据EPG名称生成自动绑定规则
+func genEpgRemarks(name string) string {
+	upper := strings.ToUpper(name)
+	if strings.Contains(upper, "CCTV") {
+		switch {
+		case reCctvNum.MatchString(upper):
+			num := reCctvNum.FindStringSubmatch(upper)[1]
+			return fmt.Sprintf("CCTV%s|CCTV-%s|CCTV%s 4K|CCTV-%s 4K|CCTV%s HD|CCTV-%s HD", num, num, num, num, num, num)
+		case reCctvAlpha.MatchString(upper):
+			suffix := reCctvAlpha.FindStringSubmatch(upper)[1]
+			return fmt.Sprintf("CCTV%s|CCTV-%s", suffix, suffix)
+		}
+		return name
+	}
+	return fmt.Sprintf("%s|%s 4K|%s HD", name, name, name)
+}
+
 func BindChannel() bool {
 	// ClearBind() // 清空绑定
 
@@ -256,6 +216,7 @@ func BindChannel() bool {
 		return false
 	}
 	channelCache := make(map[string][]models.IptvChannel)
+	aliasMap := getAliasMap() // 频道别名
 
 	var update = false
 	var upCaList []string
@@ -289,8 +250,14 @@ func BindChannel() bool {
 				continue
 			}
 
+			aliasName := aliasMap[strings.ToLower(channelData.Name)]
+			if aliasName != "" && strings.EqualFold(aliasName, epgData.Name) {
+				tmpList = append(tmpList, channelData.Name)
+				continue
+			}
+
 			for _, name := range nameList {
-				if strings.EqualFold(channelData.Name, name) || channelData.Name == name {
+				if strings.EqualFold(channelData.Name, name) || channelData.Name == name || (aliasName != "" && strings.EqualFold(aliasName, name)) {
 					tmpList = append(tmpList, channelData.Name)
 					break
 				}
@@ -376,6 +343,11 @@ func getCAKey(caList []string) string {
 // - 新数据中有但数据库没有的 → 新增
 // - 数据库中有但新数据中没有的 → 删除
 func SyncEpgs(fromId int64, epgs []models.IptvEpg, newAdd bool) (bool, error) {
+	return syncEpgsFrom(strconv.FormatInt(fromId, 10), epgs, newAdd)
+}
+
+// 按来源ID同步，内置来源（如自定义节目单）使用非数字ID
+func syncEpgsFrom(fromId string, epgs []models.IptvEpg, newAdd bool) (bool, error) {
 	// 1. 查询数据库中已有的记录
 	var oldEpgs []models.IptvEpg
 	if err := dao.DB.Model(&models.IptvEpg{}).Where("status = 1").Find(&oldEpgs).Error; err != nil {
@@ -414,7 +386,7 @@ func SyncEpgs(fromId int64, epgs []models.IptvEpg, newAdd bool) (bool, error) {
 			tmpList := strings.Split(o.FromListStr, ",")
 			exist := false
 			for i, v := range tmpList {
-				if v == fmt.Sprintf("%d", fromId) {
+				if v == fromId {
 					exist = true
 					tmpList = append(tmpList[:i], tmpList[i+1:]...)
 					break // 若只删除第一个匹配项
@@ -438,7 +410,7 @@ func SyncEpgs(fromId int64, epgs []models.IptvEpg, newAdd bool) (bool, error) {
 
 		for _, toAddOne := range toAdd {
 			oldList := strings.Split(toAddOne.FromListStr, ",")
-			tmpList := append(oldList, fmt.Sprintf("%d", fromId))
+			tmpList := append(oldList, fromId)
 			tmpList = RemoveEmptyStrings(tmpList)
 			toAddOne.FromListStr = strings.Join(tmpList, ",")
 
@@ -595,11 +567,15 @@ func GetEpgXml(channelList []models.IptvChannelShow) dto.XmlTV {
 	}
 
 	// ===== 核心缓存 =====
-	epgXmlExist := make(map[string]struct{})            // channel.Name 是否已生成
-	epgCache := make(map[int64]models.IptvEpg)          // IptvEpg 表缓存
-	epgListCache := make(map[string]models.IptvEpgList) // IptvEpgList 表缓存
-	channelIndex := make(map[string]int)                // epg.Name -> Channels index
-	epgXmlCache := make(map[string]*dto.XmlTV)          // 零重复解析缓存
+	epgXmlExist := make(map[string]struct{})      // channel.Name 是否已生成
+	epgCache := make(map[int64]models.IptvEpg)    // IptvEpg 表缓存
+	providerCache := make(map[string]EpgProvider) // EPG来源缓存，避免重复解析
+	channelIndex := make(map[string]int)          // epg.Name -> Channels index
+	progsCache := make(map[int64][]dto.Programme) // 同一EPG只获取一次
+
+	now := time.Now().In(EpgLocation)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, EpgLocation).AddDate(0, 0, -1)
+	end := start.AddDate(0, 0, 8)
 
 	for _, channel := range channelList {
 		if channel.EId <= 0 {
@@ -620,77 +596,20 @@ func GetEpgXml(channelList []models.IptvChannelShow) dto.XmlTV {
 			epg = tmp
 		}
 
-		fromList := strings.Split(epg.FromListStr, ",")
-		if len(fromList) == 0 {
-			continue
+		progs, ok := progsCache[epg.ID]
+		if !ok {
+			progs = GetEpgProgrammes(epg, start, end, providerCache)
+			progsCache[epg.ID] = progs
 		}
-
-		// ===== CNTV 优先 =====
-		if slices.Contains(fromList, "0") {
-			name := epg.Name
-			if strings.EqualFold(name, "cctv5+") || strings.EqualFold(name, "cctv-5+") {
-				name = "cctv5plus"
-			}
-
-			if tmpData, err := GetEpgCntv(name); err == nil {
-				tmpXml := ConvertCntvToXml(tmpData, name)
-
-				mergeChannel(&epgXml, channelIndex, name, channel.Name)
-
-				for _, p := range tmpXml.Programmes {
-					p2 := p
-					p2.Channel = name
-					epgXml.Programmes = append(epgXml.Programmes, p2)
-				}
-
-				epgXmlExist[channel.Name] = struct{}{}
-				continue
-			}
+		if len(progs) == 0 {
+			continue
 		}
 
-		// ===== 其他来源 =====
-		for _, from := range fromList {
-			if from == "" || from == "0" {
-				continue
-			}
-
-			epgFrom, ok := epgListCache[from]
-			if !ok {
-				var tmp models.IptvEpgList
-				if err := dao.DB.Where("id = ? and status = 1", from).First(&tmp).Error; err != nil {
-					continue
-				}
-				epgListCache[from] = tmp
-				epgFrom = tmp
-			}
-
-			if epgFrom.Url == "" || epgFrom.Name == "" {
-				continue
-			}
-
-			tmpXml := getEpgListXmlCached(epgFrom.Name, epgFrom.Url, epgXmlCache)
-
-			mergeChannel(&epgXml, channelIndex, epg.Name, channel.Name)
-
-			var srcID string
-			for _, c := range tmpXml.Channels {
-				if len(c.DisplayName) > 0 && c.DisplayName[0].Value == epg.Name {
-					srcID = c.ID
-					break
-				}
-			}
-
-			for _, p := range tmpXml.Programmes {
-				if p.Channel == srcID {
-					p2 := p
-					p2.Channel = epg.Name
-					epgXml.Programmes = append(epgXml.Programmes, p2)
-				}
-			}
-
-			epgXmlExist[channel.Name] = struct{}{}
-			break
+		if _, exist := channelIndex[epg.Name]; !exist {
+			epgXml.Programmes = append(epgXml.Programmes, progs...)
 		}
+		mergeChannel(&epgXml, channelIndex, epg.Name, channel.Name)
+		epgXmlExist[channel.Name] = struct{}{}
 	}
 
 	return epgXml
@@ -722,18 +641,3 @@ func mergeChannel(
 		},
 	})
 }
-
-func getEpgListXmlCached(
-	name, url string,
-	cache map[string]*dto.XmlTV,
-) *dto.XmlTV {
-
-	key := name + "|" + url
-	if tv, ok := cache[key]; ok {
-		return tv
-	}
-
-	tv := GetEpgListXml(name, url)
-	cache[key] = &tv
-	return &tv
-}
@@ -0,0 +1,205 @@
+package until
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ip2region xdb 离线IP库
+const GeoIPFile = "/config/ip2region.xdb"
+
+const (
+	xdbHeaderLen    = 256
+	xdbVectorCols   = 256
+	xdbVectorSize   = 8
+	xdbSegmentSize  = 14
+	xdbVectorLength = xdbVectorCols * xdbVectorCols * xdbVectorSize
+	geoCacheSize    = 4096
+)
+
+var geoDB struct {
+	sync.RWMutex
+	modTime time.Time
+	buf     []byte
+}
+
+// 加载xdb文件到内存，文件变更后重新加载
+func loadGeoDB() []byte {
+	info, err := os.Stat(GeoIPFile)
+	if err != nil {
+		geoDB.Lock()
+		geoDB.buf, geoDB.modTime = nil, time.Time{}
+		geoDB.Unlock()
+		return nil
+	}
+
+	geoDB.RLock()
+	if info.ModTime().Equal(geoDB.modTime) {
+		buf := geoDB.buf
+		geoDB.RUnlock()
+		return buf
+	}
+	geoDB.RUnlock()
+
+	geoDB.Lock()
+	defer geoDB.Unlock()
+	if info.ModTime().Equal(geoDB.modTime) {
+		return geoDB.buf
+	}
+	buf, err := os.ReadFile(GeoIPFile)
+	if err == nil {
+		err = CheckXdb(buf)
+	}
+	if err != nil {
+		log.Println("加载IP库失败:", err)
+		buf = nil
+	} else {
+		log.Printf("加载IP库成功，大小 %d 字节\n", len(buf))
+	}
+	geoDB.buf, geoDB.modTime = buf, info.ModTime()
+	geoCache.reset()
+	return buf
+}
+
+// 校验xdb文件格式
+func CheckXdb(buf []byte) error {
+	if len(buf) < xdbHeaderLen+xdbVectorLength+xdbSegmentSize {
+		return errors.New("文件过小，不是有效的xdb文件")
+	}
+	if _, err := searchXdb(buf, binary.BigEndian.Uint32(net.IPv4(1, 1, 1, 1).To4())); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 在xdb中查询IPv4地址
+func searchXdb(buf []byte, ip uint32) (string, error) {
+	il0, il1 := ip>>24&0xFF, ip>>16&0xFF
+	idx := xdbHeaderLen + int(il0)*xdbVectorCols*xdbVectorSize + int(il1)*xdbVectorSize
+	sPtr := binary.LittleEndian.Uint32(buf[idx:])
+	ePtr := binary.LittleEndian.Uint32(buf[idx+4:])
+	if int(ePtr)+xdbSegmentSize > len(buf) || ePtr < sPtr {
+		return "", errors.New("xdb索引损坏")
+	}
+
+	l, h := 0, int((ePtr-sPtr)/xdbSegmentSize)
+	for l <= h {
+		m := (l + h) >> 1
+		p := int(sPtr) + m*xdbSegmentSize
+		sip := binary.LittleEndian.Uint32(buf[p:])
+		eip := binary.LittleEndian.Uint32(buf[p+4:])
+		if ip < sip {
+			h = m - 1
+		} else if ip > eip {
+			l = m + 1
+		} else {
+			dataLen := int(binary.LittleEndian.Uint16(buf[p+8:]))
+			dataPtr := int(binary.LittleEndian.Uint32(buf[p+10:]))
+			if dataPtr+dataLen > len(buf) {
+				return "", errors.New("xdb数据损坏")
+			}
+			return string(buf[dataPtr : dataPtr+dataLen]), nil
+		}
+	}
+	return "", nil
+}
+
+// 格式化ip2region结果，如 "中国|0|广东省|深圳市|电信" -> "广东省深圳市电信"
+func formatXdbRegion(region string) string {
+	var parts []string
+	for i, v := range strings.Split(region, "|") {
+		if v == "" || v == "0" || (i == 0 && v == "中国") {
+			continue
+		}
+		if len(parts) > 0 && parts[len(parts)-1] == v {
+			continue
+		}
+		parts = append(parts, v)
+	}
+	return strings.Join(parts, "")
+}
+
+// 离线查询，未加载IP库或查询失败返回空
+func LookupGeoIP(ip net.IP) string {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return ""
+	}
+	buf := loadGeoDB()
+	if buf == nil {
+		return ""
+	}
+	region, err := searchXdb(buf, binary.BigEndian.Uint32(ip4))
+	if err != nil {
+		log.Println("IP库查询失败:", err)
+		return ""
+	}
+	return formatXdbRegion(region)
+}
+
+// IP库信息，未加载返回大小0
+func GeoIPInfo() (int, time.Time) {
+	buf := loadGeoDB()
+	geoDB.RLock()
+	defer geoDB.RUnlock()
+	return len(buf), geoDB.modTime
+}
+
+// IP地区LRU缓存
+type lruCache struct {
+	sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value string
+}
+
+var geoCache = newLruCache(geoCacheSize)
+
+func newLruCache(size int) *lruCache {
+	return &lruCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *lruCache) get(key string) (string, bool) {
+	c.Lock()
+	defer c.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*lruEntry).value, true
+	}
+	return "", false
+}
+
+func (c *lruCache) set(key, value string) {
+	c.Lock()
+	defer c.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*lruEntry).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry{key, value})
+	if c.ll.Len() > c.size {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.items, last.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lruCache) reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
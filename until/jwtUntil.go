@@ -1,6 +1,11 @@
 package until
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -11,6 +16,13 @@ import (
 var JwtKey = []byte("test")
 var RssKey = []byte("e10adc3949ba59abbe56e057f20f883e")
 
+// n 字节随机数的十六进制字符串
+func RandomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
 // ----------------------
 // 验证 JWT
 // ----------------------
@@ -28,9 +40,12 @@ func ParseJWT(tokenString string) (*jwt.Token, error) {
 // ----------------------
 // 生成 JWT
 // ----------------------
-func GenerateJWT(username string, duration time.Duration) (string, error) {
+// sid 为服务端会话ID，会话被吊销后 token 失效
+func GenerateJWT(username, role, sid string, duration time.Duration) (string, error) {
 	claims := jwt.MapClaims{
 		"username": username,
+		"role":     role,
+		"sid":      sid,
 		"exp":      time.Now().Add(duration).Unix(),
 	}
 
@@ -85,6 +100,47 @@ func GetAuthExp(claims jwt.MapClaims) int64 {
 	return int64(claims["exp"].(float64))
 }
 
+// 当前登录的会话ID
+func GetAuthSid(c *gin.Context) string {
+	auth, exists := c.Get("auth")
+	if !exists {
+		return ""
+	}
+	claims, ok := auth.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	sid, _ := claims["sid"].(string)
+	return sid
+}
+
+// 写入登录 Cookie，HTTPS 下启用 Secure 和 SameSite=Strict，HTTP 下使用 SameSite=Lax
+func SetAuthCookie(c *gin.Context, token string, maxAge int) {
+	setCookie(c, "token", token, maxAge, true)
+}
+
+func setCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	secure := IsHTTPS(c)
+	if secure {
+		c.SetSameSite(http.SameSiteStrictMode)
+	} else {
+		c.SetSameSite(http.SameSiteLaxMode)
+	}
+	c.SetCookie(name, value, maxAge, "/", "", secure, httpOnly)
+}
+
+// 与登录会话绑定的 CSRF token，页面脚本从 Cookie 读取后放入请求头
+func CsrfToken(sid string) string {
+	mac := hmac.New(sha256.New, JwtKey)
+	mac.Write([]byte("csrf:" + sid))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 写入 CSRF Cookie，需要被页面脚本读取，不能设置 HttpOnly
+func SetCsrfCookie(c *gin.Context, token string) {
+	setCookie(c, "csrf_token", token, 0, false)
+}
+
 func GenerateJWTRss(rssType, id string) (string, error) {
 	claims := jwt.MapClaims{
 		"type": rssType,
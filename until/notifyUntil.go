@@ -0,0 +1,86 @@
+package until
+
+import (
+	"go-iptv/dao"
+	"go-iptv/models"
+	"log"
+	"time"
+)
+
+// 通知级别
+const (
+	NotifyInfo    = "info"
+	NotifyWarning = "warning"
+	NotifyError   = "error"
+)
+
+// 通知来源
+const (
+	SourceList    = "list"
+	SourceEpg     = "epg"
+	SourceLicense = "license"
+	SourceApk     = "apk"
+	SourceUpdata  = "updata"
+	SourceDisk    = "disk"
+	SourceExpiry  = "expiry"
+)
+
+var notifySources = map[string]string{
+	SourceList:    "直播源",
+	SourceEpg:     "EPG源",
+	SourceLicense: "授权引擎",
+	SourceApk:     "APK编译",
+	SourceUpdata:  "在线升级",
+	SourceDisk:    "磁盘空间",
+	SourceExpiry:  "到期提醒",
+}
+
+func NotifySourceName(source string) string {
+	if name, ok := notifySources[source]; ok {
+		return name
+	}
+	return source
+}
+
+// 写入后台通知，key 相同的未读通知合并为一条，key 为空时按内容合并
+func Notify(level, source, key, message string) {
+	if dao.DB == nil {
+		return
+	}
+	if key == "" {
+		key = source + ":" + message
+	}
+	now := time.Now().Unix()
+	var old models.IptvNotification
+	if dao.DB.Where("notify_key = ? and status = 0", key).Order("id desc").First(&old).Error == nil {
+		dao.DB.Model(&models.IptvNotification{}).Where("id = ?", old.ID).Updates(map[string]interface{}{
+			"level":      level,
+			"message":    message,
+			"count":      old.Count + 1,
+			"updated_at": now,
+		})
+		return
+	}
+	err := dao.DB.Create(&models.IptvNotification{
+		Level:     level,
+		Source:    source,
+		Key:       key,
+		Message:   message,
+		Count:     1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}).Error
+	if err != nil {
+		log.Println("写入通知失败:", err)
+	}
+}
+
+// 未读通知数量，用于后台顶部角标
+func UnreadNotifications() int64 {
+	var count int64
+	if dao.DB == nil {
+		return 0
+	}
+	dao.DB.Model(&models.IptvNotification{}).Where("status = 0").Count(&count)
+	return count
+}
@@ -0,0 +1,77 @@
+package until
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var PANEL_MD5_KEY = "tvkey_"
+
+// argon2id 参数，参考 OWASP 推荐的最低配置
+const (
+	argonTime    = 2
+	argonMemory  = 19 * 1024
+	argonThreads = 1
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+var argonEncoding = base64.RawStdEncoding
+
+// HashPassword 使用 argon2id 和随机盐生成密码哈希，格式: $argon2id$v=19$m=,t=,p=$盐$哈希
+func HashPassword(password string) string {
+	salt := make([]byte, argonSaltLen)
+	rand.Read(salt)
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argonMemory, argonTime, argonThreads,
+		argonEncoding.EncodeToString(salt), argonEncoding.EncodeToString(key))
+}
+
+// 旧版密码哈希: md5(PANEL_MD5_KEY + 密码)
+func legacyHashPassword(password string) string {
+	h := md5.New()
+	h.Write([]byte(PANEL_MD5_KEY + password))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CheckPassword 校验密码，upgrade 为 true 时表示哈希为旧格式或参数过低，需要重新生成
+func CheckPassword(hash, password string) (ok bool, upgrade bool) {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		legacy := legacyHashPassword(password)
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(legacy)) == 1, true
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+	var version int
+	var memory, times uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &times, &threads); err != nil || threads == 0 {
+		return false, false
+	}
+	salt, err := argonEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	want, err := argonEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false, false
+	}
+	key := argon2.IDKey([]byte(password), salt, times, memory, threads, uint32(len(want)))
+	if subtle.ConstantTimeCompare(key, want) != 1 {
+		return false, false
+	}
+	return true, memory < argonMemory || times < argonTime
+}
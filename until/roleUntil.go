@@ -0,0 +1,77 @@
+package until
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 管理员角色
+const (
+	RoleOwner    = "owner"    // 所有者，全部权限
+	RoleOperator = "operator" // 运维，频道/EPG/套餐
+	RoleSupport  = "support"  // 客服，设备授权/客户账号
+	RoleReadOnly = "readonly" // 只读
+)
+
+// 开放接口密钥的调用记录在操作日志中使用的角色
+const RoleApiKey = "apikey"
+
+// 后台路由权限分组
+const (
+	PermUsers    = "users"    // 设备、授权、客户账号、卡密
+	PermChannels = "channels" // 频道、EPG、套餐、点播
+	PermSystem   = "system"   // 客户端、系统设置、授权、升级
+	PermAdmins   = "admins"   // 管理员管理
+)
+
+var Roles = []string{RoleOwner, RoleOperator, RoleSupport, RoleReadOnly}
+
+var roleNames = map[string]string{
+	RoleOwner:    "所有者",
+	RoleOperator: "运维",
+	RoleSupport:  "客服",
+	RoleReadOnly: "只读",
+	RoleApiKey:   "接口密钥",
+}
+
+var rolePerms = map[string][]string{
+	RoleOwner:    {PermUsers, PermChannels, PermSystem, PermAdmins},
+	RoleOperator: {PermChannels},
+	RoleSupport:  {PermUsers},
+	RoleReadOnly: {},
+}
+
+func IsRole(role string) bool {
+	_, ok := rolePerms[role]
+	return ok
+}
+
+func RoleName(role string) string {
+	if name, ok := roleNames[role]; ok {
+		return name
+	}
+	return role
+}
+
+func HasPerm(role, perm string) bool {
+	for _, p := range rolePerms[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// 当前登录管理员的角色，取自 JWT claims
+func GetAuthRole(c *gin.Context) string {
+	auth, exists := c.Get("auth")
+	if !exists {
+		return ""
+	}
+	claims, ok := auth.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	role, _ := claims["role"].(string)
+	return role
+}
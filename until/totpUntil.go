@@ -0,0 +1,109 @@
+package until
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// RFC 6238 TOTP，30秒步长，6位数字，HMAC-SHA1
+const (
+	totpPeriod = 30
+	totpDigits = 6
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// 生成 160 位随机密钥，base32 编码
+func NewTotpKey() string {
+	buf := make([]byte, 20)
+	rand.Read(buf)
+	return totpEncoding.EncodeToString(buf)
+}
+
+func totpCode(key string, counter uint64) (string, error) {
+	secret, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(key)))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000), nil
+}
+
+// 校验验证码，允许前后各一个步长的时间误差
+// 只接受大于 lastStep 的步长，返回本次匹配的步长，防止同一验证码重放
+func VerifyTotp(key, code string, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if key == "" || len(code) != totpDigits {
+		return 0, false
+	}
+	counter := uint64(time.Now().Unix() / totpPeriod)
+	for _, c := range []uint64{counter - 1, counter, counter + 1} {
+		if int64(c) <= lastStep {
+			continue
+		}
+		want, err := totpCode(key, c)
+		if err == nil && hmac.Equal([]byte(want), []byte(code)) {
+			return int64(c), true
+		}
+	}
+	return 0, false
+}
+
+// 验证器 App 使用的 otpauth 链接
+func TotpURI(issuer, account, key string) string {
+	v := url.Values{}
+	v.Set("secret", key)
+	v.Set("issuer", issuer)
+	v.Set("period", fmt.Sprint(totpPeriod))
+	v.Set("digits", fmt.Sprint(totpDigits))
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + v.Encode()
+}
+
+// 生成二维码 PNG 的 data URI
+func QrDataURI(content string) (string, error) {
+	png, err := qrcode.Encode(content, qrcode.Medium, 240)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png), nil
+}
+
+// 生成一次性恢复码，返回明文和哈希
+func NewRecoveryCodes(n int) ([]string, []string) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 5)
+		rand.Read(buf)
+		code := strings.ToLower(totpEncoding.EncodeToString(buf))
+		code = code[:4] + "-" + code[4:]
+		codes = append(codes, code)
+		hashes = append(hashes, RecoveryHash(code))
+	}
+	return codes, hashes
+}
+
+func RecoveryHash(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
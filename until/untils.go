@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"go-iptv/dao"
 	"io"
 	"io/fs"
 	"log"
@@ -42,11 +43,17 @@ func ReverseString(s string) string {
 	}
 	return string(runes)
 }
+
+// 是否为 HTTPS 访问，包括反向代理转发的请求
+func IsHTTPS(c *gin.Context) bool {
+	return c.Request.TLS != nil ||
+		c.Request.Header.Get("X-Forwarded-Proto") == "https" ||
+		c.Request.Header.Get("Front-End-Https") == "on"
+}
+
 func GetUrl(c *gin.Context) string {
 	protocol := "http://"
-	if c.Request.TLS != nil ||
-		c.Request.Header.Get("X-Forwarded-Proto") == "https" ||
-		c.Request.Header.Get("Front-End-Https") == "on" {
+	if IsHTTPS(c) {
 		protocol = "https://"
 	}
 	// 获取主机名
@@ -93,24 +100,43 @@ func GetUrlData(url string, ua ...string) string {
 	return string(body)
 }
 
+// 查询IP地区，优先使用离线IP库，未命中时按配置使用在线接口
 func GetIpRegion(ip string) string {
-	city := "局域网"
-	if !isPrivateIP(net.ParseIP(ip)) {
+	netIP := net.ParseIP(ip)
+	if netIP == nil {
+		return ""
+	}
+	if netIP.IsLoopback() || isPrivateIP(netIP) {
+		return "局域网"
+	}
+	if city, ok := geoCache.get(ip); ok {
 		return city
 	}
+
+	city := LookupGeoIP(netIP)
+	if city == "" && dao.GetConfig().System.IPRemote == 1 {
+		city = getRemoteIpRegion(ip)
+	}
+	if city != "" {
+		geoCache.set(ip, city)
+	}
+	return city
+}
+
+func getRemoteIpRegion(ip string) string {
 	url := "https://api.mir6.com/api/ip_json?ip=" + ip
 	jsonStr := GetUrlData(url)
 	var jsonMap map[string]interface{}
 	err := json.Unmarshal([]byte(jsonStr), &jsonMap)
 	if err != nil {
-		return city
+		return ""
 	}
 	if data, ok := jsonMap["data"].(map[string]interface{}); ok {
-		if cityStr, ok := data["location"].(string); ok && cityStr != "" {
-			city = cityStr
+		if cityStr, ok := data["location"].(string); ok {
+			return cityStr
 		}
 	}
-	return city
+	return ""
 }
 
 func isPrivateIP(ip net.IP) bool {
@@ -369,6 +395,22 @@ func IsSafe(input string) bool {
 	return true
 }
 
+// 导出CSV时，以 = + - @ 开头的单元格前加 ' ，避免表格软件当作公式执行
+func CsvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// 导入CSV时去掉 CsvCell 添加的前缀
+func CsvUncell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
 func GetFileModTimeStr(filePath string) (string, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -725,3 +767,39 @@ func FixPerm(path string) error {
 		return os.Chmod(p, 0777)
 	})
 }
+
+// JSON 对象转为表单参数，数组展开为多个值，布尔值转为 1/0
+func JsonToValues(raw []byte) (url.Values, error) {
+	values := url.Values{}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return values, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	for k, v := range m {
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+		for _, item := range items {
+			switch val := item.(type) {
+			case nil:
+			case bool:
+				if val {
+					values.Add(k, "1")
+				} else {
+					values.Add(k, "0")
+				}
+			case string:
+				values.Add(k, val)
+			default:
+				values.Add(k, fmt.Sprint(val))
+			}
+		}
+	}
+	return values, nil
+}
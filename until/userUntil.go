@@ -1,8 +1,6 @@
 package until
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"fmt"
 	"go-iptv/dao"
 	"go-iptv/models"
@@ -11,15 +9,6 @@ import (
 	"time"
 )
 
-var PANEL_MD5_KEY = "tvkey_"
-
-// HashPassword 使用 PANEL_MD5_KEY + 密码 做 md5
-func HashPassword(password string) string {
-	h := md5.New()
-	h.Write([]byte(PANEL_MD5_KEY + password))
-	return hex.EncodeToString(h.Sum(nil))
-}
-
 func CheckUserDay(users []models.IptvUserShow) []models.IptvUserShow {
 	now := time.Now().Unix()
 	for i, u := range users {
@@ -39,7 +28,18 @@ func CheckUserDay(users []models.IptvUserShow) []models.IptvUserShow {
 			statusDesc = days
 		} else if u.Status == -1 {
 			days = "未授权"
-			statusDesc = fmt.Sprintf("试用天数[%d]", remainDays)
+			statusDesc = days
+			if u.Exp > now {
+				days = fmt.Sprintf("试用剩%d天", remainDays)
+				statusDesc = fmt.Sprintf("试用天数[%d]", remainDays)
+			} else if u.Exp > 0 {
+				statusDesc = "试用已结束"
+			} else {
+				expDate = ""
+			}
+		} else if u.Status == 2 {
+			days = "过期"
+			statusDesc = "已过期"
 		} else if u.Exp > now {
 			statusDesc = "正常"
 			days = fmt.Sprintf("剩%d天", remainDays)
@@ -60,16 +60,26 @@ func PasswordReset() bool {
 		return false
 	}
 	log.Println("尝试重置密码为:", data)
-	res := dao.DB.Model(&models.IptvAdmin{}).Where("id = 1").Update("password", HashPassword(data))
-	if res.Error != nil {
-		log.Println("密码重置失败，数据库错误:", res.Error)
+	// 重置最早创建的所有者账号，同时重新启用
+	var admin models.IptvAdmin
+	if err := dao.DB.Model(&models.IptvAdmin{}).Where("role = ?", RoleOwner).Order("id asc").First(&admin).Error; err != nil {
+		log.Println("密码重置失败，没有找到管理员信息")
 		return false
 	}
-	if res.RowsAffected == 0 {
-		log.Println("密码重置失败，没有找到管理员信息")
+	// 同时关闭两步验证，用于丢失验证器时恢复登录
+	res := dao.DB.Model(&models.IptvAdmin{}).Where("id = ?", admin.ID).Updates(map[string]interface{}{
+		"password":       HashPassword(data),
+		"status":         1,
+		"totp":           0,
+		"totp_key":       "",
+		"recovery_codes": "",
+	})
+	if res.Error != nil {
+		log.Println("密码重置失败，数据库错误:", res.Error)
 		return false
 	}
-	log.Println("密码重置成功")
+	dao.DB.Where("admin_id = ?", admin.ID).Delete(&models.IptvAdminSession{})
+	log.Println("密码重置成功，管理员:", admin.UserName)
 	return true
 }
 
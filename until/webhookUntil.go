@@ -0,0 +1,247 @@
+package until
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-iptv/dao"
+	"go-iptv/dto"
+	"go-iptv/models"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook 事件
+const (
+	EventDeviceNew      = "device.unauthorized"     // 新的未授权设备
+	EventDeviceExpiring = "device.expiring"         // 设备即将到期
+	EventPlaylistFailed = "playlist.refresh_failed" // 直播源更新失败
+	EventEpgFailed      = "epg.refresh_failed"      // EPG源更新失败
+	EventApkBuilt       = "apk.build_succeeded"     // APK编译完成
+	EventApkFailed      = "apk.build_failed"        // APK编译失败
+	EventLicenseOffline = "license.disconnected"    // 授权引擎断开
+	EventTokenCreated   = "token.created"           // 订阅token创建
+	EventTokenRevoked   = "token.revoked"           // 订阅token禁用或删除
+	EventPing           = "ping"                    // 测试
+)
+
+var WebhookEvents = []dto.WebhookEvent{
+	{Key: EventDeviceNew, Name: "新的未授权设备"},
+	{Key: EventDeviceExpiring, Name: "设备即将到期"},
+	{Key: EventPlaylistFailed, Name: "直播源更新失败"},
+	{Key: EventEpgFailed, Name: "EPG源更新失败"},
+	{Key: EventApkBuilt, Name: "APK编译完成"},
+	{Key: EventApkFailed, Name: "APK编译失败"},
+	{Key: EventLicenseOffline, Name: "授权引擎断开"},
+	{Key: EventTokenCreated, Name: "订阅Token创建"},
+	{Key: EventTokenRevoked, Name: "订阅Token吊销"},
+}
+
+func WebhookEventName(event string) string {
+	for _, e := range WebhookEvents {
+		if e.Key == event {
+			return e.Name
+		}
+	}
+	if event == EventPing {
+		return "测试"
+	}
+	return event
+}
+
+// 投递失败后的重试间隔(秒)，全部用完后标记为失败
+var webhookBackoff = []int64{30, 120, 600, 1800}
+
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookMaxResponse = 500
+	webhookBatch       = 20
+)
+
+var webhookSignal = make(chan struct{}, 1)
+
+func notifyWebhookWorker() {
+	select {
+	case webhookSignal <- struct{}{}:
+	default:
+	}
+}
+
+// 触发事件，为订阅了该事件的 Webhook 写入待发送的投递记录
+func FireWebhook(event string, data interface{}) {
+	if dao.DB == nil {
+		return
+	}
+	var hooks []models.IptvWebhook
+	if err := dao.DB.Where("enable = 1").Find(&hooks).Error; err != nil {
+		log.Println("查询Webhook失败:", err)
+		return
+	}
+	for _, hook := range hooks {
+		for _, e := range strings.Split(hook.Events, ",") {
+			if e == event {
+				QueueWebhook(hook, event, data)
+				break
+			}
+		}
+	}
+}
+
+// 直播源更新失败，推送 Webhook 并写入通知
+func ReportListFailed(list models.IptvCategoryList, reason string) {
+	Notify(NotifyError, SourceList, "list:"+strconv.FormatInt(list.ID, 10), "直播源 "+list.Name+" 更新失败: "+reason)
+	FireWebhook(EventPlaylistFailed, map[string]interface{}{
+		"id":     list.ID,
+		"name":   list.Name,
+		"url":    list.Url,
+		"reason": reason,
+	})
+}
+
+// EPG源更新失败，推送 Webhook 并写入通知
+func ReportEpgFailed(list models.IptvEpgList, reason string) {
+	Notify(NotifyError, SourceEpg, "epg:"+strconv.FormatInt(list.ID, 10), "EPG源 "+list.Name+" 更新失败: "+reason)
+	FireWebhook(EventEpgFailed, map[string]interface{}{
+		"id":     list.ID,
+		"name":   list.Name,
+		"url":    list.Url,
+		"type":   list.Type,
+		"reason": reason,
+	})
+}
+
+// 写入单个 Webhook 的投递记录，由后台任务发送
+func QueueWebhook(hook models.IptvWebhook, event string, data interface{}) (models.IptvWebhookDelivery, error) {
+	now := time.Now().Unix()
+	uuid := RandomHex(16)
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":    uuid,
+		"event": event,
+		"time":  now,
+		"data":  data,
+	})
+	if err != nil {
+		return models.IptvWebhookDelivery{}, err
+	}
+	delivery := models.IptvWebhookDelivery{
+		WebhookID: hook.ID,
+		Event:     event,
+		Uuid:      uuid,
+		Payload:   string(payload),
+		NextAt:    now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := dao.DB.Create(&delivery).Error; err != nil {
+		log.Println("写入Webhook投递记录失败:", err)
+		return delivery, err
+	}
+	notifyWebhookWorker()
+	return delivery, nil
+}
+
+// 重新发送投递记录
+func RetryWebhookDeliveries(ids []string) int64 {
+	res := dao.DB.Model(&models.IptvWebhookDelivery{}).Where("id in (?) and status <> 0", ids).Updates(map[string]interface{}{
+		"status":   0,
+		"attempts": 0,
+		"next_at":  time.Now().Unix(),
+	})
+	notifyWebhookWorker()
+	return res.RowsAffected
+}
+
+// 签名：HMAC-SHA256(密钥, 时间戳 + "." + 请求体)
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// 后台发送 Webhook，启动时继续发送未完成的投递
+func WebhookWorker() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		for sendDueWebhooks() {
+		}
+		select {
+		case <-ticker.C:
+		case <-webhookSignal:
+		}
+	}
+}
+
+// 发送到期的投递，返回是否还有未处理的记录
+func sendDueWebhooks() bool {
+	var list []models.IptvWebhookDelivery
+	dao.DB.Where("status = 0 and next_at <= ?", time.Now().Unix()).Order("id").Limit(webhookBatch).Find(&list)
+	for _, d := range list {
+		var hook models.IptvWebhook
+		if err := dao.DB.Where("id = ?", d.WebhookID).First(&hook).Error; err != nil {
+			dao.DB.Model(&models.IptvWebhookDelivery{}).Where("id = ?", d.ID).Updates(map[string]interface{}{
+				"status": 2, "error": "Webhook已删除", "updated_at": time.Now().Unix(),
+			})
+			continue
+		}
+		sendWebhook(hook, d)
+	}
+	return len(list) == webhookBatch
+}
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+func sendWebhook(hook models.IptvWebhook, d models.IptvWebhookDelivery) {
+	body := []byte(d.Payload)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	updates := map[string]interface{}{
+		"attempts":   d.Attempts + 1,
+		"updated_at": time.Now().Unix(),
+	}
+
+	var errMsg string
+	req, err := http.NewRequest(http.MethodPost, hook.Url, bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "go-iptv-webhook/"+GetVersion())
+		req.Header.Set("X-Iptv-Event", d.Event)
+		req.Header.Set("X-Iptv-Delivery", d.Uuid)
+		req.Header.Set("X-Iptv-Timestamp", ts)
+		req.Header.Set("X-Iptv-Signature", WebhookSignature(hook.Secret, ts, body))
+		var resp *http.Response
+		resp, err = webhookClient.Do(req)
+		if err == nil {
+			data, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponse))
+			resp.Body.Close()
+			updates["code"] = resp.StatusCode
+			updates["response"] = string(data)
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				errMsg = "HTTP " + strconv.Itoa(resp.StatusCode)
+			}
+		}
+	}
+	if err != nil {
+		errMsg = err.Error()
+		updates["code"] = 0
+		updates["response"] = ""
+	}
+	updates["error"] = errMsg
+
+	switch {
+	case errMsg == "":
+		updates["status"] = 1
+	case int(d.Attempts) < len(webhookBackoff):
+		updates["next_at"] = time.Now().Unix() + webhookBackoff[d.Attempts]
+	default:
+		updates["status"] = 2
+		log.Printf("Webhook %s 投递 %s 失败: %s\n", hook.Name, d.Event, errMsg)
+	}
+	dao.DB.Model(&models.IptvWebhookDelivery{}).Where("id = ?", d.ID).Updates(updates)
+}
@@ -0,0 +1,158 @@
+package until
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"go-iptv/dao"
+	"go-iptv/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupWebhookDB(t *testing.T) {
+	t.Helper()
+	dao.InitDB(filepath.Join(t.TempDir(), "webhook.db"))
+	if err := dao.DB.AutoMigrate(&models.IptvWebhook{}, &models.IptvWebhookDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if db, err := dao.DB.DB(); err == nil {
+			db.Close()
+		}
+	})
+}
+
+func createWebhook(t *testing.T, url string) models.IptvWebhook {
+	t.Helper()
+	hook := models.IptvWebhook{Name: "test", Url: url, Secret: "s3cret", Events: EventPing, Enable: 1}
+	if err := dao.DB.Create(&hook).Error; err != nil {
+		t.Fatal(err)
+	}
+	return hook
+}
+
+func getDelivery(t *testing.T, id int64) models.IptvWebhookDelivery {
+	t.Helper()
+	var d models.IptvWebhookDelivery
+	if err := dao.DB.Where("id = ?", id).First(&d).Error; err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestWebhookSignature(t *testing.T) {
+	setupWebhookDB(t)
+
+	var (
+		mu     sync.Mutex
+		header http.Header
+		body   []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	hook := createWebhook(t, srv.URL)
+	d, err := QueueWebhook(hook, EventPing, map[string]interface{}{"msg": "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendDueWebhooks()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if header == nil {
+		t.Fatal("webhook not delivered")
+	}
+	if string(body) != d.Payload {
+		t.Fatalf("body = %s, want %s", body, d.Payload)
+	}
+	if got := header.Get("X-Iptv-Event"); got != EventPing {
+		t.Errorf("X-Iptv-Event = %q", got)
+	}
+	if got := header.Get("X-Iptv-Delivery"); got != d.Uuid {
+		t.Errorf("X-Iptv-Delivery = %q, want %q", got, d.Uuid)
+	}
+
+	// 接收方按文档自行计算签名
+	mac := hmac.New(sha256.New, []byte(hook.Secret))
+	mac.Write([]byte(header.Get("X-Iptv-Timestamp") + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := header.Get("X-Iptv-Signature"); got != want {
+		t.Errorf("X-Iptv-Signature = %q, want %q", got, want)
+	}
+
+	d = getDelivery(t, d.ID)
+	if d.Status != 1 || d.Attempts != 1 || d.Code != http.StatusNoContent || d.Error != "" {
+		t.Errorf("delivery = status %d attempts %d code %d error %q", d.Status, d.Attempts, d.Code, d.Error)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	setupWebhookDB(t)
+
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	hook := createWebhook(t, srv.URL)
+	d, err := QueueWebhook(hook, EventPing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, wait := range webhookBackoff {
+		before := time.Now().Unix()
+		sendDueWebhooks()
+		d = getDelivery(t, d.ID)
+		if d.Status != 0 || d.Attempts != int64(i+1) {
+			t.Fatalf("attempt %d: status %d attempts %d", i+1, d.Status, d.Attempts)
+		}
+		if d.Code != http.StatusInternalServerError || d.Error != "HTTP 500" {
+			t.Fatalf("attempt %d: code %d error %q", i+1, d.Code, d.Error)
+		}
+		if d.NextAt < before+wait || d.NextAt > time.Now().Unix()+wait {
+			t.Fatalf("attempt %d: next_at %d, want about %d", i+1, d.NextAt, before+wait)
+		}
+
+		// 未到重试时间不发送
+		sendDueWebhooks()
+		if got := getDelivery(t, d.ID).Attempts; got != d.Attempts {
+			t.Fatalf("attempt %d: sent before next_at", i+1)
+		}
+		dao.DB.Model(&models.IptvWebhookDelivery{}).Where("id = ?", d.ID).Update("next_at", 0)
+	}
+
+	// 重试次数用完后标记为失败
+	sendDueWebhooks()
+	d = getDelivery(t, d.ID)
+	if d.Status != 2 || d.Attempts != int64(len(webhookBackoff)+1) {
+		t.Fatalf("final: status %d attempts %d", d.Status, d.Attempts)
+	}
+	sendDueWebhooks()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != len(webhookBackoff)+1 {
+		t.Errorf("calls = %d, want %d", calls, len(webhookBackoff)+1)
+	}
+}